@@ -0,0 +1,424 @@
+// Package chiptan decodes the HHD challenges of the chipTAN optical and USB
+// procedures (HHD-UC) and encodes them as flicker code to be transmitted to a
+// TAN generator.
+//
+// The challenge is sent by the institute within the HITAN segment. A Code is
+// parsed from it with Parse and rendered by Render to the flicker code, which
+// carries the Luhn and XOR control digits. Frames returns the bit sequence
+// shown by renderers like terminal animations or SVG images.
+package chiptan
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version defines the HHD version of a challenge
+type Version int
+
+// Supported HHD versions
+const (
+	HHD13 Version = 13
+	HHD14 Version = 14
+)
+
+// lcLength returns the number of digits of the length of a challenge
+func (v Version) lcLength() int {
+	if v == HHD13 {
+		return 2
+	}
+	return 3
+}
+
+// Encoding defines the encoding of the data of a DataElement
+type Encoding int
+
+// Possible encodings
+const (
+	BCD Encoding = iota
+	ASCII
+)
+
+const (
+	// bitEncoding marks a data element as ASCII encoded
+	bitEncoding = 1 << 6
+	// bitControlByte marks that control bytes follow the start code
+	// length. Within a control byte it marks that another one follows.
+	bitControlByte = 1 << 7
+	// lengthMask masks the length of a data element
+	lengthMask = 0x3F
+	// maxControlBytes is the maximum number of control bytes
+	maxControlBytes = 9
+	// maxDataElements is the maximum number of data elements following the
+	// start code
+	maxDataElements = 3
+)
+
+// DataElement represents a data element of a HHD challenge
+type DataElement struct {
+	Data     string
+	Encoding Encoding
+}
+
+// StartCode represents the start code of a HHD challenge. It defines the
+// operation performed by the TAN generator.
+type StartCode struct {
+	DataElement
+	// ControlBytes are only used since HHD 1.4
+	ControlBytes []byte
+}
+
+// Code represents a HHD-UC challenge
+type Code struct {
+	Version   Version
+	StartCode StartCode
+	// DataElements contains up to three data elements, e.g. the account and
+	// the amount of a transfer
+	DataElements []DataElement
+}
+
+// Parse parses the HHD-UC challenge as sent by the institute. HHD 1.3
+// challenges may be enclosed by CHLGUC and CHLGTEXT like sent by some
+// institutes.
+func Parse(challenge string) (*Code, error) {
+	challenge = trimEnvelope(strings.TrimSpace(challenge))
+	code, err := parse(challenge, HHD14)
+	if err == nil {
+		return code, nil
+	}
+	code, hhd13Err := parse(challenge, HHD13)
+	if hhd13Err != nil {
+		return nil, fmt.Errorf("Malformed challenge %q: %v", challenge, err)
+	}
+	return code, nil
+}
+
+// trimEnvelope removes the CHLGUC prefix with its four digit length and the
+// CHLGTEXT suffix from challenge
+func trimEnvelope(challenge string) string {
+	start := strings.Index(challenge, "CHLGUC")
+	end := strings.Index(challenge, "CHLGTEXT")
+	if start == -1 || end == -1 || end < start+10 {
+		return challenge
+	}
+	return challenge[start+10 : end]
+}
+
+func parse(challenge string, version Version) (*Code, error) {
+	lcLength := version.lcLength()
+	if len(challenge) < lcLength {
+		return nil, fmt.Errorf("Missing length")
+	}
+	lc, err := strconv.Atoi(challenge[:lcLength])
+	if err != nil {
+		return nil, fmt.Errorf("Malformed length: %v", err)
+	}
+	rest := challenge[lcLength:]
+	if len(rest) != lc {
+		return nil, fmt.Errorf("Length mismatch: expected %d, got %d", lc, len(rest))
+	}
+	code := &Code{Version: version}
+	rest, err = code.parseStartCode(rest)
+	if err != nil {
+		return nil, err
+	}
+	for len(rest) > 0 {
+		if len(code.DataElements) == maxDataElements {
+			return nil, fmt.Errorf("Too many data elements")
+		}
+		var de DataElement
+		de, rest, err = parseDataElement(rest, version)
+		if err != nil {
+			return nil, err
+		}
+		code.DataElements = append(code.DataElements, de)
+	}
+	return code, nil
+}
+
+func (c *Code) parseStartCode(value string) (string, error) {
+	if len(value) < 2 {
+		return "", fmt.Errorf("Missing start code")
+	}
+	lde, err := strconv.ParseUint(value[:2], 16, 8)
+	if err != nil {
+		return "", fmt.Errorf("Malformed start code length: %v", err)
+	}
+	value = value[2:]
+	if c.Version == HHD14 && lde&bitControlByte != 0 {
+		for {
+			if len(c.StartCode.ControlBytes) == maxControlBytes {
+				return "", fmt.Errorf("Too many control bytes")
+			}
+			if len(value) < 2 {
+				return "", fmt.Errorf("Missing control byte")
+			}
+			controlByte, err := strconv.ParseUint(value[:2], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("Malformed control byte: %v", err)
+			}
+			value = value[2:]
+			c.StartCode.ControlBytes = append(c.StartCode.ControlBytes, byte(controlByte))
+			if controlByte&bitControlByte == 0 {
+				break
+			}
+		}
+	}
+	length := int(lde & lengthMask)
+	if len(value) < length {
+		return "", fmt.Errorf("Start code too short: expected %d, got %d", length, len(value))
+	}
+	c.StartCode.Data = value[:length]
+	if lde&bitEncoding != 0 {
+		c.StartCode.Encoding = ASCII
+	} else if !isNumeric(c.StartCode.Data) {
+		return "", fmt.Errorf("Malformed start code: %q is not numeric", c.StartCode.Data)
+	}
+	return value[length:], nil
+}
+
+func parseDataElement(value string, version Version) (DataElement, string, error) {
+	if len(value) < 2 {
+		return DataElement{}, "", fmt.Errorf("Missing data element length")
+	}
+	lde, err := strconv.Atoi(value[:2])
+	if err != nil {
+		return DataElement{}, "", fmt.Errorf("Malformed data element length: %v", err)
+	}
+	value = value[2:]
+	length := lde & lengthMask
+	if len(value) < length {
+		return DataElement{}, "", fmt.Errorf("Data element too short: expected %d, got %d", length, len(value))
+	}
+	de := DataElement{Data: value[:length]}
+	if (version == HHD14 && lde&bitEncoding != 0) || !isNumeric(de.Data) {
+		de.Encoding = ASCII
+	}
+	return de, value[length:], nil
+}
+
+// Render returns the flicker code of c as hexadecimal string. It consists of
+// the length, the start code and data elements and the Luhn and XOR control
+// digits.
+func (c *Code) Render() string {
+	var buf bytes.Buffer
+	buf.WriteString(c.renderStartCodeLength())
+	for _, controlByte := range c.StartCode.ControlBytes {
+		buf.WriteString(fmt.Sprintf("%02X", controlByte))
+	}
+	buf.WriteString(c.StartCode.render())
+	for _, de := range c.DataElements {
+		buf.WriteString(de.renderLength(c.Version))
+		buf.WriteString(de.render())
+	}
+	// The length includes the byte of the control digits
+	payload := fmt.Sprintf("%02X", buf.Len()/2+1) + buf.String()
+	return payload + c.luhnChecksum() + xorChecksum(payload)
+}
+
+func (c *Code) renderStartCodeLength() string {
+	length := c.StartCode.renderLength(c.Version)
+	if c.Version == HHD13 || len(c.StartCode.ControlBytes) == 0 {
+		return length
+	}
+	lde, _ := strconv.ParseUint(length, 16, 8)
+	return fmt.Sprintf("%02X", lde|bitControlByte)
+}
+
+// luhnChecksum calculates the Luhn control digit over the control bytes and
+// the data of the start code and data elements
+func (c *Code) luhnChecksum() string {
+	var buf bytes.Buffer
+	for _, controlByte := range c.StartCode.ControlBytes {
+		buf.WriteString(fmt.Sprintf("%02X", controlByte))
+	}
+	buf.WriteString(c.StartCode.render())
+	for _, de := range c.DataElements {
+		buf.WriteString(de.render())
+	}
+	data := buf.String()
+	sum := 0
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += hexValue(data[i])
+		doubled := 2 * hexValue(data[i+1])
+		sum += doubled/10 + doubled%10
+	}
+	return fmt.Sprintf("%X", (10-sum%10)%10)
+}
+
+// xorChecksum calculates the XOR control digit over all half-bytes of
+// payload
+func xorChecksum(payload string) string {
+	xor := 0
+	for i := 0; i < len(payload); i++ {
+		xor ^= hexValue(payload[i])
+	}
+	return fmt.Sprintf("%X", xor)
+}
+
+// renderLength returns the length of the rendered data in bytes along with
+// the encoding flag
+func (d DataElement) renderLength(version Version) string {
+	length := len(d.render()) / 2
+	if d.Encoding == BCD {
+		return fmt.Sprintf("%02X", length)
+	}
+	if version == HHD13 {
+		return fmt.Sprintf("1%X", length)
+	}
+	return fmt.Sprintf("%02X", length|bitEncoding)
+}
+
+// render returns the data as hexadecimal string. BCD encoded data is padded
+// with F to full bytes.
+func (d DataElement) render() string {
+	if d.Encoding == ASCII {
+		return fmt.Sprintf("%X", d.Data)
+	}
+	if len(d.Data)%2 == 1 {
+		return d.Data + "F"
+	}
+	return d.Data
+}
+
+// ParseFlickerCode decodes a rendered flicker code like returned by Render.
+// It returns an error if the Luhn or XOR control digit does not match.
+func ParseFlickerCode(flickerCode string) (*Code, error) {
+	flickerCode = strings.ToUpper(strings.TrimSpace(flickerCode))
+	if len(flickerCode) < 4 || !isHex(flickerCode) {
+		return nil, fmt.Errorf("Malformed flicker code %q", flickerCode)
+	}
+	lc, _ := strconv.ParseUint(flickerCode[:2], 16, 8)
+	if len(flickerCode) != 2+2*int(lc) {
+		return nil, fmt.Errorf("Flicker code length mismatch: expected %d bytes, got %d", lc, len(flickerCode)/2-1)
+	}
+	payload := flickerCode[:len(flickerCode)-2]
+	code := &Code{Version: HHD14}
+	rest, err := code.decodeStartCode(payload[2:])
+	if err != nil {
+		return nil, err
+	}
+	for len(rest) > 0 {
+		if len(code.DataElements) == maxDataElements {
+			return nil, fmt.Errorf("Too many data elements")
+		}
+		var de DataElement
+		de, rest, err = code.decodeDataElement(rest)
+		if err != nil {
+			return nil, err
+		}
+		code.DataElements = append(code.DataElements, de)
+	}
+	if luhn := code.luhnChecksum(); luhn != flickerCode[len(flickerCode)-2:len(flickerCode)-1] {
+		return nil, fmt.Errorf("Luhn checksum mismatch: expected %s, got %s", luhn, flickerCode[len(flickerCode)-2:len(flickerCode)-1])
+	}
+	if xor := xorChecksum(payload); xor != flickerCode[len(flickerCode)-1:] {
+		return nil, fmt.Errorf("XOR checksum mismatch: expected %s, got %s", xor, flickerCode[len(flickerCode)-1:])
+	}
+	return code, nil
+}
+
+func (c *Code) decodeStartCode(value string) (string, error) {
+	if len(value) < 2 {
+		return "", fmt.Errorf("Missing start code")
+	}
+	lde, _ := strconv.ParseUint(value[:2], 16, 8)
+	value = value[2:]
+	if lde&bitControlByte != 0 {
+		for {
+			if len(c.StartCode.ControlBytes) == maxControlBytes {
+				return "", fmt.Errorf("Too many control bytes")
+			}
+			if len(value) < 2 {
+				return "", fmt.Errorf("Missing control byte")
+			}
+			controlByte, _ := strconv.ParseUint(value[:2], 16, 8)
+			value = value[2:]
+			c.StartCode.ControlBytes = append(c.StartCode.ControlBytes, byte(controlByte))
+			if controlByte&bitControlByte == 0 {
+				break
+			}
+		}
+	}
+	de, rest, err := c.decodeData(value, byte(lde&^bitControlByte))
+	if err != nil {
+		return "", err
+	}
+	c.StartCode.DataElement = de
+	return rest, nil
+}
+
+func (c *Code) decodeDataElement(value string) (DataElement, string, error) {
+	if len(value) < 2 {
+		return DataElement{}, "", fmt.Errorf("Missing data element length")
+	}
+	lde, _ := strconv.ParseUint(value[:2], 16, 8)
+	return c.decodeData(value[2:], byte(lde))
+}
+
+// decodeData decodes the data of a data element with the rendered length
+// lde. ASCII encoded data is marked by bit 6 of the length since HHD 1.4 and
+// by a leading 1 in HHD 1.3.
+func (c *Code) decodeData(value string, lde byte) (DataElement, string, error) {
+	var de DataElement
+	length := int(lde & lengthMask)
+	switch {
+	case lde&bitEncoding != 0:
+		de.Encoding = ASCII
+	case lde&0xF0 == 0x10:
+		de.Encoding = ASCII
+		c.Version = HHD13
+		length = int(lde & 0x0F)
+	}
+	if len(value) < 2*length {
+		return DataElement{}, "", fmt.Errorf("Data element too short: expected %d bytes, got %d", length, len(value)/2)
+	}
+	data := value[:2*length]
+	if de.Encoding == ASCII {
+		var ascii bytes.Buffer
+		for i := 0; i < len(data); i += 2 {
+			ascii.WriteByte(byte(hexValue(data[i])<<4 | hexValue(data[i+1])))
+		}
+		de.Data = ascii.String()
+	} else {
+		de.Data = strings.TrimSuffix(data, "F")
+		if !isNumeric(de.Data) {
+			return DataElement{}, "", fmt.Errorf("Malformed BCD data: %q", data)
+		}
+	}
+	return de, value[2*length:], nil
+}
+
+func isNumeric(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if hexValue(value[i]) == -1 {
+			return false
+		}
+	}
+	return true
+}
+
+// hexValue returns the value of the hexadecimal digit b or -1 if b is no
+// hexadecimal digit
+func hexValue(b byte) int {
+	switch {
+	case b >= '0' && b <= '9':
+		return int(b - '0')
+	case b >= 'A' && b <= 'F':
+		return int(b-'A') + 10
+	case b >= 'a' && b <= 'f':
+		return int(b-'a') + 10
+	}
+	return -1
+}
@@ -0,0 +1,141 @@
+package chiptan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		description string
+		challenge   string
+		expected    *Code
+		flickerCode string
+	}{
+		{
+			"HHD 1.4 with control byte",
+			"039870110490631098765432100812345678041,00",
+			&Code{
+				Version: HHD14,
+				StartCode: StartCode{
+					DataElement:  DataElement{Data: "1049063"},
+					ControlBytes: []byte{0x01},
+				},
+				DataElements: []DataElement{
+					{Data: "9876543210"},
+					{Data: "12345678"},
+					{Data: "1,00", Encoding: ASCII},
+				},
+			},
+			"1784011049063F059876543210041234567844312C303019",
+		},
+		{
+			"HHD 1.3 within envelope",
+			"CHLGUC002624088715131306389726041,00CHLGTEXT0244 Sie haben eine Überweisung erfasst",
+			&Code{
+				Version:   HHD13,
+				StartCode: StartCode{DataElement: DataElement{Data: "87151313"}},
+				DataElements: []DataElement{
+					{Data: "389726"},
+					{Data: "1,00", Encoding: ASCII},
+				},
+			},
+			"0F04871513130338972614312C30303B",
+		},
+		{
+			"start code only",
+			"006040123",
+			&Code{
+				Version:   HHD14,
+				StartCode: StartCode{DataElement: DataElement{Data: "0123"}},
+			},
+			"0402012306",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			code, err := Parse(test.challenge)
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			if !reflect.DeepEqual(test.expected, code) {
+				t.Logf("Expected code to equal\n%+v\n\tgot\n%+v\n", test.expected, code)
+				t.Fail()
+			}
+			if flickerCode := code.Render(); flickerCode != test.flickerCode {
+				t.Logf("Expected flicker code to equal %q, got %q\n", test.flickerCode, flickerCode)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestParseMalformedChallenge(t *testing.T) {
+	tests := []struct {
+		description string
+		challenge   string
+	}{
+		{"empty", ""},
+		{"length mismatch", "0398701104906310987654321008123456780"},
+		{"missing control byte", "00287"},
+		{"start code too short", "00407123"},
+		{"non numeric BCD start code", "0040412A4"},
+		{"too many data elements", "0180210101010101010101"},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, err := Parse(test.challenge)
+
+			if err == nil {
+				t.Logf("Expected error, got nil\n")
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestParseFlickerCode(t *testing.T) {
+	t.Run("valid flicker codes", func(t *testing.T) {
+		for _, challenge := range []string{
+			"039870110490631098765432100812345678041,00",
+			"CHLGUC002624088715131306389726041,00CHLGTEXT",
+		} {
+			expected, err := Parse(challenge)
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			code, err := ParseFlickerCode(expected.Render())
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			if !reflect.DeepEqual(expected, code) {
+				t.Logf("Expected code to equal\n%+v\n\tgot\n%+v\n", expected, code)
+				t.Fail()
+			}
+		}
+	})
+	t.Run("invalid flicker codes", func(t *testing.T) {
+		tests := []struct {
+			description string
+			flickerCode string
+		}{
+			{"wrong Luhn checksum", "1784011049063F059876543210041234567844312C303029"},
+			{"wrong XOR checksum", "1784011049063F059876543210041234567844312C303018"},
+			{"length mismatch", "1884011049063F059876543210041234567844312C303019"},
+			{"no hex", "0F04871513130338972614312C3030XY"},
+		}
+		for _, test := range tests {
+			t.Run(test.description, func(t *testing.T) {
+				_, err := ParseFlickerCode(test.flickerCode)
+
+				if err == nil {
+					t.Logf("Expected error, got nil\n")
+					t.Fail()
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package chiptan
+
+import "strings"
+
+// syncIdentifier is shown ahead of each flicker code to let the TAN
+// generator synchronize
+const syncIdentifier = "0FFF"
+
+// Frame represents a single frame of a flicker code. The first bit is the
+// clock, the other four bits are the transmitted half-byte, least
+// significant bit first. A set bit is shown as white bar, an unset bit as
+// black bar.
+type Frame [5]bool
+
+// String returns the frame as five bars which can be printed to a terminal
+func (f Frame) String() string {
+	var bars []string
+	for _, bit := range f {
+		if bit {
+			bars = append(bars, "█")
+		} else {
+			bars = append(bars, " ")
+		}
+	}
+	return strings.Join(bars, " ")
+}
+
+// Frames returns the bit sequence of the flicker code of c, led by the sync
+// identifier. Each half-byte is shown in two frames, with the clock bit set
+// and unset. The lower half-byte of a byte is shown first. Renderers show
+// the frames in an endless loop until the TAN generator read the code.
+func (c *Code) Frames() []Frame {
+	code := syncIdentifier + c.Render()
+	var frames []Frame
+	for i := 0; i+1 < len(code); i += 2 {
+		for _, digit := range []byte{code[i+1], code[i]} {
+			halfByte := hexValue(digit)
+			frame := Frame{
+				true,
+				halfByte&1 != 0,
+				halfByte&2 != 0,
+				halfByte&4 != 0,
+				halfByte&8 != 0,
+			}
+			frames = append(frames, frame)
+			frame[0] = false
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
@@ -0,0 +1,66 @@
+package chiptan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCodeFrames(t *testing.T) {
+	code := &Code{
+		Version:   HHD14,
+		StartCode: StartCode{DataElement: DataElement{Data: "0123"}},
+	}
+
+	frames := code.Frames()
+
+	// 0FFF 04 02 01 23 06
+	if len(frames) != 2*14 {
+		t.Fatalf("Expected %d frames, got %d\n", 2*14, len(frames))
+	}
+	expected := []Frame{
+		// F
+		{true, true, true, true, true},
+		{false, true, true, true, true},
+		// 0
+		{true, false, false, false, false},
+		{false, false, false, false, false},
+		// F
+		{true, true, true, true, true},
+		{false, true, true, true, true},
+		// F
+		{true, true, true, true, true},
+		{false, true, true, true, true},
+		// 4
+		{true, false, false, true, false},
+		{false, false, false, true, false},
+		// 0
+		{true, false, false, false, false},
+		{false, false, false, false, false},
+	}
+	if !reflect.DeepEqual(expected, frames[:len(expected)]) {
+		t.Logf("Expected frames to start with\n%v\n\tgot\n%v\n", expected, frames[:len(expected)])
+		t.Fail()
+	}
+	last := []Frame{
+		// 6
+		{true, false, true, true, false},
+		{false, false, true, true, false},
+		// 0
+		{true, false, false, false, false},
+		{false, false, false, false, false},
+	}
+	if !reflect.DeepEqual(last, frames[len(frames)-len(last):]) {
+		t.Logf("Expected frames to end with\n%v\n\tgot\n%v\n", last, frames[len(frames)-len(last):])
+		t.Fail()
+	}
+}
+
+func TestFrameString(t *testing.T) {
+	frame := Frame{true, false, true, true, false}
+
+	expected := "█   █ █  "
+	if actual := frame.String(); actual != expected {
+		t.Logf("Expected frame to equal %q, got %q\n", expected, actual)
+		t.Fail()
+	}
+}
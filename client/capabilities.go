@@ -0,0 +1,129 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mitch000001/go-hbci/domain"
+)
+
+// Capabilities summarises the bank and user parameter data of an institute.
+// They describe which jobs are supported by the institute and allowed for the
+// accounts of the user.
+type Capabilities struct {
+	BankID                    string                  `json:"bank_id"`
+	BankName                  string                  `json:"bank_name"`
+	BPDVersion                int                     `json:"bpd_version"`
+	HBCIVersions              []int                   `json:"hbci_versions"`
+	SecurityMethods           []domain.SecurityMethod `json:"security_methods"`
+	CompressionFunctions      []string                `json:"compression_functions,omitempty"`
+	MaxTransactionsPerMessage int                     `json:"max_transactions_per_message"`
+	// MaxMessageSize is the maximum message size in kB. Zero means no limit.
+	MaxMessageSize int `json:"max_message_size,omitempty"`
+	// Jobs contains all jobs supported by the institute, sorted by ID
+	Jobs []JobCapability `json:"jobs"`
+	// Accounts contains the accounts of the user. They are empty for
+	// capabilities fetched anonymously.
+	Accounts []AccountCapabilities `json:"accounts,omitempty"`
+}
+
+// JobCapability describes a job supported by an institute. The parameters
+// are taken from the highest supported version of the job.
+type JobCapability struct {
+	ID            string   `json:"id"`
+	Versions      []int    `json:"versions"`
+	TANRequired   bool     `json:"tan_required"`
+	MaxJobs       int      `json:"max_jobs"`
+	MinSignatures int      `json:"min_signatures"`
+	SepaFormats   []string `json:"sepa_formats,omitempty"`
+}
+
+// AccountCapabilities lists the jobs allowed for an account of the user
+type AccountCapabilities struct {
+	Account domain.AccountConnection `json:"account"`
+	Jobs    []string                 `json:"jobs"`
+}
+
+// Capabilities returns the capabilities of the institute and the accounts of
+// the user
+func (c *Client) Capabilities() (Capabilities, error) {
+	if err := c.init(); err != nil {
+		return Capabilities{}, err
+	}
+	capabilities := newCapabilities(c.pinTanDialog.BankParameterData)
+	for _, account := range c.pinTanDialog.Accounts {
+		accountCapabilities := AccountCapabilities{Account: account.AccountConnection}
+		for _, transaction := range account.AllowedBusinessTransactions {
+			accountCapabilities.Jobs = append(accountCapabilities.Jobs, transaction.ID)
+		}
+		capabilities.Accounts = append(capabilities.Accounts, accountCapabilities)
+	}
+	return capabilities, nil
+}
+
+// NewAnonymous creates a new AnonymousClient. The AccountID and PIN of the
+// config are not used.
+func NewAnonymous(config Config) (*AnonymousClient, error) {
+	c, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	return &AnonymousClient{c}, nil
+}
+
+// Capabilities returns the capabilities of the institute. The bank parameter
+// data are fetched within an anonymous dialog, so no accounts are included.
+func (a *AnonymousClient) Capabilities() (Capabilities, error) {
+	if a.pinTanDialog.BankParameterDataVersion() == 0 {
+		err := a.pinTanDialog.FetchBankParameterData()
+		if err != nil {
+			return Capabilities{}, fmt.Errorf("Error while fetching bank parameter data: %v", err)
+		}
+	}
+	return newCapabilities(a.pinTanDialog.BankParameterData), nil
+}
+
+func newCapabilities(bpd domain.BankParameterData) Capabilities {
+	capabilities := Capabilities{
+		BankID:                    bpd.BankID.ID,
+		BankName:                  bpd.BankName,
+		BPDVersion:                bpd.Version,
+		HBCIVersions:              bpd.SupportedHBCIVersions,
+		SecurityMethods:           bpd.SecurityMethods,
+		CompressionFunctions:      bpd.CompressionFunctions,
+		MaxTransactionsPerMessage: bpd.MaxTransactionsPerMessage,
+		MaxMessageSize:            bpd.MaxMessageSize,
+	}
+	seen := make(map[string]bool)
+	for _, transaction := range bpd.BusinessTransactions {
+		if seen[transaction.ID] || !isJob(transaction.ID) {
+			continue
+		}
+		seen[transaction.ID] = true
+		params, _ := bpd.BusinessTransaction(transaction.ID)
+		versions := bpd.BusinessTransactionVersions(transaction.ID)
+		sort.Ints(versions)
+		capabilities.Jobs = append(capabilities.Jobs, JobCapability{
+			ID:            transaction.ID,
+			Versions:      versions,
+			TANRequired:   bpd.TANRequired(transaction.ID),
+			MaxJobs:       params.MaxJobs,
+			MinSignatures: params.MinSignatures,
+			SepaFormats:   params.SepaFormats(),
+		})
+	}
+	sort.Slice(capabilities.Jobs, func(i, j int) bool {
+		return capabilities.Jobs[i].ID < capabilities.Jobs[j].ID
+	})
+	return capabilities
+}
+
+// isJob returns false for parameter segments not describing a job, like the
+// PIN/TAN parameters
+func isJob(id string) bool {
+	switch id {
+	case "DKPIN", "HKPIN":
+		return false
+	}
+	return true
+}
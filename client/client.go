@@ -2,16 +2,15 @@ package client
 
 import (
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/mitch000001/go-hbci/bankinfo"
 	"github.com/mitch000001/go-hbci/dialog"
 	"github.com/mitch000001/go-hbci/domain"
-	"github.com/mitch000001/go-hbci/element"
 	"github.com/mitch000001/go-hbci/message"
 	"github.com/mitch000001/go-hbci/segment"
 	"github.com/mitch000001/go-hbci/transport"
+	middleware "github.com/mitch000001/go-hbci/transport/middleware"
 )
 
 // Config defines the basic configuration needed for a Client to work.
@@ -22,6 +21,12 @@ type Config struct {
 	URL         string `json:"url"`
 	HBCIVersion int    `json:"hbci_version"`
 	Transport   transport.Transport
+	// PinProvider is asked for the PIN whenever a message has to be signed.
+	// If set, PIN is ignored.
+	PinProvider domain.PinProvider `json:"-"`
+
+	// RetryPolicy enables retries of read jobs, see dialog.Config
+	RetryPolicy *middleware.RetryPolicy
 }
 
 func (c Config) hbciVersion() (segment.HBCIVersion, error) {
@@ -72,10 +77,15 @@ func New(config Config) (*Client, error) {
 		UserID:      config.AccountID,
 		HBCIVersion: hbciVersion,
 		Transport:   config.Transport,
+		RetryPolicy: config.RetryPolicy,
 	}
 
 	d := dialog.NewPinTanDialog(dcfg)
-	d.SetPin(config.PIN)
+	if config.PinProvider != nil {
+		d.SetPinProvider(config.PinProvider)
+	} else {
+		d.SetPin(config.PIN)
+	}
 	client := &Client{
 		config:       config,
 		hbciVersion:  hbciVersion,
@@ -112,131 +122,94 @@ func (c *Client) Accounts() ([]domain.AccountInformation, error) {
 	return c.pinTanDialog.Accounts, nil
 }
 
+// BankParameterData returns the bank parameter data of the institute. They
+// describe the jobs supported by the institute along with their parameters
+// and the limits to respect when talking to it.
+func (c *Client) BankParameterData() (domain.BankParameterData, error) {
+	if err := c.init(); err != nil {
+		return domain.BankParameterData{}, err
+	}
+	return c.pinTanDialog.BankParameterData, nil
+}
+
 // AccountTransactions return all transactions for the provided timeframe.
 // If allAccouts is true, it will fetch all transactions associated with the
 // proviced account. For the initial request no continuationReference is
-// needed, as this method will be called recursivly if the server sends one.
+// needed, as the continuation references sent by the server are followed
+// within the same dialog.
 func (c *Client) AccountTransactions(account domain.AccountConnection, timeframe domain.Timeframe, allAccounts bool, continuationReference string) ([]domain.AccountTransaction, error) {
 	if err := c.init(); err != nil {
 		return nil, err
 	}
 	builder := segment.NewBuilder(c.pinTanDialog.SupportedSegments())
-	accountTransactionRequest, err := builder.AccountTransactionRequest(account, allAccounts)
-	if err != nil {
-		return nil, err
-	}
-	accountTransactionRequest.SetTransactionRange(timeframe)
-	if continuationReference != "" {
-		accountTransactionRequest.SetContinuationReference(continuationReference)
-	}
-	decryptedMessage, err := c.pinTanDialog.SendMessage(message.NewHBCIMessage(c.hbciVersion, accountTransactionRequest))
-	if err != nil {
-		return nil, err
-	}
-	acknowledgements := decryptedMessage.Acknowledgements()
-	for _, ack := range acknowledgements {
-		if ack.Code == element.AcknowledgementAdditionalInformation {
-			fmt.Printf("Additional information: %+v\n", ack)
-		}
-	}
-	var accountTransactions []domain.AccountTransaction
-	accountTransactionResponses := decryptedMessage.FindSegments("HIKAZ")
-	if accountTransactionResponses != nil {
-		type response struct {
-			transactions []domain.AccountTransaction
-			err          error
-		}
-		resFn := func(tr []domain.AccountTransaction, err error) response {
-			return response{tr, err}
-		}
-		responses := make(chan response, len(accountTransactionResponses))
-		for _, unmarshaledSegment := range accountTransactionResponses {
-			seg := unmarshaledSegment.(segment.AccountTransactionResponse)
-			accountTransactions = append(accountTransactions, seg.Transactions()...)
-			if seg != nil {
-				go func() {
-					responses <- resFn(c.AccountTransactions(account, timeframe, allAccounts, continuationReference))
-				}()
-			} else {
-				responses <- resFn([]domain.AccountTransaction{}, nil)
-			}
-		}
-		var errs []string
-		for {
-			if len(responses) == 0 {
-				break
-			}
-			res := <-responses
-			accountTransactions = append(accountTransactions, res.transactions...)
-			if res.err != nil {
-				errs = append(errs, fmt.Sprintf("%T:%v", res.err, res.err))
-			}
-		}
-		if len(errs) != 0 {
-			return nil, fmt.Errorf("Got errors: %s", strings.Join(errs, "\t"))
-		}
-	} else {
-		return nil, fmt.Errorf("Malformed response: expected HIKAZ segment")
-	}
-
-	return accountTransactions, nil
+	return c.accountTransactions(continuationReference, func() (*segment.AccountTransactionRequestSegment, error) {
+		return builder.AccountTransactionRequest(account, allAccounts)
+	}, timeframe)
 }
 
 // SepaAccountTransactions return all transactions for the provided timeframe.
 // If allAccouts is true, it will fetch all transactions associated with the
 // provided account. For the initial request no continuationReference is
-// needed, as this method will be called recursivly if the server sends one.
+// needed, as the continuation references sent by the server are followed
+// within the same dialog.
 func (c *Client) SepaAccountTransactions(account domain.InternationalAccountConnection, timeframe domain.Timeframe, allAccounts bool, continuationReference string) ([]domain.AccountTransaction, error) {
 	if err := c.init(); err != nil {
 		return nil, err
 	}
-	accountTransactionRequest := c.hbciVersion.SepaAccountTransactionRequest(account, allAccounts)
-	accountTransactionRequest.SetTransactionRange(timeframe)
-	if continuationReference != "" {
-		accountTransactionRequest.SetContinuationReference(continuationReference)
-	}
-	decryptedMessage, err := c.pinTanDialog.SendMessage(message.NewHBCIMessage(c.hbciVersion, accountTransactionRequest))
+	return c.accountTransactions(continuationReference, func() (*segment.AccountTransactionRequestSegment, error) {
+		return c.hbciVersion.SepaAccountTransactionRequest(account, allAccounts), nil
+	}, timeframe)
+}
+
+// accountTransactions sends the requests returned by newRequest for
+// timeframe within one dialog, until the server sends no further
+// continuation reference, and returns the transactions of all responses
+func (c *Client) accountTransactions(continuationReference string, newRequest func() (*segment.AccountTransactionRequestSegment, error), timeframe domain.Timeframe) ([]domain.AccountTransaction, error) {
+	bankMessages, err := c.pinTanDialog.SendContinuableJob(continuationReference, func(continuationReference string) (segment.ClientSegment, error) {
+		request, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		request.SetTransactionRange(timeframe)
+		if continuationReference != "" {
+			request.SetContinuationReference(continuationReference)
+		}
+		return request, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	acknowledgements := decryptedMessage.Acknowledgements()
-	for _, ack := range acknowledgements {
-		if ack.Code == element.AcknowledgementAdditionalInformation {
-			fmt.Printf("Additional information: %+v\n", ack)
-		}
-	}
 	var accountTransactions []domain.AccountTransaction
-	accountTransactionResponses := decryptedMessage.FindSegments("HIKAZ")
-	if accountTransactionResponses != nil {
+	for _, bankMessage := range bankMessages {
+		accountTransactionResponses := bankMessage.FindSegments("HIKAZ")
+		if accountTransactionResponses == nil {
+			return nil, fmt.Errorf("Malformed response: expected HIKAZ segment")
+		}
 		for _, unmarshaledSegment := range accountTransactionResponses {
 			seg := unmarshaledSegment.(segment.AccountTransactionResponse)
 			accountTransactions = append(accountTransactions, seg.Transactions()...)
 		}
-	} else {
-		return nil, fmt.Errorf("Malformed response: expected HIKAZ segment")
 	}
-
 	return accountTransactions, nil
 }
 
-// AccountInformation will print all information attached to the provided
-// account. If allAccounts is true it will fetch also the information
-// associated with the account.
-func (c *Client) AccountInformation(account domain.AccountConnection, allAccounts bool) error {
+// AccountInformation returns all information attached to the provided
+// account as marshaled HIKIF segments. If allAccounts is true it will fetch
+// also the information associated with the account.
+func (c *Client) AccountInformation(account domain.AccountConnection, allAccounts bool) ([][]byte, error) {
 	if err := c.init(); err != nil {
-		return err
+		return nil, err
 	}
 	accountInformationRequest := segment.NewAccountInformationRequestSegmentV1(account, allAccounts)
 	decryptedMessage, err := c.pinTanDialog.SendMessage(message.NewHBCIMessage(c.hbciVersion, accountInformationRequest))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	accountInfoResponse := decryptedMessage.FindMarshaledSegment("HIKIF")
-	if accountInfoResponse != nil {
-		fmt.Printf("Account Info: %s\n", accountInfoResponse)
-		return nil
+	accountInfoResponses := decryptedMessage.FindMarshaledSegments("HIKIF")
+	if accountInfoResponses == nil {
+		return nil, fmt.Errorf("Malformed response: expected HIKIF segment")
 	}
-	return fmt.Errorf("Malformed response: expected HIKIF segment")
+	return accountInfoResponses, nil
 }
 
 // AccountBalances retrieves the balance for the provided account.
@@ -257,12 +230,49 @@ func (c *Client) AccountBalances(account domain.AccountConnection, allAccounts b
 	if err != nil {
 		return nil, err
 	}
+	return accountBalances(decryptedMessage)
+}
+
+// BatchAccountBalances retrieves the balances for all provided accounts
+// within one dialog. The requests are spread across as many messages as
+// needed to comply with the limits of the institute.
+// If allAccounts is true it will fetch also the balances for all accounts
+// associated with the accounts.
+func (c *Client) BatchAccountBalances(accounts []domain.AccountConnection, allAccounts bool) ([]domain.AccountBalance, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	builder := segment.NewBuilder(c.pinTanDialog.SupportedSegments())
+	var requests []segment.ClientSegment
+	for _, account := range accounts {
+		accountBalanceRequest, err := builder.AccountBalanceRequest(account, allAccounts)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, accountBalanceRequest)
+	}
+	decryptedMessages, err := c.pinTanDialog.SendJobs(requests...)
+	if err != nil {
+		return nil, err
+	}
+	var balances []domain.AccountBalance
+	for _, decryptedMessage := range decryptedMessages {
+		messageBalances, err := accountBalances(decryptedMessage)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, messageBalances...)
+	}
+	return balances, nil
+}
+
+func accountBalances(decryptedMessage message.BankMessage) ([]domain.AccountBalance, error) {
 	var balances []domain.AccountBalance
 	balanceResponses := decryptedMessage.FindMarshaledSegments("HISAL")
 	if balanceResponses != nil {
 		for _, marshaledSegment := range balanceResponses {
 			balanceSegment := &segment.AccountBalanceResponseSegment{}
-			err = balanceSegment.UnmarshalHBCI(marshaledSegment)
+			err := balanceSegment.UnmarshalHBCI(marshaledSegment)
 			if err != nil {
 				return nil, fmt.Errorf("Error while parsing account balance: %v", err)
 			}
@@ -70,15 +70,15 @@ func TestClientBalances(t *testing.T) {
 		ProductName: "Sichteinlagen",
 		Currency:    "EUR",
 		BookedBalance: domain.Balance{
-			Amount:           domain.Amount{Amount: 1000.15, Currency: "EUR"},
+			Amount:           domain.Amount{Amount: domain.NewDecimal(100015, 2), Currency: "EUR"},
 			TransmissionDate: date,
 		},
 		EarmarkedBalance: &domain.Balance{
-			Amount:           domain.Amount{Amount: 20, Currency: "EUR"},
+			Amount:           domain.Amount{Amount: domain.NewDecimalFromInt(20), Currency: "EUR"},
 			TransmissionDate: date,
 		},
-		CreditLimit:     &domain.Amount{Amount: 500, Currency: "EUR"},
-		AvailableAmount: &domain.Amount{Amount: 1499.85, Currency: "EUR"},
+		CreditLimit:     &domain.Amount{Amount: domain.NewDecimalFromInt(500), Currency: "EUR"},
+		AvailableAmount: &domain.Amount{Amount: domain.NewDecimal(149985, 2), Currency: "EUR"},
 	}
 
 	if len(balances) != 1 {
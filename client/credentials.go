@@ -0,0 +1,94 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/message"
+	"github.com/mitch000001/go-hbci/segment"
+)
+
+// ChangePin changes the PIN of the user to newPin. The client uses the new
+// PIN afterwards. If the client is configured with a PinProvider, the
+// provider is told about the new PIN if it implements
+// domain.PinChangeListener. Otherwise it is told to forget the old PIN, as
+// if it was rejected, and has to return the new PIN from now on, already for
+// the end of the dialog changing the PIN.
+func (c *Client) ChangePin(newPin string) error {
+	if newPin == "" {
+		return fmt.Errorf("Empty PIN")
+	}
+	if err := c.init(); err != nil {
+		return err
+	}
+	builder := segment.NewBuilder(c.pinTanDialog.SupportedSegments())
+	pinChangeRequest, err := builder.PinChangeRequest(newPin)
+	if err != nil {
+		return err
+	}
+	_, err = c.pinTanDialog.ChangePin(pinChangeRequest, newPin)
+	if err != nil {
+		return err
+	}
+	if c.config.PinProvider == nil {
+		c.config.PIN = newPin
+	}
+	return nil
+}
+
+// LockAccess locks the access of the user, e.g. if the PIN is compromised.
+// Only the institute can unlock the access again.
+func (c *Client) LockAccess() error {
+	if err := c.init(); err != nil {
+		return err
+	}
+	builder := segment.NewBuilder(c.pinTanDialog.SupportedSegments())
+	pinLockRequest, err := builder.PinLockRequest()
+	if err != nil {
+		return err
+	}
+	_, err = c.pinTanDialog.SendMessage(message.NewHBCIMessage(c.hbciVersion, pinLockRequest))
+	return err
+}
+
+// TanMedia returns the active and available TAN media of the user of the
+// given class. Use domain.AllTanMediumClasses to get the TAN media of all
+// classes.
+func (c *Client) TanMedia(class domain.TanMediumClass) ([]domain.TanMedium, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	builder := segment.NewBuilder(c.pinTanDialog.SupportedSegments())
+	tanMediaRequest, err := builder.TanMediaListRequest(class)
+	if err != nil {
+		return nil, err
+	}
+	bankMessage, err := c.pinTanDialog.SendMessage(message.NewHBCIMessage(c.hbciVersion, tanMediaRequest))
+	if err != nil {
+		return nil, err
+	}
+	tanMediaResponse := bankMessage.FindSegment("HITAB")
+	if tanMediaResponse == nil {
+		return nil, fmt.Errorf("Malformed response: expected HITAB segment")
+	}
+	return tanMediaResponse.(*segment.TanMediaListResponseSegment).TanMedia()
+}
+
+// SyncTanGenerator synchronizes the TAN generator using the card of medium
+// with the institute. atc is the application transaction counter shown by
+// the TAN generator in synchronisation mode along with tan.
+func (c *Client) SyncTanGenerator(medium domain.TanMedium, atc int, tan string) error {
+	if medium.CardNumber == "" {
+		return fmt.Errorf("Missing card number: the TAN medium is no TAN generator")
+	}
+	if err := c.init(); err != nil {
+		return err
+	}
+	builder := segment.NewBuilder(c.pinTanDialog.SupportedSegments())
+	syncRequest, err := builder.TanGeneratorSyncRequest(medium.CardNumber, medium.CardSequenceNumber, atc, tan)
+	if err != nil {
+		return err
+	}
+	_, err = c.pinTanDialog.SendMessage(message.NewHBCIMessage(c.hbciVersion, syncRequest))
+	return err
+}
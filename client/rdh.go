@@ -0,0 +1,112 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/mitch000001/go-hbci/dialog"
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/segment"
+	"github.com/mitch000001/go-hbci/transport"
+)
+
+// RDHConfig defines the configuration needed for a RDHClient. The keys and
+// the signature ID have to be kept between the runs of the client, e.g.
+// within a key file.
+type RDHConfig struct {
+	BankID    string
+	AccountID string
+	// URL is the address of the bank given as "host" or "host:port"
+	URL string
+	// HBCIVersion is the HBCI version to use. If zero, FinTS 3.0 is used.
+	HBCIVersion int
+	// SigningKey and EncryptionKey are the private keys of the user
+	SigningKey    *domain.RSAKey
+	EncryptionKey *domain.RSAKey
+	// BankSigningKey and BankEncryptionKey are the verified public keys of
+	// the bank
+	BankSigningKey    *domain.RSAKey
+	BankEncryptionKey *domain.RSAKey
+	// SignatureID is the ID of the next signature, see RDHClient.SignatureID
+	SignatureID int
+	// RequireSignatures rejects responses which are not signed with
+	// BankSigningKey
+	RequireSignatures bool
+	Transport         transport.Transport
+}
+
+// NewRDH creates a new client using the RDH and RAH security profiles. It
+// returns an error if any of the keys is missing or the HBCI version is not
+// supported.
+func NewRDH(config RDHConfig) (*RDHClient, error) {
+	if config.SigningKey == nil || config.EncryptionKey == nil || config.BankEncryptionKey == nil {
+		return nil, fmt.Errorf("Missing keys: the keys of the user and the encryption key of the bank are required")
+	}
+	version := config.HBCIVersion
+	if version == 0 {
+		version = segment.FINTS300.Version()
+	}
+	hbciVersion, ok := segment.SupportedHBCIVersions[version]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported HBCI version. Supported versions are %v", domain.SupportedHBCIVersions)
+	}
+	d, err := dialog.NewRDHDialog(dialog.RDHConfig{
+		BankID:            domain.BankID{CountryCode: 280, ID: config.BankID},
+		HBCIURL:           config.URL,
+		UserID:            config.AccountID,
+		HBCIVersion:       hbciVersion,
+		SigningKey:        config.SigningKey,
+		EncryptionKey:     config.EncryptionKey,
+		BankSigningKey:    config.BankSigningKey,
+		BankEncryptionKey: config.BankEncryptionKey,
+		SignatureID:       config.SignatureID,
+		RequireSignatures: config.RequireSignatures,
+		Transport:         config.Transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RDHClient{config: config, rdhDialog: d}, nil
+}
+
+// RDHClient is the entrypoint to manage the keys of a RDH user
+type RDHClient struct {
+	config    RDHConfig
+	rdhDialog *dialog.RDHDialog
+}
+
+// SignatureID returns the ID of the next signature. It changes with every
+// message sent and has to be stored after using the client.
+func (r *RDHClient) SignatureID() int {
+	return r.rdhDialog.SignatureID()
+}
+
+// SigningKey returns the current private signing key of the user
+func (r *RDHClient) SigningKey() *domain.RSAKey {
+	return r.rdhDialog.SigningKey()
+}
+
+// EncryptionKey returns the current private encryption key of the user
+func (r *RDHClient) EncryptionKey() *domain.RSAKey {
+	return r.rdhDialog.EncryptionKey()
+}
+
+// ChangeKeys replaces the keys of the user by new keys with the next key
+// version. The new keys have to be stored once ChangeKeys returns without
+// error, as the old keys are invalid afterwards.
+func (r *RDHClient) ChangeKeys() error {
+	return r.rdhDialog.ChangeKeys()
+}
+
+// LockKeys locks the keys of the user at the bank, e.g. if they are
+// compromised. reason must be one of segment.KeyCompromitted,
+// segment.KeyMaybeCompromitted or segment.KeyRevocationMisc.
+func (r *RDHClient) LockKeys(reason string) error {
+	return r.rdhDialog.LockKeys(reason)
+}
+
+// SyncSignatureID synchronizes the signature ID with the bank, e.g. after
+// the bank rejected a message for a signature ID used before. It returns
+// the ID of the next signature.
+func (r *RDHClient) SyncSignatureID() (int, error) {
+	return r.rdhDialog.SyncSignatureID()
+}
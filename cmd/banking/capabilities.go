@@ -0,0 +1,146 @@
+// Copyright © 2015 Michael Wagner <mitch.wagna@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mitch000001/go-hbci/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var capabilitiesFormat string
+var capabilitiesAnonymous bool
+
+// capabilitiesCmd represents the capabilities command
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "Lists the jobs supported by the bank institute",
+	Long: `This command lists the jobs and versions supported by the bank institute,
+whether they need a TAN, the supported SEPA formats and the limits of the
+institute, along with the jobs allowed for the accounts of the user. For
+example:
+
+	banking capabilities --format=json
+
+will print the capabilities as JSON. With --anonymous the bank parameter data
+are fetched within an anonymous dialog, so only the blz is needed and no
+accounts are listed.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if !capabilitiesAnonymous {
+			initClient()
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		var capabilities client.Capabilities
+		var err error
+		if capabilitiesAnonymous {
+			capabilities, err = anonymousCapabilities()
+		} else {
+			capabilities, err = hbciClient.Capabilities()
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := writeCapabilities(os.Stdout, capabilitiesFormat, capabilities); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func anonymousCapabilities() (client.Capabilities, error) {
+	blz := viper.GetString("blz")
+	if blz == "" {
+		return client.Capabilities{}, fmt.Errorf("Error: required flag(s) \"blz\" not set")
+	}
+	anonymousClient, err := client.NewAnonymous(client.Config{
+		URL:    url,
+		BankID: blz,
+	})
+	if err != nil {
+		return client.Capabilities{}, err
+	}
+	return anonymousClient.Capabilities()
+}
+
+func writeCapabilities(out io.Writer, format string, capabilities client.Capabilities) error {
+	switch format {
+	case "table":
+		w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(w, "Bank:\t%s (%s)\n", capabilities.BankName, capabilities.BankID)
+		fmt.Fprintf(w, "HBCI versions:\t%s\n", joinInts(capabilities.HBCIVersions))
+		var securityMethods []string
+		for _, method := range capabilities.SecurityMethods {
+			securityMethods = append(securityMethods, method.Code+":"+joinInts(method.Versions))
+		}
+		fmt.Fprintf(w, "Security methods:\t%s\n", strings.Join(securityMethods, " "))
+		fmt.Fprintf(w, "Compression functions:\t%s\n", strings.Join(capabilities.CompressionFunctions, ","))
+		fmt.Fprintf(w, "Max jobs per message:\t%d\n", capabilities.MaxTransactionsPerMessage)
+		fmt.Fprintf(w, "Max message size (kB):\t%d\n", capabilities.MaxMessageSize)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Job\tVersions\tTAN\tMax jobs\tMin signatures\tSEPA formats")
+		for _, job := range capabilities.Jobs {
+			tan := "N"
+			if job.TANRequired {
+				tan = "J"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n", job.ID, joinInts(job.Versions), tan, job.MaxJobs, job.MinSignatures, strings.Join(job.SepaFormats, " "))
+		}
+		if len(capabilities.Accounts) != 0 {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "Account\tJobs")
+			for _, account := range capabilities.Accounts {
+				fmt.Fprintf(w, "%s\t%s\n", account.Account.AccountID, strings.Join(account.Jobs, ","))
+			}
+		}
+		return w.Flush()
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(capabilities)
+	default:
+		return fmt.Errorf("Unsupported format %q. Supported formats are table and json", format)
+	}
+}
+
+func joinInts(values []int) string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = strconv.Itoa(value)
+	}
+	return strings.Join(strs, ",")
+}
+
+func init() {
+	rootCmd.AddCommand(capabilitiesCmd)
+
+	capabilitiesCmd.Flags().StringVar(
+		&capabilitiesFormat, "format", "table",
+		"the output format, one of table or json",
+	)
+	capabilitiesCmd.Flags().BoolVar(
+		&capabilitiesAnonymous, "anonymous", false,
+		"whether to fetch the bank parameter data anonymously, without credentials",
+	)
+}
@@ -6,7 +6,10 @@
 // Available Commands:
 //   accounts     Lists all accounts associated with the UserID
 //   balances     Fetches balances for a specific account
+//   capabilities Lists the jobs supported by the bank institute
 //   help         Help about any command
+//   import-mt940 Imports transactions from a MT940 file
+//   rdh          Manages the keys for the RDH security profiles
 //   transactions fetch transactions for an account
 //
 // Flags:
@@ -15,7 +18,7 @@
 //   -d, --debug             enable debug logging (very verbose)
 //       --hbci.url string   the URL to the bank institute
 //   -h, --help              help for banking
-//       --pin string        the pin for the provided account
+//       --pin string        the pin for the provided account (asked for if not set)
 //       --userID string     the account ID to authenticate with
 //
 // Use "banking [command] --help" for more information about a command.
@@ -0,0 +1,170 @@
+// Copyright © 2015 Michael Wagner <mitch.wagna@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/swift"
+	"github.com/spf13/cobra"
+)
+
+var importFormat string
+var importOutput string
+var importMode string
+var importReconcile bool
+
+var importModes = map[string]swift.UnmarshalMode{
+	"default": swift.DefaultMode,
+	"lenient": swift.LenientMode,
+	"strict":  swift.StrictMode,
+}
+
+// importMT940Cmd represents the import-mt940 command
+var importMT940Cmd = &cobra.Command{
+	Use:   "import-mt940 [file]",
+	Short: "Imports transactions from a MT940 file",
+	Long: `This command parses a MT940 file (also known as STA file) as downloaded
+from the web portal of a bank institute. No connection to the bank institute
+is made, so no credentials are needed. If no file is given, it reads from
+stdin. For example:
+
+	banking import-mt940 --format=csv --output=transactions.csv statement.sta
+
+will write all transactions of statement.sta as CSV into transactions.csv.
+With --mode=lenient malformed bookings are skipped and reported as warnings
+instead of aborting the import. With --reconcile the statements are checked for
+matching balances, missing statements or sheets and duplicate bookings. If any
+issue is found, the command exits with status 2.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{offlineAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		in := os.Stdin
+		if len(args) == 1 {
+			f, err := os.Open(args[0])
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			in = f
+		}
+		mode, ok := importModes[importMode]
+		if !ok {
+			fmt.Printf("Unsupported mode %q. Supported modes are default, lenient and strict\n", importMode)
+			os.Exit(1)
+		}
+		statements, diagnostics, err := swift.ParseMT940StatementsWithOptions(in, swift.UnmarshalOptions{Mode: mode})
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		for _, diagnostic := range diagnostics {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", diagnostic)
+		}
+		var issues []swift.ReconciliationIssue
+		if importReconcile {
+			issues = swift.Reconcile(statements)
+			for _, issue := range issues {
+				fmt.Fprintf(os.Stderr, "Reconciliation: %s\n", issue)
+			}
+		}
+		var transactions []domain.AccountTransaction
+		for _, statement := range statements {
+			transactions = append(transactions, statement.AccountTransactions()...)
+		}
+		var out io.Writer = os.Stdout
+		if importOutput != "" {
+			f, err := os.Create(importOutput)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := writeTransactions(out, importFormat, transactions); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if len(issues) != 0 {
+			os.Exit(2)
+		}
+	},
+}
+
+func writeTransactions(out io.Writer, format string, transactions []domain.AccountTransaction) error {
+	switch format {
+	case "table":
+		_, err := fmt.Fprint(out, domain.AccountTransactions(transactions))
+		return err
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(transactions)
+	case "csv":
+		w := csv.NewWriter(out)
+		w.Comma = ';'
+		w.Write([]string{
+			"BankID", "AccountID", "BookingDate", "ValutaDate", "Amount", "Currency",
+			"BookingText", "PayeeBankID", "PayeeAccountID", "Name", "Purpose",
+		})
+		for _, tr := range transactions {
+			w.Write([]string{
+				tr.Account.BankID,
+				tr.Account.AccountID,
+				tr.BookingDate.Format("2006-01-02"),
+				tr.ValutaDate.Format("2006-01-02"),
+				tr.Amount.Amount.StringFixed(domain.MinorUnits(tr.Amount.Currency)),
+				tr.Amount.Currency,
+				tr.BookingText,
+				tr.BankID,
+				tr.AccountID,
+				tr.Name,
+				tr.Purpose,
+			})
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		return fmt.Errorf("Unsupported format %q. Supported formats are table, json and csv", format)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(importMT940Cmd)
+
+	importMT940Cmd.Flags().StringVar(
+		&importFormat, "format", "table",
+		"the output format, one of table, json or csv",
+	)
+	importMT940Cmd.Flags().StringVar(
+		&importMode, "mode", "default",
+		"how to handle malformed data, one of default, lenient or strict",
+	)
+	importMT940Cmd.Flags().BoolVar(
+		&importReconcile, "reconcile", false,
+		"whether to check the statements for integrity issues",
+	)
+	importMT940Cmd.Flags().StringVar(
+		&importOutput, "output", "",
+		"the file to write the transactions to (defaults to stdout)",
+	)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// terminalPinProvider asks the user for the PIN on the terminal the first
+// time a message has to be signed. The PIN is kept until the bank rejects
+// it.
+type terminalPinProvider struct {
+	in  *os.File
+	out io.Writer
+	// reader reads the PIN if in is no terminal, e.g. if it is piped
+	reader *bufio.Reader
+	pin    string
+}
+
+// newTerminalPinProvider returns a PIN provider reading from stdin
+func newTerminalPinProvider() *terminalPinProvider {
+	return &terminalPinProvider{
+		in:     os.Stdin,
+		out:    os.Stderr,
+		reader: bufio.NewReader(os.Stdin),
+	}
+}
+
+// Pin returns the PIN, asking the user for it if necessary
+func (t *terminalPinProvider) Pin() (string, error) {
+	if t.pin != "" {
+		return t.pin, nil
+	}
+	pin, err := t.readPin("PIN: ")
+	if err != nil {
+		return "", err
+	}
+	t.pin = pin
+	return pin, nil
+}
+
+// PinRejected forgets the PIN, so the user is asked again
+func (t *terminalPinProvider) PinRejected() {
+	t.pin = ""
+	fmt.Fprintln(t.out, "The bank rejected the PIN")
+}
+
+// PinChanged replaces the PIN with newPin
+func (t *terminalPinProvider) PinChanged(newPin string) {
+	t.pin = newPin
+}
+
+// readPin prints question and reads the answer. The answer is not echoed if
+// the input is a terminal.
+func (t *terminalPinProvider) readPin(question string) (string, error) {
+	return readSecret(t.in, t.reader, t.out, question)
+}
+
+// readSecret prints question and reads the answer from in without echoing
+// it. If in is no terminal, e.g. if it is piped, the answer is read from
+// reader, which has to buffer in.
+func readSecret(in *os.File, reader *bufio.Reader, out io.Writer, question string) (string, error) {
+	fmt.Fprint(out, question)
+	fd := int(in.Fd())
+	if terminal.IsTerminal(fd) {
+		answer, err := terminal.ReadPassword(fd)
+		fmt.Fprintln(out)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(answer)), nil
+	}
+	answer, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
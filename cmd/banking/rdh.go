@@ -0,0 +1,290 @@
+// Copyright © 2015 Michael Wagner <mitch.wagna@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitch000001/go-hbci/dialog"
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/message"
+	"github.com/mitch000001/go-hbci/passport"
+	"github.com/mitch000001/go-hbci/segment"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rdhPassportFile string
+var rdhLetterFile string
+var rdhHBCIVersion int
+var rdhConfirmed bool
+
+// rdhCmd represents the rdh command
+var rdhCmd = &cobra.Command{
+	Use:   "rdh",
+	Short: "Manages the keys for the RDH security profiles",
+	Long: `This command groups the subcommands to manage the keys used with the RDH
+and RAH security profiles. The keys are stored within a password protected
+passport file.`,
+	// The RDH commands don't use the PIN/TAN client
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+}
+
+// rdhInitCmd represents the rdh init command
+var rdhInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Initializes the RDH keys of the user",
+	Long: `This command performs the first contact of a RDH user with the bank institute.
+It fetches the public keys of the bank and prints their hashes, which must
+match the INI letter of the bank. After confirmation, the newly generated keys
+of the user are stored within the passport and submitted to the bank. Finally
+the INI letter of the user is printed. For example:
+
+	banking rdh init --blz=10000000 --userID=12345 --hbci.url=hbci.example.com --letter=ini.txt
+
+The bank unlocks the keys once it received the signed INI letter. The
+passport is encrypted with a password asked for on the command line.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := rdhInit(os.Stdin, os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+// rdhShowCmd represents the rdh show command
+var rdhShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Shows the content of the RDH passport",
+	Long: `This command prints the keys and the session state stored within the
+passport, including the hashes of the public keys of the bank.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := rdhShow(os.Stdin, os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func rdhInit(in *os.File, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	var missingFlags []string
+	userID := viper.GetString("userID")
+	blz := viper.GetString("blz")
+	if userID == "" {
+		missingFlags = append(missingFlags, `"userID"`)
+	}
+	if blz == "" {
+		missingFlags = append(missingFlags, `"blz"`)
+	}
+	if url == "" {
+		missingFlags = append(missingFlags, `"hbci.url"`)
+	}
+	if len(missingFlags) != 0 {
+		return fmt.Errorf("Error: required flag(s) %s not set", strings.Join(missingFlags, ", "))
+	}
+	hbciVersion, ok := segment.SupportedHBCIVersions[rdhHBCIVersion]
+	if !ok {
+		return fmt.Errorf("Unsupported HBCI version. Supported versions are %v", domain.SupportedHBCIVersions)
+	}
+	passportFile, err := rdhPassportPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(passportFile); err == nil {
+		return fmt.Errorf("The passport %s already exists", passportFile)
+	}
+	d, err := dialog.NewRDHDialog(dialog.RDHConfig{
+		BankID:      domain.BankID{CountryCode: 280, ID: blz},
+		HBCIURL:     url,
+		UserID:      userID,
+		HBCIVersion: hbciVersion,
+	})
+	if err != nil {
+		return err
+	}
+	if err := d.FetchBankKeys(); err != nil {
+		return fmt.Errorf("Error while fetching the keys of the bank: %v", err)
+	}
+	fmt.Fprintf(out, "Security profile: %s\n", d.Profile())
+	printBankKeyHashes(out, d.Profile(), d.BankSigningKey(), d.BankEncryptionKey())
+	if !rdhConfirmed {
+		answer, err := prompt(reader, out, "\nDo the hashes match the INI letter of your bank? [y/N] ")
+		if err != nil {
+			return err
+		}
+		if answer = strings.ToLower(answer); answer != "y" && answer != "yes" {
+			return fmt.Errorf("Aborted: the keys of the bank were not confirmed")
+		}
+	}
+	password, err := newPassword(in, reader, out)
+	if err != nil {
+		return err
+	}
+	p := &passport.Passport{
+		BankID:           d.BankID,
+		UserID:           userID,
+		URL:              url,
+		HBCIVersion:      rdhHBCIVersion,
+		BankKeysVerified: true,
+	}
+	p.Update(d)
+	if err := p.SaveFile(passportFile, password); err != nil {
+		return fmt.Errorf("Error while writing the passport: %v", err)
+	}
+	fmt.Fprintf(out, "\nKeys written to %s\n", passportFile)
+	if err := d.SubmitPublicKeys(); err != nil {
+		return fmt.Errorf("Error while submitting the keys: %v", err)
+	}
+	fmt.Fprintf(out, "Keys submitted to the bank\n")
+	p.Update(d)
+	if err := p.SaveFile(passportFile, password); err != nil {
+		return fmt.Errorf("Error while writing the passport: %v", err)
+	}
+	letter := d.INILetter().String()
+	if rdhLetterFile == "" {
+		fmt.Fprintf(out, "\n%s", letter)
+		return nil
+	}
+	if err := ioutil.WriteFile(rdhLetterFile, []byte(letter), 0644); err != nil {
+		return fmt.Errorf("Error while writing the INI letter: %v", err)
+	}
+	fmt.Fprintf(out, "INI letter written to %s. Please print, sign and send it to your bank.\n", rdhLetterFile)
+	return nil
+}
+
+func rdhShow(in *os.File, out io.Writer) error {
+	passportFile, err := rdhPassportPath()
+	if err != nil {
+		return err
+	}
+	password, err := readSecret(in, bufio.NewReader(in), out, "Password: ")
+	if err != nil {
+		return err
+	}
+	p, err := passport.LoadFile(passportFile, []byte(password))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Bank ID:             %s\n", p.BankID.ID)
+	fmt.Fprintf(out, "User ID:             %s\n", p.UserID)
+	fmt.Fprintf(out, "URL:                 %s\n", p.URL)
+	fmt.Fprintf(out, "HBCI version:        %d\n", p.HBCIVersion)
+	fmt.Fprintf(out, "Signature ID:        %d\n", p.SignatureID)
+	fmt.Fprintf(out, "Client system ID:    %s\n", p.ClientSystemID)
+	fmt.Fprintf(out, "BPD version:         %d\n", p.BankParameterData.Version)
+	fmt.Fprintf(out, "UPD version:         %d\n", p.UserParameterData.Version)
+	fmt.Fprintf(out, "Bank keys verified:  %t\n", p.BankKeysVerified)
+	for _, key := range []*domain.RSAKey{p.SigningKey, p.EncryptionKey} {
+		if key == nil {
+			continue
+		}
+		keyName := key.KeyName()
+		fmt.Fprintf(out, "User key %s:          number %d, version %d\n", keyName.KeyType, keyName.KeyNumber, keyName.KeyVersion)
+	}
+	profile, err := message.SelectRDHProfile(p.BankParameterData.SecurityMethods)
+	if err != nil {
+		profile = message.RDH2
+		fmt.Fprintf(out, "Security profile:    %s, assumed as the profile is unknown: %v\n", profile, err)
+	} else {
+		fmt.Fprintf(out, "Security profile:    %s\n", profile)
+	}
+	printBankKeyHashes(out, profile, p.BankSigningKey, p.BankEncryptionKey)
+	return nil
+}
+
+// printBankKeyHashes prints the hashes of the keys of the bank as printed on
+// the INI letter of the bank
+func printBankKeyHashes(out io.Writer, profile message.RDHProfile, keys ...*domain.RSAKey) {
+	for _, key := range keys {
+		if key == nil {
+			continue
+		}
+		keyName := key.KeyName()
+		fmt.Fprintf(out, "\nHash of the bank key %s (number %d, version %d):\n", keyName.KeyType, keyName.KeyNumber, keyName.KeyVersion)
+		fmt.Fprintln(out, strings.Join(domain.HexLines(profile.KeyHash(key.PublicKey)), "\n"))
+	}
+}
+
+// prompt prints question and returns the answer without surrounding spaces
+func prompt(in *bufio.Reader, out io.Writer, question string) (string, error) {
+	fmt.Fprint(out, question)
+	answer, err := in.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// newPassword asks for the password of a new passport twice. The password
+// is read from reader if in is no terminal.
+func newPassword(in *os.File, reader *bufio.Reader, out io.Writer) ([]byte, error) {
+	password, err := readSecret(in, reader, out, "\nPassword for the passport: ")
+	if err != nil {
+		return nil, err
+	}
+	if password == "" {
+		return nil, fmt.Errorf("Empty password")
+	}
+	repeated, err := readSecret(in, reader, out, "Repeat password: ")
+	if err != nil {
+		return nil, err
+	}
+	if password != repeated {
+		return nil, fmt.Errorf("The passwords don't match")
+	}
+	return []byte(password), nil
+}
+
+func rdhPassportPath() (string, error) {
+	if rdhPassportFile != "" {
+		return rdhPassportFile, nil
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".banking-rdh.passport"), nil
+}
+
+func init() {
+	rootCmd.AddCommand(rdhCmd)
+	rdhCmd.AddCommand(rdhInitCmd)
+	rdhCmd.AddCommand(rdhShowCmd)
+
+	rdhCmd.PersistentFlags().StringVar(
+		&rdhPassportFile, "passport", "",
+		"the passport file to store the keys in (default is $HOME/.banking-rdh.passport)",
+	)
+	rdhCmd.PersistentFlags().IntVar(
+		&rdhHBCIVersion, "hbci.version", 300,
+		"the HBCI version to use, one of 220 or 300",
+	)
+	rdhInitCmd.Flags().StringVar(
+		&rdhLetterFile, "letter", "",
+		"the file to write the INI letter to (default is stdout)",
+	)
+	rdhInitCmd.Flags().BoolVarP(
+		&rdhConfirmed, "yes", "y", false,
+		"whether the hashes of the bank keys are confirmed without asking",
+	)
+}
@@ -36,8 +36,18 @@ var rootCmd = &cobra.Command{
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	//	Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if _, ok := cmd.Annotations[offlineAnnotation]; ok {
+			return
+		}
+		initClient()
+	},
 }
 
+// offlineAnnotation marks commands which work without a connection to a bank
+// institute. No client will be initialized for those.
+const offlineAnnotation = "offline"
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func execute() {
@@ -61,7 +71,6 @@ var debug bool
 func init() {
 	cobra.OnInitialize(
 		initConfig,
-		initClient,
 		initLoggers,
 	)
 
@@ -72,10 +81,9 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&url, "hbci.url", "", "the URL to the bank institute")
 	rootCmd.PersistentFlags().StringVar(&UserID, "userID", "", "the account ID to authenticate with")
 	rootCmd.PersistentFlags().StringVar(&BLZ, "blz", "", "the identifier for the bank institute")
-	rootCmd.PersistentFlags().StringVar(&PIN, "pin", "", "the pin for the provided account")
+	rootCmd.PersistentFlags().StringVar(&PIN, "pin", "", "the pin for the provided account (asked for if not set)")
 	viper.BindPFlag("userID", rootCmd.PersistentFlags().Lookup("userID"))
 	viper.BindPFlag("blz", rootCmd.PersistentFlags().Lookup("blz"))
-	rootCmd.MarkPersistentFlagRequired("pin")
 
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "enable debug logging (very verbose)")
 }
@@ -100,6 +108,9 @@ func initClient() {
 		BankID:    blz,
 		PIN:       PIN,
 	}
+	if PIN == "" {
+		clientConfig.PinProvider = newTerminalPinProvider()
+	}
 	c, err := client.New(clientConfig)
 	if err != nil {
 		fmt.Println(err)
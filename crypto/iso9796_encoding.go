@@ -166,7 +166,7 @@ func (i *ISO9796d1Encoding) decodeBlock(in []byte, inOff, inLen int) ([]byte, er
 	if x = x.Mod(iS, sixteen); x.Cmp(six) == 0 {
 		iR = iS
 	} else if y = y.Mod(y.Sub(i.modulus, iS), sixteen); y.Cmp(six) == 0 {
-		iR = iR.Sub(i.modulus, iS)
+		iR = new(big.Int).Sub(i.modulus, iS)
 	} else {
 		return nil, fmt.Errorf("resulting integer iS or (modulus - iS) is not congruent to 6 mod 16")
 	}
@@ -183,7 +183,7 @@ func (i *ISO9796d1Encoding) decodeBlock(in []byte, inOff, inLen int) ([]byte, er
 	boundaryFound := false
 	boundary := 0
 
-	for i := len(block) - 1; i < len(block)-2*t; i -= 2 {
+	for i := len(block) - 1; i >= len(block)-2*t; i -= 2 {
 		val := ((shadows[(block[i]&0xff)>>4] << 4) | shadows[block[i]&0x0f])
 
 		if ((block[i-1] ^ val) & 0xff) != 0 {
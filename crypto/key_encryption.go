@@ -0,0 +1,38 @@
+package crypto
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"math/big"
+)
+
+// EncryptZeroPadded pads data with leading zeros to the byte length of the
+// key modulus and encrypts it with the public key using plain RSA, as defined
+// for RDH message keys.
+func EncryptZeroPadded(data []byte, key *rsa.PublicKey) ([]byte, error) {
+	if key == nil {
+		return nil, fmt.Errorf("No public key given")
+	}
+	size := (key.N.BitLen() + 7) / 8
+	if len(data) >= size {
+		return nil, fmt.Errorf("Data too large for key: %d >= %d bytes", len(data), size)
+	}
+	m := new(big.Int).SetBytes(data)
+	c := new(big.Int).Exp(m, big.NewInt(int64(key.E)), key.N)
+	return c.FillBytes(make([]byte, size)), nil
+}
+
+// DecryptZeroPadded decrypts data with the private key using plain RSA. The
+// result has the byte length of the key modulus, including the leading zeros.
+func DecryptZeroPadded(data []byte, key *rsa.PrivateKey) ([]byte, error) {
+	if key == nil {
+		return nil, fmt.Errorf("No private key given")
+	}
+	size := (key.N.BitLen() + 7) / 8
+	c := new(big.Int).SetBytes(data)
+	if c.Cmp(key.N) >= 0 {
+		return nil, fmt.Errorf("Encrypted data too large for key")
+	}
+	m := new(big.Int).Exp(c, key.D, key.N)
+	return m.FillBytes(make([]byte, size)), nil
+}
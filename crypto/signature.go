@@ -0,0 +1,60 @@
+package crypto
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"math/big"
+)
+
+// SignISO9796d1 signs hash with key using the ISO 9796-1 padding scheme. The
+// returned signature has the byte length of the key modulus.
+func SignISO9796d1(hash []byte, key *rsa.PrivateKey) (signature []byte, err error) {
+	if key == nil {
+		return nil, fmt.Errorf("No private key given")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Error while signing with ISO 9796-1: %v", r)
+		}
+	}()
+	encoding := NewISO9796d1Encoding(new(RSAEngine))
+	encoding.Init(true, privateKeyParameters(key))
+	if len(hash) > encoding.InputBlockSize() {
+		return nil, fmt.Errorf("Hash too large for key: %d > %d bytes", len(hash), encoding.InputBlockSize())
+	}
+	return encoding.ProcessBlock(hash, 0, len(hash))
+}
+
+// RecoverISO9796d1 recovers the signed hash from an ISO 9796-1 signature
+// using the public key. It returns an error if signature is not a valid ISO
+// 9796-1 signature for key.
+func RecoverISO9796d1(signature []byte, key *rsa.PublicKey) (hash []byte, err error) {
+	if key == nil {
+		return nil, fmt.Errorf("No public key given")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Error while verifying ISO 9796-1 signature: %v", r)
+		}
+	}()
+	encoding := NewISO9796d1Encoding(new(RSAEngine))
+	encoding.Init(false, NewRSAKeyParameters(false, key.N, big.NewInt(int64(key.E))))
+	return encoding.ProcessBlock(signature, 0, len(signature))
+}
+
+func privateKeyParameters(key *rsa.PrivateKey) RSAKeyParameters {
+	if len(key.Primes) != 2 {
+		return NewRSAKeyParameters(true, key.N, key.D)
+	}
+	key.Precompute()
+	return NewRSAPrivateCrtKeyParameters(
+		key.N,
+		big.NewInt(int64(key.E)),
+		key.D,
+		key.Primes[0],
+		key.Primes[1],
+		key.Precomputed.Dp,
+		key.Precomputed.Dq,
+		key.Precomputed.Qinv,
+	)
+}
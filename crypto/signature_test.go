@@ -0,0 +1,73 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestSignISO9796d1(t *testing.T) {
+	// ISO 9796-1 test vector, see doTest2
+	key := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: mod1, E: 3},
+		D:         pri1,
+	}
+
+	signature, err := SignISO9796d1(msg2, key)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if !isSameAs(signature, 1, sig2) {
+		t.Logf("Expected signature to equal\n%x\ngot\n%x\n", sig2, signature)
+		t.Fail()
+	}
+
+	recovered, err := RecoverISO9796d1(signature, &key.PublicKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if !reflect.DeepEqual(msg2, recovered) {
+		t.Logf("Expected recovered hash to equal\n%x\ngot\n%x\n", msg2, recovered)
+		t.Fail()
+	}
+}
+
+func TestSignISO9796d1WithCrtKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	hash := mustBytes(hex.DecodeString("8eb208f7e05d987a9b044a8e98c6b087f15a0bfc"))
+
+	signature, err := SignISO9796d1(hash, key)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if len(signature) != 256 {
+		t.Logf("Expected signature to have length %d, got %d\n", 256, len(signature))
+		t.Fail()
+	}
+
+	recovered, err := RecoverISO9796d1(signature, &key.PublicKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if !reflect.DeepEqual(hash, recovered) {
+		t.Logf("Expected recovered hash to equal\n%x\ngot\n%x\n", hash, recovered)
+		t.Fail()
+	}
+
+	signature[len(signature)/2] ^= 0x01
+
+	recovered, err = RecoverISO9796d1(signature, &key.PublicKey)
+	if err == nil && reflect.DeepEqual(hash, recovered) {
+		t.Logf("Expected tampered signature not to recover the hash\n")
+		t.Fail()
+	}
+}
@@ -6,10 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
-	"net"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/mitch000001/go-hbci/domain"
 	"github.com/mitch000001/go-hbci/element"
@@ -17,12 +14,15 @@ import (
 	"github.com/mitch000001/go-hbci/message"
 	"github.com/mitch000001/go-hbci/segment"
 	"github.com/mitch000001/go-hbci/transport"
+	middleware "github.com/mitch000001/go-hbci/transport/middleware"
 )
 
 // Dialog represents the common interface to use when talking to bank institutes
 type Dialog interface {
 	SyncClientSystemID() (string, error)
 	SendMessage(message.HBCIMessage) (message.BankMessage, error)
+	SendJobs(...segment.ClientSegment) ([]message.BankMessage, error)
+	SendContinuableJob(continuationReference string, newJob func(continuationReference string) (segment.ClientSegment, error)) ([]message.BankMessage, error)
 }
 
 const initialDialogID = "0"
@@ -70,6 +70,18 @@ type dialog struct {
 	BankParameterData domain.BankParameterData
 	hbciVersion       segment.HBCIVersion
 	supportedSegments []segment.VersionedSegment
+	retryPolicy       *middleware.RetryPolicy
+
+	// signatureVerifier verifies the signatures of encrypted responses if
+	// set. If requireSignatures is true, responses without valid signature
+	// are rejected.
+	signatureVerifier message.SignatureVerifier
+	requireSignatures bool
+	// pinProvider is told when the institute rejects the PIN, if set
+	pinProvider domain.PinProvider
+	// compress enables the compression of messages. The PIN/TAN profile
+	// fixes the compression function to "0", so only RDH dialogs compress.
+	compress bool
 }
 
 func (d *dialog) UserParameterDataVersion() int {
@@ -96,18 +108,171 @@ func (d *dialog) SetSecurityFunction(securityFn string) {
 	d.cryptoProvider.SetSecurityFunction(d.securityFn)
 }
 
+// SendMessage sends clientMessage within a new dialog. If a RetryPolicy is
+// configured and clientMessage only contains idempotent jobs, the dialog is
+// repeated if the institute rejects it for temporary reasons, e.g. by
+// aborting the dialog. Messages containing other jobs, like payments, are
+// never resent.
 func (d *dialog) SendMessage(clientMessage message.HBCIMessage) (message.BankMessage, error) {
+	if d.retryPolicy == nil || !isIdempotent(clientMessage) {
+		return d.sendMessage(clientMessage)
+	}
+	var bankMessage message.BankMessage
+	err := d.currentRetryPolicy().Do(func() error {
+		var err error
+		bankMessage, err = d.sendMessage(clientMessage)
+		return err
+	}, func(err error) bool {
+		instituteErr, ok := err.(*InstituteError)
+		return ok && instituteErr.Temporary()
+	})
+	return bankMessage, err
+}
+
+// currentRetryPolicy returns the configured RetryPolicy limited by the bank
+// parameter data
+func (d *dialog) currentRetryPolicy() middleware.RetryPolicy {
+	return d.retryPolicy.Limit(d.BankParameterData)
+}
+
+func isIdempotent(clientMessage message.HBCIMessage) bool {
+	for _, seg := range clientMessage.HBCISegments() {
+		if !middleware.IdempotentJobs[seg.Header().ID.Val()] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *dialog) sendMessage(clientMessage message.HBCIMessage) (message.BankMessage, error) {
+	err := d.init()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { logErr(d.end()) }()
+	return d.sendJobMessage(clientMessage)
+}
+
+// SendJobs sends jobs within a new dialog. The jobs are spread across as
+// many messages as needed to stay within the limits of the bank parameter
+// data. It returns the responses of all messages in the order of the jobs.
+//
+// Like with SendMessage, the whole dialog is repeated for temporary errors
+// if a RetryPolicy is configured and all jobs are idempotent.
+func (d *dialog) SendJobs(jobs ...segment.ClientSegment) ([]message.BankMessage, error) {
+	if d.retryPolicy == nil || !isIdempotent(message.NewHBCIMessage(d.hbciVersion, jobs...)) {
+		return d.sendJobs(jobs)
+	}
+	var bankMessages []message.BankMessage
+	err := d.currentRetryPolicy().Do(func() error {
+		var err error
+		bankMessages, err = d.sendJobs(jobs)
+		return err
+	}, func(err error) bool {
+		instituteErr, ok := err.(*InstituteError)
+		return ok && instituteErr.Temporary()
+	})
+	return bankMessages, err
+}
+
+func (d *dialog) sendJobs(jobs []segment.ClientSegment) ([]message.BankMessage, error) {
+	err := d.init()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { logErr(d.end()) }()
+	// The limits are known after the dialog initialization at the latest
+	batches, err := message.SplitJobs(d.BankParameterData, jobs)
+	if err != nil {
+		return nil, err
+	}
+	var bankMessages []message.BankMessage
+	for _, batch := range batches {
+		bankMessage, err := d.sendJobMessage(message.NewHBCIMessage(d.hbciVersion, batch...))
+		if err != nil {
+			return nil, err
+		}
+		bankMessages = append(bankMessages, bankMessage)
+	}
+	return bankMessages, nil
+}
+
+// SendContinuableJob sends the job returned by newJob for
+// continuationReference within a new dialog. As long as the institute
+// announces further information with a continuation reference, the job
+// returned by newJob for this reference is sent within the same dialog, as
+// continuation references are only valid within their dialog. It returns the
+// responses of all messages.
+//
+// Like with SendMessage, the whole dialog is repeated for temporary errors
+// if a RetryPolicy is configured and the job is idempotent.
+func (d *dialog) SendContinuableJob(continuationReference string, newJob func(continuationReference string) (segment.ClientSegment, error)) ([]message.BankMessage, error) {
+	job, err := newJob(continuationReference)
+	if err != nil {
+		return nil, err
+	}
+	if d.retryPolicy == nil || !isIdempotent(message.NewHBCIMessage(d.hbciVersion, job)) {
+		return d.sendContinuableJob(continuationReference, newJob)
+	}
+	var bankMessages []message.BankMessage
+	err = d.currentRetryPolicy().Do(func() error {
+		var err error
+		bankMessages, err = d.sendContinuableJob(continuationReference, newJob)
+		return err
+	}, func(err error) bool {
+		instituteErr, ok := err.(*InstituteError)
+		return ok && instituteErr.Temporary()
+	})
+	return bankMessages, err
+}
+
+func (d *dialog) sendContinuableJob(continuationReference string, newJob func(continuationReference string) (segment.ClientSegment, error)) ([]message.BankMessage, error) {
 	err := d.init()
 	if err != nil {
 		return nil, err
 	}
 	defer func() { logErr(d.end()) }()
+	var bankMessages []message.BankMessage
+	for {
+		job, err := newJob(continuationReference)
+		if err != nil {
+			return nil, err
+		}
+		bankMessage, err := d.sendJobMessage(message.NewHBCIMessage(d.hbciVersion, job))
+		if err != nil {
+			return nil, err
+		}
+		bankMessages = append(bankMessages, bankMessage)
+		continuationReference = nextContinuationReference(bankMessage)
+		if continuationReference == "" {
+			return bankMessages, nil
+		}
+	}
+}
+
+// nextContinuationReference returns the continuation reference announced
+// by the institute within bankMessage or an empty string if there is none
+func nextContinuationReference(bankMessage message.BankMessage) string {
+	for _, ack := range bankMessage.Acknowledgements() {
+		if ack.Code == element.AcknowledgementAdditionalInformation && len(ack.Params) > 0 {
+			return ack.Params[0]
+		}
+	}
+	return ""
+}
+
+// sendJobMessage sends clientMessage within the current dialog
+func (d *dialog) sendJobMessage(clientMessage message.HBCIMessage) (message.BankMessage, error) {
+	err := message.CheckLimits(d.BankParameterData, clientMessage, nil)
+	if err != nil {
+		return nil, err
+	}
 	requestMessage := d.newBasicMessage(clientMessage)
 	signedMessage, err := requestMessage.Sign(d.signatureProvider)
 	if err != nil {
 		return nil, err
 	}
-	encMessage, err := signedMessage.Encrypt(d.cryptoProvider)
+	encMessage, err := signedMessage.EncryptCompressed(d.cryptoProvider, d.compressionFunction())
 	if err != nil {
 		return nil, err
 	}
@@ -115,88 +280,100 @@ func (d *dialog) SendMessage(clientMessage message.HBCIMessage) (message.BankMes
 	if err != nil {
 		return nil, err
 	}
-	var errors []string
+	var errors []domain.Acknowledgement
 	acknowledgements := decryptedMessage.Acknowledgements()
 	for _, ack := range acknowledgements {
 		if ack.IsWarning() {
 			fmt.Printf("%v\n", ack)
 		}
 		if ack.IsError() {
-			errors = append(errors, ack.String())
+			errors = append(errors, ack)
 		}
 	}
 	if len(errors) > 0 {
-		return nil, fmt.Errorf("Institute returned errors:\n%s", strings.Join(errors, "\n"))
+		return nil, &InstituteError{Acknowledgements: errors}
 	}
 	return decryptedMessage, nil
 }
 
 func (d *dialog) SyncClientSystemID() (string, error) {
+	syncResponse, err := d.synchronize(segment.SyncModeAquireClientID, initialClientSystemID)
+	if err != nil {
+		return "", err
+	}
+	d.SetClientSystemID(syncResponse.ClientSystemID())
+
+	err = d.end()
+	if err != nil {
+		return "", err
+	}
+
+	return d.ClientSystemID, nil
+}
+
+// synchronize initializes a dialog with a synchronisation request (HKSYN)
+// for mode and returns the synchronisation response of the bank. The dialog
+// is left open, so the caller can update its state before ending it.
+func (d *dialog) synchronize(mode segment.SyncMode, clientSystemID string) (segment.SynchronisationResponse, error) {
+	d.dialogID = initialDialogID
+	d.messageCount = 0
 	syncMessage := message.NewSynchronisationMessage(d.hbciVersion)
-	syncMessage.Identification = segment.NewIdentificationSegment(d.BankID, d.clientID, initialClientSystemID, true)
+	syncMessage.Identification = segment.NewIdentificationSegment(d.BankID, d.clientID, clientSystemID, true)
 	syncMessage.ProcessingPreparation = segment.NewProcessingPreparationSegment(0, 0, 1)
-	syncMessage.Sync = d.hbciVersion.SynchronisationRequest(segment.SyncModeAquireClientID)
+	syncMessage.Sync = d.hbciVersion.SynchronisationRequest(mode)
 	syncMessage.BasicMessage = d.newBasicMessage(syncMessage)
 	signedSyncMessage, err := syncMessage.Sign(d.signatureProvider)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	d.cryptoProvider.SetClientSystemID(initialClientSystemID)
-	encryptedSyncMessage, err := signedSyncMessage.Encrypt(d.cryptoProvider)
+	d.cryptoProvider.SetClientSystemID(clientSystemID)
+	encryptedSyncMessage, err := signedSyncMessage.EncryptCompressed(d.cryptoProvider, d.compressionFunction())
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	decryptedMessage, err := d.request(encryptedSyncMessage)
 	if err != nil {
-		return "", fmt.Errorf("Error while extracting encrypted message: %v", err)
+		return nil, fmt.Errorf("Error while extracting encrypted message: %v", err)
 	}
 
 	messageHeader := decryptedMessage.MessageHeader()
 	if messageHeader == nil {
-		return "", fmt.Errorf("Malformed response message: %q", decryptedMessage)
+		return nil, fmt.Errorf("Malformed response message: %q", decryptedMessage)
 	}
 	d.dialogID = messageHeader.DialogID.Val()
 	d.supportedSegments = decryptedMessage.SupportedSegments()
 
-	var errors []string
+	var errors []domain.Acknowledgement
 	acknowledgements := decryptedMessage.Acknowledgements()
 	for _, ack := range acknowledgements {
 		if ack.IsWarning() {
 			internal.Info.Printf("%v\n", ack)
 		}
 		if ack.IsError() {
-			errors = append(errors, ack.String())
+			errors = append(errors, ack)
 		}
 	}
 	if len(errors) > 0 {
-		return "", fmt.Errorf("Institute returned errors:\n%s", strings.Join(errors, "\n"))
+		return nil, &InstituteError{Acknowledgements: errors}
 	}
 
 	syncResponse := decryptedMessage.FindSegment("HISYN")
-	if syncResponse != nil {
-		syncSegment := syncResponse.(segment.SynchronisationResponse)
-		d.SetClientSystemID(syncSegment.ClientSystemID())
-	} else {
-		return "", fmt.Errorf("Malformed message: missing unmarshaler for SynchronisationResponse")
+	if syncResponse == nil {
+		return nil, fmt.Errorf("Malformed message: missing unmarshaler for SynchronisationResponse")
 	}
 
 	err = d.parseBankParameterData(decryptedMessage)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	err = d.parseUserParameterData(decryptedMessage)
 	if err != nil {
-		return "", err
-	}
-
-	err = d.end()
-	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return d.ClientSystemID, nil
+	return syncResponse.(segment.SynchronisationResponse), nil
 }
 
 func (d *dialog) SendAnonymousMessage(clientMessage message.HBCIMessage) (message.BankMessage, error) {
@@ -212,48 +389,74 @@ func (d *dialog) SendAnonymousMessage(clientMessage message.HBCIMessage) (messag
 	if err != nil {
 		return nil, err
 	}
-	var errors []string
+	var errors []domain.Acknowledgement
 	acknowledgements := bankMessage.Acknowledgements()
 	for _, ack := range acknowledgements {
 		if ack.IsWarning() {
 			fmt.Printf("%v\n", ack)
 		}
 		if ack.IsError() {
-			errors = append(errors, ack.String())
+			errors = append(errors, ack)
 		}
 	}
 	if len(errors) > 0 {
-		return nil, fmt.Errorf("Institute returned errors:\n%s", strings.Join(errors, "\n"))
+		return nil, &InstituteError{Acknowledgements: errors}
 	}
 	return bankMessage, nil
 }
 
+// FetchBankParameterData fetches the bank parameter data within an
+// anonymous dialog. No credentials are needed for it.
+func (d *dialog) FetchBankParameterData() error {
+	err := d.anonymousInit()
+	if err != nil {
+		return fmt.Errorf("Error while initating anonymous dialog: %v", err)
+	}
+	return d.anonymousEnd()
+}
+
 func (d *dialog) anonymousInit() error {
-	d.dialogID = initialDialogID
-	d.messageCount = 0
+	_, err := d.sendAnonymousInit(d.newAnonymousInitMessage())
+	return err
+}
+
+// newAnonymousInitMessage returns an initialization message for an
+// anonymous dialog. Further segments can be added before sending it.
+func (d *dialog) newAnonymousInitMessage() *message.DialogInitializationClientMessage {
 	initMessage := message.NewDialogInitializationClientMessage(d.hbciVersion)
 	initMessage.Identification = segment.NewIdentificationSegment(d.BankID, anonymousClientID, initialClientSystemID, false)
 	initMessage.ProcessingPreparation = segment.NewProcessingPreparationSegment(d.BankParameterDataVersion(), d.UserParameterDataVersion(), d.Language)
+	return initMessage
+}
+
+// sendAnonymousInit initializes an anonymous dialog with initMessage and
+// returns the response of the bank
+func (d *dialog) sendAnonymousInit(initMessage *message.DialogInitializationClientMessage) (message.BankMessage, error) {
+	d.dialogID = initialDialogID
+	d.messageCount = 0
 	initMessage.BasicMessage = d.newBasicMessage(initMessage)
 	initMessage.SetNumbers()
 	bankMessage, err := d.request(initMessage)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	messageHeader := bankMessage.MessageHeader()
 	if messageHeader == nil {
-		return fmt.Errorf("Malformed response message: %q", bankMessage)
+		return nil, fmt.Errorf("Malformed response message: %q", bankMessage)
 	}
 	d.dialogID = messageHeader.DialogID.Val()
+	if bankMessage.FindMarshaledSegment("HIBPA") != nil {
+		d.supportedSegments = bankMessage.SupportedSegments()
+	}
 
 	err = d.parseBankParameterData(bankMessage)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = d.parseUserParameterData(bankMessage)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	bankInfoMessage := bankMessage.FindSegment("HIKIM")
@@ -262,20 +465,20 @@ func (d *dialog) anonymousInit() error {
 		internal.Info.Printf("INFO:\n%s\n%s\n", bankInfoSegment.Subject.Val(), bankInfoSegment.Body.Val())
 	}
 
-	errors := make([]string, 0)
+	var errors []domain.Acknowledgement
 	acknowledgements := bankMessage.Acknowledgements()
 	for _, ack := range acknowledgements {
 		if ack.IsWarning() {
 			fmt.Printf("%v\n", ack)
 		}
 		if ack.IsError() {
-			errors = append(errors, ack.String())
+			errors = append(errors, ack)
 		}
 	}
 	if len(errors) > 0 {
-		return fmt.Errorf("DialogEnd: Institute returned errors:\n%s", strings.Join(errors, "\n"))
+		return nil, &InstituteError{Context: "DialogEnd", Acknowledgements: errors}
 	}
-	return nil
+	return bankMessage, nil
 }
 
 func (d *dialog) anonymousEnd() error {
@@ -288,15 +491,15 @@ func (d *dialog) anonymousEnd() error {
 		return fmt.Errorf("Error while ending dialog: %v", err)
 	}
 
-	errors := make([]string, 0)
+	var errors []domain.Acknowledgement
 	acknowledgements := decryptedMessage.Acknowledgements()
 	for _, ack := range acknowledgements {
 		if ack.IsError() {
-			errors = append(errors, ack.String())
+			errors = append(errors, ack)
 		}
 	}
 	if len(errors) > 0 {
-		return fmt.Errorf("DialogEnd: Institute returned errors:\n%s", strings.Join(errors, "\n"))
+		return &InstituteError{Context: "DialogEnd", Acknowledgements: errors}
 	}
 
 	return nil
@@ -320,7 +523,7 @@ func (d *dialog) init() error {
 	if err != nil {
 		return err
 	}
-	encryptedInitMessage, err := signedInitMessage.Encrypt(d.cryptoProvider)
+	encryptedInitMessage, err := signedInitMessage.EncryptCompressed(d.cryptoProvider, d.compressionFunction())
 	if err != nil {
 		return err
 	}
@@ -352,7 +555,7 @@ func (d *dialog) init() error {
 	}
 
 	newSecurityFn := d.securityFn
-	errors := make([]string, 0)
+	var errors []domain.Acknowledgement
 	acknowledgements := decryptedMessage.Acknowledgements()
 	for _, ack := range acknowledgements {
 		if ack.Code == element.AcknowledgementSupportedSecurityFunction {
@@ -364,11 +567,11 @@ func (d *dialog) init() error {
 			}
 		}
 		if ack.IsError() {
-			errors = append(errors, ack.String())
+			errors = append(errors, ack)
 		}
 	}
 	if len(errors) > 0 {
-		return fmt.Errorf("DialogEnd: Institute returned errors:\n%s", strings.Join(errors, "\n"))
+		return &InstituteError{Context: "DialogEnd", Acknowledgements: errors}
 	}
 	if d.securityFn != newSecurityFn {
 		err = d.end()
@@ -392,7 +595,7 @@ func (d *dialog) end() error {
 	if err != nil {
 		return err
 	}
-	encryptedDialogEnd, err := signedDialogEnd.Encrypt(d.cryptoProvider)
+	encryptedDialogEnd, err := signedDialogEnd.EncryptCompressed(d.cryptoProvider, d.compressionFunction())
 	if err != nil {
 		return err
 	}
@@ -402,15 +605,15 @@ func (d *dialog) end() error {
 		return fmt.Errorf("Error while ending dialog: %v", err)
 	}
 
-	errors := make([]string, 0)
+	var errors []domain.Acknowledgement
 	acknowledgements := decryptedMessage.Acknowledgements()
 	for _, ack := range acknowledgements {
 		if ack.IsError() {
-			errors = append(errors, ack.String())
+			errors = append(errors, ack)
 		}
 	}
 	if len(errors) > 0 {
-		return fmt.Errorf("DialogEnd: Institute returned errors:\n%s", strings.Join(errors, "\n"))
+		return &InstituteError{Context: "DialogEnd", Acknowledgements: errors}
 	}
 
 	return nil
@@ -443,9 +646,63 @@ func (d *dialog) parseBankParameterData(bankMessage message.BankMessage) error {
 		}
 		d.BankParameterData.PinTanBusinessTransactions = pinTransactions
 	}
+	compressionMethods := bankMessage.FindSegment("HIKPV")
+	if compressionMethods != nil {
+		compressionSegment := compressionMethods.(*segment.CompressionMethodSegment)
+		d.BankParameterData.CompressionFunctions = compressionSegment.CompressionFunctions()
+	}
+	communicationAccess := bankMessage.FindSegment("HIKOM")
+	if communicationAccess != nil {
+		communicationSegment := communicationAccess.(*segment.CommunicationAccessResponseSegment)
+		d.BankParameterData.StandardLanguage = communicationSegment.Language()
+		d.BankParameterData.CommunicationParameters = communicationSegment.CommunicationParameters()
+	}
+	securityMethods := bankMessage.FindSegment("HISHV")
+	if securityMethods != nil {
+		securityMethodSegment := securityMethods.(*segment.SecurityMethodSegment)
+		d.BankParameterData.SecurityMethods = securityMethodSegment.SecurityMethods()
+		d.BankParameterData.SecurityMethodsMixAllowed = securityMethodSegment.MixingAllowed()
+	}
+	if transactions := parseBusinessTransactionParameters(bankMessage); transactions != nil {
+		d.BankParameterData.BusinessTransactions = transactions
+	}
 	return nil
 }
 
+// parseBusinessTransactionParameters returns the parameters of all jobs
+// described by parameter segments within bankMessage. Malformed parameter
+// segments are skipped.
+func parseBusinessTransactionParameters(bankMessage message.BankMessage) []domain.BusinessTransactionParameters {
+	var transactions []domain.BusinessTransactionParameters
+	parsed := make(map[string]bool)
+	for _, versionedSegment := range bankMessage.SupportedSegments() {
+		if parsed[versionedSegment.ID] || !strings.HasSuffix(versionedSegment.ID, "S") {
+			continue
+		}
+		parsed[versionedSegment.ID] = true
+		for _, marshaledSegment := range bankMessage.FindMarshaledSegments(versionedSegment.ID) {
+			paramSegment := &segment.BusinessTransactionParamsSegment{}
+			err := paramSegment.UnmarshalHBCI(marshaledSegment)
+			if err != nil {
+				internal.Debug.Printf("Error while parsing parameter segment %s: %v", versionedSegment.ID, err)
+				continue
+			}
+			transactions = append(transactions, paramSegment.BusinessTransactionParameters())
+		}
+	}
+	return transactions
+}
+
+// compressionFunction returns the compression function to use for messages
+// as negotiated with the bank parameter data. Dialogs not allowed to compress
+// always use message.CompressionNone.
+func (d *dialog) compressionFunction() string {
+	if !d.compress {
+		return message.CompressionNone
+	}
+	return message.SelectCompressionFunction(d.BankParameterData.CompressionFunctions)
+}
+
 func (d *dialog) parseUserParameterData(bankMessage message.BankMessage) error {
 	userParamData := bankMessage.FindSegment("HIUPA")
 	if userParamData != nil {
@@ -470,11 +727,10 @@ func (d *dialog) request(clientMessage message.ClientMessage) (message.BankMessa
 	if err != nil {
 		return nil, err
 	}
-	internal.Debug.Printf("Request:\n")
-	for _, seg := range bytes.Split(marshaledMessage, []byte("'")) {
-		internal.Debug.Printf("%q\n", seg)
+	err = message.CheckLimits(d.BankParameterData, nil, marshaledMessage)
+	if err != nil {
+		return nil, err
 	}
-
 	reqBody := bytes.NewReader(marshaledMessage)
 
 	request := &transport.Request{
@@ -502,6 +758,9 @@ func (d *dialog) request(clientMessage message.ClientMessage) (message.BankMessa
 		if err != nil {
 			return nil, fmt.Errorf("Error while decrypting message: %v", err)
 		}
+		if err := d.verifySignature(decryptedMessage); err != nil {
+			return nil, err
+		}
 		internal.Debug.Printf("Response:\n %s\n", decryptedMessage.MessageHeader())
 		bankMessage = decryptedMessage
 	} else {
@@ -509,13 +768,59 @@ func (d *dialog) request(clientMessage message.ClientMessage) (message.BankMessa
 		if err != nil {
 			return nil, err
 		}
+		// Only anonymous messages are sent unencrypted, so an unencrypted
+		// response to an authenticated message might be forged
+		if _, authenticated := clientMessage.(*message.EncryptedMessage); authenticated {
+			if d.requireSignatures {
+				return nil, fmt.Errorf("Unencrypted response to an authenticated message")
+			}
+			if err := d.verifySignature(decryptedMessage); err != nil {
+				return nil, err
+			}
+		}
 		internal.Debug.Printf("Response:\n %s\n", decryptedMessage.MessageHeader())
 		bankMessage = decryptedMessage
 	}
 
+	d.notifyPinRejected(bankMessage)
 	return bankMessage, err
 }
 
+// notifyPinRejected tells the PIN provider if the institute rejected the PIN
+// within bankMessage
+func (d *dialog) notifyPinRejected(bankMessage message.BankMessage) {
+	if d.pinProvider == nil {
+		return
+	}
+	for _, ack := range bankMessage.Acknowledgements() {
+		if pinRejectedCodes[ack.Code] {
+			d.pinProvider.PinRejected()
+			return
+		}
+	}
+}
+
+// verifySignature verifies the signature of bankMessage if a signature
+// verifier is set. Messages with a missing or invalid signature are only
+// rejected if signatures are required.
+func (d *dialog) verifySignature(bankMessage message.BankMessage) error {
+	if d.signatureVerifier == nil {
+		if d.requireSignatures {
+			return fmt.Errorf("Missing public signing key of the bank to verify the response")
+		}
+		return nil
+	}
+	err := bankMessage.VerifySignature(d.signatureVerifier)
+	if err == nil {
+		return nil
+	}
+	if d.requireSignatures {
+		return fmt.Errorf("Error while verifying signature: %v", err)
+	}
+	internal.Info.Printf("Unverified response: %v\n", err)
+	return nil
+}
+
 func (d *dialog) extractEncryptedMessage(response *transport.Response) (*message.EncryptedMessage, error) {
 	messageHeader := response.FindSegment("HNHBK")
 	if messageHeader == nil {
@@ -527,10 +832,23 @@ func (d *dialog) extractEncryptedMessage(response *transport.Response) (*message
 		return nil, fmt.Errorf("Error while unmarshaling message header: %v", err)
 	}
 	// TODO: parse messageEnd
-	// TODO: parse encryptionHeader
 
 	encMessage := message.NewEncryptedMessage(header, nil, d.hbciVersion)
 
+	// The encryption header determines the compression function and, for
+	// RDH, carries the message key. Institutes not compressing messages are
+	// not rejected for unusual headers, RDH responses fail on decryption.
+	encryptionHeader := response.FindSegment(segment.EncryptionHeaderSegmentID)
+	if encryptionHeader != nil {
+		encHeader := &segment.EncryptionHeaderSegment{}
+		err = encHeader.UnmarshalHBCI(encryptionHeader)
+		if err == nil {
+			encMessage.EncryptionHeader = encHeader
+		} else {
+			internal.Debug.Printf("Error while unmarshaling encryption header: %v\n", err)
+		}
+	}
+
 	encryptedData := response.FindSegment("HNVSD")
 	if encryptedData != nil {
 		encSegment := &segment.EncryptedDataSegment{}
@@ -568,43 +886,6 @@ func (d *dialog) nextMessageNumber() int {
 	return d.messageCount
 }
 
-func (d *dialog) dial(message []byte) ([]byte, error) {
-	conn, err := net.Dial("tcp4", d.hbciURL)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { logErr(conn.Close()) }()
-	err = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
-	if err != nil {
-		return nil, err
-	}
-	fmt.Fprintf(conn, "%s\r\n\r\n", string(message))
-	buf := bufio.NewReader(conn)
-	// read answer header
-	header, err := buf.ReadString('\'')
-	if err != nil {
-		return nil, err
-	}
-	headerItems := strings.Split(header, "+")
-	if len(headerItems) < 2 {
-		return nil, fmt.Errorf("response header too short")
-	}
-	sizeString := headerItems[1]
-	size, err := strconv.Atoi(sizeString)
-	if err != nil {
-		return nil, fmt.Errorf("error while parsing message size: %T:%v", err, err)
-	}
-	messageBuf := make([]byte, size)
-	_, err = buf.Read(messageBuf)
-	if err != nil {
-		return nil, fmt.Errorf("error while reading message: %T:%v", err, err)
-	}
-	var retBuf bytes.Buffer
-	retBuf.WriteString(header)
-	retBuf.Write(messageBuf)
-	return retBuf.Bytes(), err
-}
-
 func logErr(err error) {
 	if err != nil {
 		log.Println(err)
@@ -0,0 +1,67 @@
+package dialog
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mitch000001/go-hbci/domain"
+)
+
+// temporaryErrorCodes are acknowledgement codes of institutes signaling a
+// temporary condition. The message may succeed within a new dialog.
+var temporaryErrorCodes = map[int]bool{
+	9800: true, // Dialog abgebrochen
+}
+
+// summaryErrorCodes are acknowledgement codes which only summarize the
+// errors of a message
+var summaryErrorCodes = map[int]bool{
+	9000: true, // Nachricht enthält Fehler
+	9050: true, // Teilweise fehlerhaft
+}
+
+// pinRejectedCodes are acknowledgement codes of institutes rejecting the
+// PIN of the user
+var pinRejectedCodes = map[int]bool{
+	9931: true, // Anmeldename oder PIN ist falsch
+	9942: true, // PIN falsch
+}
+
+// An InstituteError is returned if the institute rejects a message with
+// error acknowledgements
+type InstituteError struct {
+	// Context describes the step of the dialog which failed, if any
+	Context string
+	// Acknowledgements are the error acknowledgements returned by the
+	// institute
+	Acknowledgements []domain.Acknowledgement
+}
+
+func (i *InstituteError) Error() string {
+	var buf bytes.Buffer
+	if i.Context != "" {
+		fmt.Fprintf(&buf, "%s: ", i.Context)
+	}
+	buf.WriteString("Institute returned errors:")
+	for _, ack := range i.Acknowledgements {
+		fmt.Fprintf(&buf, "\n%s", ack)
+	}
+	return buf.String()
+}
+
+// Temporary returns true if the institute rejected the message only for
+// temporary reasons, like an aborted dialog. Errors caused by the message
+// itself or by the credentials, like a wrong PIN, are never temporary.
+func (i *InstituteError) Temporary() bool {
+	temporary := false
+	for _, ack := range i.Acknowledgements {
+		switch {
+		case temporaryErrorCodes[ack.Code]:
+			temporary = true
+		case summaryErrorCodes[ack.Code]:
+		default:
+			return false
+		}
+	}
+	return temporary
+}
@@ -0,0 +1,35 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/mitch000001/go-hbci/domain"
+)
+
+func TestInstituteErrorTemporary(t *testing.T) {
+	tests := []struct {
+		description string
+		codes       []int
+		temporary   bool
+	}{
+		{"aborted dialog", []int{9800}, true},
+		{"aborted dialog with summary", []int{9050, 9800}, true},
+		{"generic rejection", []int{9010}, false},
+		{"wrong PIN", []int{9800, 9942}, false},
+		{"summary only", []int{9050}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var acknowledgements []domain.Acknowledgement
+			for _, code := range test.codes {
+				acknowledgements = append(acknowledgements, domain.Acknowledgement{Code: code})
+			}
+			err := &InstituteError{Acknowledgements: acknowledgements}
+
+			if err.Temporary() != test.temporary {
+				t.Logf("Expected Temporary to return %t for codes %v, got %t\n", test.temporary, test.codes, err.Temporary())
+				t.Fail()
+			}
+		})
+	}
+}
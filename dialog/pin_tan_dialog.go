@@ -19,6 +19,10 @@ type Config struct {
 	UserID      string
 	HBCIVersion segment.HBCIVersion
 	Transport   transport.Transport
+	// RetryPolicy enables retries of messages only containing idempotent
+	// jobs. Its limits are bounded by the timeouts of the bank parameter
+	// data. If nil, messages are never retried.
+	RetryPolicy *middleware.RetryPolicy
 }
 
 // NewPinTanDialog creates a new dialog to use for pin/tan transport
@@ -45,7 +49,14 @@ func NewPinTanDialog(config Config) *PinTanDialog {
 		dialogTransport = config.Transport
 	}
 	dialogTransport = middleware.Base64Encoding(base64.StdEncoding)(dialogTransport)
-	dialogTransport = middleware.Logging(internal.Debug)(dialogTransport)
+	if config.RetryPolicy != nil {
+		d.retryPolicy = config.RetryPolicy
+		encodingTransport := dialogTransport
+		dialogTransport = transport.Func(func(req *transport.Request) (*transport.Response, error) {
+			return middleware.Retry(d.currentRetryPolicy())(encodingTransport).Do(req)
+		})
+	}
+	dialogTransport = middleware.RedactedLogging(internal.Debug, nil)(dialogTransport)
 	d.transport = dialogTransport
 	return d
 }
@@ -61,4 +72,43 @@ func (d *PinTanDialog) SetPin(pin string) {
 	d.signatureProvider = message.NewPinTanSignatureProvider(pinKey, d.ClientSystemID)
 	pinKey = domain.NewPinKey(pin, domain.NewPinTanKeyName(d.BankID, d.UserID, "V"))
 	d.cryptoProvider = message.NewPinTanCryptoProvider(pinKey, d.ClientSystemID)
+	d.pinProvider = nil
+}
+
+// SetPinProvider lets the dialog ask provider for the PIN whenever a message
+// has to be signed, instead of keeping the PIN. The provider is told when the
+// institute rejects the PIN.
+func (d *PinTanDialog) SetPinProvider(provider domain.PinProvider) {
+	pinKey := domain.NewPinProviderKey(provider, domain.NewPinTanKeyName(d.BankID, d.UserID, "S"))
+	d.signatureProvider = message.NewPinTanSignatureProvider(pinKey, d.ClientSystemID)
+	pinKey = domain.NewPinProviderKey(provider, domain.NewPinTanKeyName(d.BankID, d.UserID, "V"))
+	d.cryptoProvider = message.NewPinTanCryptoProvider(pinKey, d.ClientSystemID)
+	d.pinProvider = provider
+}
+
+// ChangePin sends pinChangeRequest within a new dialog. Once the institute
+// accepted newPin, the dialog switches to it, so that the end of the dialog
+// is already signed with the new PIN. A PinProvider is told about newPin if
+// it implements domain.PinChangeListener. Otherwise it is told to forget the
+// old PIN, as if it was rejected.
+func (d *PinTanDialog) ChangePin(pinChangeRequest segment.ClientSegment, newPin string) (message.BankMessage, error) {
+	err := d.init()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { logErr(d.end()) }()
+	bankMessage, err := d.sendJobMessage(message.NewHBCIMessage(d.hbciVersion, pinChangeRequest))
+	if err != nil {
+		return nil, err
+	}
+	if d.pinProvider == nil {
+		d.SetPin(newPin)
+		return bankMessage, nil
+	}
+	if listener, ok := d.pinProvider.(domain.PinChangeListener); ok {
+		listener.PinChanged(newPin)
+	} else {
+		d.pinProvider.PinRejected()
+	}
+	return bankMessage, nil
 }
@@ -1,25 +1,460 @@
 package dialog
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/internal"
 	"github.com/mitch000001/go-hbci/message"
 	"github.com/mitch000001/go-hbci/segment"
+	"github.com/mitch000001/go-hbci/transport"
+	middleware "github.com/mitch000001/go-hbci/transport/middleware"
+	tcp "github.com/mitch000001/go-hbci/transport/tcp"
 )
 
+// RDHConfig contains the configuration of a RDH dialog
+type RDHConfig struct {
+	BankID domain.BankID
+	// HBCIURL is the address of the bank given as "host" or "host:port". If
+	// the port is omitted, the HBCI port 3000 is used.
+	HBCIURL     string
+	UserID      string
+	HBCIVersion segment.HBCIVersion
+	// SigningKey is the private signing key of the user. If nil, a new key
+	// is generated.
+	SigningKey *domain.RSAKey
+	// EncryptionKey is the private encryption key of the user, used to
+	// decrypt the responses of the bank. If nil, a new key is generated.
+	EncryptionKey *domain.RSAKey
+	// BankEncryptionKey is the public encryption key of the bank. Messages
+	// can't be encrypted without it. If nil, it can be fetched with
+	// FetchBankKeys.
+	BankEncryptionKey *domain.RSAKey
+	// BankSigningKey is the public signing key of the bank
+	BankSigningKey *domain.RSAKey
+	// Profile is the RDH or RAH security profile to use. If nil, the
+	// preferred profile out of the security methods offered by the bank
+	// within its bank parameter data is used. The bank parameter data are
+	// then fetched anonymously before the first message is sent.
+	Profile *message.RDHProfile
+	// SignatureID is the ID of the next signature of the user. If zero, the
+	// first signature uses the ID 1.
+	SignatureID int
+	// RequireSignatures rejects encrypted responses which are not signed
+	// with BankSigningKey, as well as unencrypted responses to messages
+	// outside of anonymous dialogs. Otherwise, such responses are only
+	// logged.
+	RequireSignatures bool
+	// Transport is the transport used to send the messages. If nil, the
+	// messages are sent over plain TCP.
+	Transport transport.Transport
+}
+
 // NewRDHDialog creates a dialog to use with cardreader flow
-func NewRDHDialog(bankID domain.BankID, hbciURL string, clientID string, hbciVersion segment.HBCIVersion) Dialog {
-	key, err := domain.GenerateSigningKey()
-	if err != nil {
-		panic(err)
+func NewRDHDialog(config RDHConfig) (*RDHDialog, error) {
+	signingKey := config.SigningKey
+	if signingKey == nil {
+		key, err := domain.GenerateSigningKey()
+		if err != nil {
+			return nil, err
+		}
+		signingKey = newUserKey(key, config.BankID, config.UserID)
+	}
+	encryptionKey := config.EncryptionKey
+	if encryptionKey == nil {
+		key, err := domain.GenerateEncryptionKey()
+		if err != nil {
+			return nil, err
+		}
+		encryptionKey = newUserKey(key, config.BankID, config.UserID)
+	}
+	profile := message.RDH2
+	if config.Profile != nil {
+		profile = *config.Profile
+	}
+	signatureID := config.SignatureID
+	if signatureID == 0 {
+		signatureID = 1
 	}
-	signingKey := domain.NewRSAKey(key, domain.NewInitialKeyName(bankID.CountryCode, bankID.ID, clientID, "S"))
-	provider := message.NewRDHSignatureProvider(signingKey, 12345)
-	d := &rdhDialog{
-		dialog: newDialog(bankID, hbciURL, clientID, hbciVersion, provider, nil),
+	d := &RDHDialog{
+		signingKey:        signingKey,
+		encryptionKey:     encryptionKey,
+		bankEncryptionKey: config.BankEncryptionKey,
+		bankSigningKey:    config.BankSigningKey,
+		profileSelected:   config.Profile != nil,
 	}
-	return d
+	d.dialog = newDialog(
+		config.BankID,
+		config.HBCIURL,
+		config.UserID,
+		config.HBCIVersion,
+		nil,
+		nil,
+	)
+	d.requireSignatures = config.RequireSignatures
+	d.compress = true
+	d.rdhSignatureProvider = message.NewRDHSignatureProvider(signingKey, signatureID, profile)
+	d.setProfile(profile)
+	dialogTransport := config.Transport
+	if dialogTransport == nil {
+		dialogTransport = tcp.New()
+	}
+	d.transport = middleware.RedactedLogging(internal.Debug, nil)(dialogTransport)
+	return d, nil
+}
+
+// newUserKey returns key named as the first version of a newly generated
+// key of the user
+func newUserKey(key *domain.PublicKey, bankID domain.BankID, userID string) *domain.RSAKey {
+	keyName := domain.NewInitialKeyName(bankID.CountryCode, bankID.ID, userID, key.Type)
+	return domain.NewRSAKey(key, keyName.NextVersion())
 }
 
-type rdhDialog struct {
+// RDHDialog represents a dialog to use with the RDH and RAH security
+// profiles over TCP
+type RDHDialog struct {
 	*dialog
+	signingKey        *domain.RSAKey
+	encryptionKey     *domain.RSAKey
+	bankEncryptionKey *domain.RSAKey
+	bankSigningKey    *domain.RSAKey
+	profile           message.RDHProfile
+	profileSelected   bool
+	// rdhSignatureProvider is the signatureProvider of the embedded dialog,
+	// keeping track of the signature ID
+	rdhSignatureProvider *message.RDHSignatureProvider
+}
+
+// Profile returns the security profile used by the dialog
+func (r *RDHDialog) Profile() message.RDHProfile {
+	return r.profile
+}
+
+// SigningKey returns the private signing key of the user
+func (r *RDHDialog) SigningKey() *domain.RSAKey {
+	return r.signingKey
+}
+
+// EncryptionKey returns the private encryption key of the user
+func (r *RDHDialog) EncryptionKey() *domain.RSAKey {
+	return r.encryptionKey
+}
+
+// SignatureID returns the ID of the next signature of the user. It has to be
+// kept along with the keys, as the bank rejects signature IDs used before.
+func (r *RDHDialog) SignatureID() int {
+	return r.rdhSignatureProvider.SignatureID()
+}
+
+// BankSigningKey returns the public signing key of the bank, or nil if it is
+// unknown
+func (r *RDHDialog) BankSigningKey() *domain.RSAKey {
+	return r.bankSigningKey
+}
+
+// BankEncryptionKey returns the public encryption key of the bank, or nil if
+// it is unknown
+func (r *RDHDialog) BankEncryptionKey() *domain.RSAKey {
+	return r.bankEncryptionKey
+}
+
+// RequiresSignatures returns true if responses without valid signature of
+// the bank are rejected
+func (r *RDHDialog) RequiresSignatures() bool {
+	return r.requireSignatures
+}
+
+// FetchBankKeys fetches the public signing and encryption keys of the bank
+// within an anonymous dialog (HKISA). The bank parameter data are fetched
+// along with them. Before submitting the keys of the user, the user must
+// compare the hashes of the keys with the INI letter of the bank, see
+// RDHProfile.KeyHash.
+func (r *RDHDialog) FetchBankKeys() error {
+	keys, err := r.requestBankKeys()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		switch key.KeyName().KeyType {
+		case "S":
+			r.bankSigningKey = key
+		case "V":
+			r.bankEncryptionKey = key
+		}
+	}
+	if r.bankEncryptionKey == nil {
+		return fmt.Errorf("Malformed response: missing public encryption key of the bank")
+	}
+	if err := r.selectProfile(); err != nil {
+		return err
+	}
+	r.setProfile(r.profile)
+	return nil
+}
+
+func (r *RDHDialog) requestBankKeys() ([]*domain.RSAKey, error) {
+	initMessage := r.newAnonymousInitMessage()
+	initMessage.PublicSigningKeyRequest = segment.NewPublicKeyRequestSegment(0, r.bankKeyName("S"))
+	initMessage.PublicEncryptionKeyRequest = segment.NewPublicKeyRequestSegment(0, r.bankKeyName("V"))
+	bankMessage, err := r.sendAnonymousInit(initMessage)
+	if err != nil {
+		return nil, fmt.Errorf("Error while initating anonymous dialog: %v", err)
+	}
+	defer func() { logErr(r.anonymousEnd()) }()
+	var keys []*domain.RSAKey
+	for _, seg := range bankMessage.FindSegments("HIISA") {
+		transmission := seg.(*segment.PublicKeyTransmissionSegment)
+		if transmission.KeyName == nil || transmission.PublicKey == nil {
+			return nil, fmt.Errorf("Malformed public key transmission: %q", transmission)
+		}
+		keyName := transmission.KeyName.Val()
+		keys = append(keys, domain.NewRSAKey(transmission.PublicKey.Val(), &keyName))
+	}
+	return keys, nil
+}
+
+// bankKeyName returns the name to request the current key of the bank with.
+// Keys of the bank are named after the bank ID.
+func (r *RDHDialog) bankKeyName(keyType string) domain.KeyName {
+	return *domain.NewInitialKeyName(r.BankID.CountryCode, r.BankID.ID, r.BankID.ID, keyType)
+}
+
+// SubmitPublicKeys submits the public signing and encryption keys of the
+// user to the bank (HKSAK). The message is signed with the new signing key
+// and encrypted with the public encryption key of the bank, which has to be
+// known, e.g. by calling FetchBankKeys. The bank unlocks the keys once it
+// received the INI letter signed by the user.
+func (r *RDHDialog) SubmitPublicKeys() error {
+	if r.bankEncryptionKey == nil {
+		return fmt.Errorf("Missing public encryption key of the bank")
+	}
+	if err := r.selectProfile(); err != nil {
+		return err
+	}
+	r.dialogID = initialDialogID
+	r.messageCount = 0
+	initMessage := message.NewDialogInitializationClientMessage(r.hbciVersion)
+	initMessage.Identification = segment.NewIdentificationSegment(r.BankID, r.clientID, initialClientSystemID, true)
+	initMessage.ProcessingPreparation = segment.NewProcessingPreparationSegment(r.BankParameterDataVersion(), r.UserParameterDataVersion(), r.Language)
+	signingKeyRenewal, encryptionKeyRenewal, err := r.newKeyRenewalSegments(r.signingKey, r.encryptionKey)
+	if err != nil {
+		return err
+	}
+	initMessage.PublicSigningKeyRenewal = signingKeyRenewal
+	initMessage.PublicEncryptionKeyRenewal = encryptionKeyRenewal
+	initMessage.BasicMessage = r.newBasicMessage(initMessage)
+	signedInitMessage, err := initMessage.Sign(r.signatureProvider)
+	if err != nil {
+		return err
+	}
+	encryptedInitMessage, err := signedInitMessage.EncryptCompressed(r.cryptoProvider, r.compressionFunction())
+	if err != nil {
+		return err
+	}
+
+	decryptedMessage, err := r.request(encryptedInitMessage)
+	if err != nil {
+		return fmt.Errorf("Error while submitting public keys: %v", err)
+	}
+	messageHeader := decryptedMessage.MessageHeader()
+	if messageHeader == nil {
+		return fmt.Errorf("Malformed response message: %q", decryptedMessage)
+	}
+	r.dialogID = messageHeader.DialogID.Val()
+
+	var errors []domain.Acknowledgement
+	for _, ack := range decryptedMessage.Acknowledgements() {
+		if ack.IsWarning() {
+			internal.Info.Printf("%v\n", ack)
+		}
+		if ack.IsError() {
+			errors = append(errors, ack)
+		}
+	}
+	if len(errors) > 0 {
+		return &InstituteError{Context: "PublicKeySubmission", Acknowledgements: errors}
+	}
+	return r.end()
+}
+
+// INILetter returns the INI letter for the public keys of the user. It has
+// to be printed, signed and sent to the bank after submitting the keys.
+func (r *RDHDialog) INILetter() domain.INILetter {
+	letter := domain.INILetter{
+		BankID:          r.BankID,
+		UserID:          r.UserID,
+		SecurityProfile: r.profile.String(),
+		Date:            time.Now(),
+	}
+	for _, key := range []*domain.RSAKey{r.signingKey, r.encryptionKey} {
+		letter.Keys = append(letter.Keys, domain.INILetterKey{
+			KeyName:  key.KeyName(),
+			Exponent: key.Exponent,
+			Modulus:  key.Modulus,
+			Hash:     r.profile.KeyHash(key.PublicKey),
+		})
+	}
+	return letter
+}
+
+// SyncClientSystemID synchronizes the client system ID, selecting the
+// security profile first if needed
+func (r *RDHDialog) SyncClientSystemID() (string, error) {
+	if err := r.selectProfile(); err != nil {
+		return "", err
+	}
+	return r.dialog.SyncClientSystemID()
+}
+
+// SendMessage sends clientMessage within a new dialog, selecting the
+// security profile first if needed
+func (r *RDHDialog) SendMessage(clientMessage message.HBCIMessage) (message.BankMessage, error) {
+	if err := r.selectProfile(); err != nil {
+		return nil, err
+	}
+	return r.dialog.SendMessage(clientMessage)
+}
+
+// SendJobs sends jobs within a new dialog, selecting the security profile
+// first if needed
+func (r *RDHDialog) SendJobs(jobs ...segment.ClientSegment) ([]message.BankMessage, error) {
+	if err := r.selectProfile(); err != nil {
+		return nil, err
+	}
+	return r.dialog.SendJobs(jobs...)
+}
+
+// SendContinuableJob sends the job returned by newJob within a new dialog,
+// following the continuation references of the institute. It selects the
+// security profile first if needed.
+func (r *RDHDialog) SendContinuableJob(continuationReference string, newJob func(continuationReference string) (segment.ClientSegment, error)) ([]message.BankMessage, error) {
+	if err := r.selectProfile(); err != nil {
+		return nil, err
+	}
+	return r.dialog.SendContinuableJob(continuationReference, newJob)
+}
+
+// selectProfile selects the preferred profile out of the security methods
+// offered by the bank within HISHV, if no profile was configured
+func (r *RDHDialog) selectProfile() error {
+	if r.profileSelected {
+		return nil
+	}
+	if len(r.BankParameterData.SecurityMethods) == 0 {
+		if err := r.FetchBankParameterData(); err != nil {
+			return fmt.Errorf("Error while fetching bank parameter data: %v", err)
+		}
+	}
+	profile, err := message.SelectRDHProfile(r.BankParameterData.SecurityMethods)
+	if err != nil {
+		return err
+	}
+	r.setProfile(profile)
+	r.profileSelected = true
+	return nil
+}
+
+func (r *RDHDialog) setProfile(profile message.RDHProfile) {
+	r.profile = profile
+	r.rdhSignatureProvider = message.NewRDHSignatureProvider(r.signingKey, r.rdhSignatureProvider.SignatureID(), profile)
+	r.rdhSignatureProvider.SetClientSystemID(r.ClientSystemID)
+	r.signatureProvider = r.rdhSignatureProvider
+	r.cryptoProvider = message.NewRDHCryptoProvider(r.bankEncryptionKey, r.encryptionKey, r.ClientSystemID, profile)
+	if r.bankSigningKey != nil {
+		r.signatureVerifier = message.NewRDHSignatureVerifier(r.bankSigningKey, profile)
+	}
+}
+
+// SyncSignatureID synchronizes the signature ID with the bank (HKSYN mode
+// 2). It returns the ID of the next signature, which is the last signature
+// ID known to the bank increased by one.
+func (r *RDHDialog) SyncSignatureID() (int, error) {
+	if err := r.selectProfile(); err != nil {
+		return 0, err
+	}
+	syncResponse, err := r.synchronize(segment.SyncModeAquireSignatureID, r.ClientSystemID)
+	if err != nil {
+		return 0, err
+	}
+	r.rdhSignatureProvider.SetSignatureID(syncResponse.SignatureID() + 1)
+	if err := r.end(); err != nil {
+		return 0, err
+	}
+	return r.SignatureID(), nil
+}
+
+// ChangeKeys generates the next version of the signing and encryption key
+// of the user and submits them to the bank (HKSAK). The message is signed
+// with the current signing key. The new keys are used once the bank accepted
+// them.
+func (r *RDHDialog) ChangeKeys() error {
+	signingKey, err := domain.GenerateSigningKey()
+	if err != nil {
+		return err
+	}
+	encryptionKey, err := domain.GenerateEncryptionKey()
+	if err != nil {
+		return err
+	}
+	signingKeyName := r.signingKey.KeyName()
+	encryptionKeyName := r.encryptionKey.KeyName()
+	return r.changeKeys(
+		domain.NewRSAKey(signingKey, signingKeyName.NextVersion()),
+		domain.NewRSAKey(encryptionKey, encryptionKeyName.NextVersion()),
+	)
+}
+
+// newKeyRenewalSegments returns the segments transmitting the public parts
+// of signingKey and encryptionKey with the operation modes of the profile
+func (r *RDHDialog) newKeyRenewalSegments(signingKey, encryptionKey *domain.RSAKey) (*segment.PublicKeyRenewalSegment, *segment.PublicKeyRenewalSegment, error) {
+	signingKeyRenewal, err := segment.NewPublicKeyRenewalSegment(0, signingKey.KeyName(), signingKey.PublicKey, r.profile.SignatureMode)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Malformed signing key: %v", err)
+	}
+	encryptionKeyRenewal, err := segment.NewPublicKeyRenewalSegment(0, encryptionKey.KeyName(), encryptionKey.PublicKey, r.profile.EncryptionMode)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Malformed encryption key: %v", err)
+	}
+	return signingKeyRenewal, encryptionKeyRenewal, nil
+}
+
+func (r *RDHDialog) changeKeys(signingKey, encryptionKey *domain.RSAKey) error {
+	if err := r.selectProfile(); err != nil {
+		return err
+	}
+	signingKeyRenewal, encryptionKeyRenewal, err := r.newKeyRenewalSegments(signingKey, encryptionKey)
+	if err != nil {
+		return err
+	}
+	_, err = r.SendMessage(message.NewHBCIMessage(r.hbciVersion, signingKeyRenewal, encryptionKeyRenewal))
+	if err != nil {
+		return fmt.Errorf("Error while changing keys: %v", err)
+	}
+	r.signingKey = signingKey
+	r.encryptionKey = encryptionKey
+	r.setProfile(r.profile)
+	return nil
+}
+
+// LockKeys locks the signing and encryption key of the user (HKSSP), e.g. if
+// they are compromised. reason must be one of segment.KeyCompromitted,
+// segment.KeyMaybeCompromitted or segment.KeyRevocationMisc. The keys can't
+// be used anymore afterwards, so new keys have to be submitted.
+func (r *RDHDialog) LockKeys(reason string) error {
+	if !segment.IsValidRevocationReason(reason) {
+		return fmt.Errorf("Invalid revocation reason: %q", reason)
+	}
+	if err := r.selectProfile(); err != nil {
+		return err
+	}
+	_, err := r.SendMessage(message.NewHBCIMessage(
+		r.hbciVersion,
+		segment.NewPublicKeyRevocationSegment(0, r.signingKey.KeyName(), reason),
+		segment.NewPublicKeyRevocationSegment(0, r.encryptionKey.KeyName(), reason),
+	))
+	if err != nil {
+		return fmt.Errorf("Error while locking keys: %v", err)
+	}
+	return nil
 }
@@ -0,0 +1,325 @@
+package dialog
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/element"
+	"github.com/mitch000001/go-hbci/message"
+	"github.com/mitch000001/go-hbci/segment"
+)
+
+func TestRDHDialogFetchBankKeys(t *testing.T) {
+	transport := &mockHTTPSTransport{}
+	signingModulus := bytes.Repeat([]byte{0xAB, 0x27}, 16)
+	encryptionModulus := bytes.Repeat([]byte{0x2B, 0x3A}, 16)
+	initResponse := unencryptedTestMessage(
+		"abcde",
+		"HIRMG:2:2:1+0020::Auftrag entgegengenommen'",
+		"HISHV:3:3:1+N+RDH:2:10'",
+		fmt.Sprintf("HIISA:4:2:3+1+abcde+1+224+280:10000000:10000000:S:1:1+6:16:10:@%d@%s:12:@3@\x01\x00\x01:13'", len(signingModulus), signingModulus),
+		fmt.Sprintf("HIISA:5:2:4+1+abcde+1+224+280:10000000:10000000:V:1:2+5:16:10:@%d@%s:12:@3@\x01\x00\x01:13'", len(encryptionModulus), encryptionModulus),
+	)
+	dialogEndResponse := unencryptedTestMessage("abcde", "HIRMG:2:2:1+0100::Dialog beendet'")
+	transport.SetResponseMessages([][]byte{initResponse, dialogEndResponse})
+
+	d, err := NewRDHDialog(RDHConfig{
+		BankID:        domain.BankID{CountryCode: 280, ID: "10000000"},
+		HBCIURL:       "localhost",
+		UserID:        "12345",
+		HBCIVersion:   segment.FINTS300,
+		SigningKey:    domain.NewRSAKey(domain.NewRSAPublicKey("S", []byte{0xFF}, []byte{0x01, 0x00, 0x01}), domain.NewInitialKeyName(280, "10000000", "12345", "S")),
+		EncryptionKey: domain.NewRSAKey(domain.NewRSAPublicKey("V", []byte{0xFF}, []byte{0x01, 0x00, 0x01}), domain.NewInitialKeyName(280, "10000000", "12345", "V")),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	d.transport = transport
+
+	err = d.FetchBankKeys()
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	request := transport.requests[0]
+	marshaledRequest := new(bytes.Buffer)
+	marshaledRequest.ReadFrom(request.Body)
+	for _, expected := range []string{
+		"HKISA:4:2+2+124+280:10000000:10000000:S:999:999'",
+		"HKISA:5:2+2+124+280:10000000:10000000:V:999:999'",
+	} {
+		if !strings.Contains(marshaledRequest.String(), expected) {
+			t.Logf("Expected request to contain\n%q\n\tgot\n%q\n", expected, marshaledRequest.String())
+			t.Fail()
+		}
+	}
+
+	tests := []struct {
+		key             *domain.RSAKey
+		expectedKeyName domain.KeyName
+		expectedModulus []byte
+	}{
+		{
+			d.BankSigningKey(),
+			domain.KeyName{BankID: domain.BankID{CountryCode: 280, ID: "10000000"}, UserID: "10000000", KeyType: "S", KeyNumber: 1, KeyVersion: 1},
+			signingModulus,
+		},
+		{
+			d.BankEncryptionKey(),
+			domain.KeyName{BankID: domain.BankID{CountryCode: 280, ID: "10000000"}, UserID: "10000000", KeyType: "V", KeyNumber: 1, KeyVersion: 2},
+			encryptionModulus,
+		},
+	}
+	for _, test := range tests {
+		if test.key == nil {
+			t.Fatalf("Expected bank key %s to be set\n", test.expectedKeyName.KeyType)
+		}
+		if !reflect.DeepEqual(test.expectedKeyName, test.key.KeyName()) {
+			t.Logf("Expected key name to equal\n%#v\n\tgot\n%#v\n", test.expectedKeyName, test.key.KeyName())
+			t.Fail()
+		}
+		if !bytes.Equal(test.expectedModulus, test.key.Modulus) {
+			t.Logf("Expected modulus to equal\n% X\n\tgot\n% X\n", test.expectedModulus, test.key.Modulus)
+			t.Fail()
+		}
+		if test.key.EncryptionKey() == nil || test.key.EncryptionKey().E != 65537 {
+			t.Logf("Expected key to have a public RSA key with exponent 65537\n")
+			t.Fail()
+		}
+	}
+
+	if d.Profile() != message.RDH10 {
+		t.Logf("Expected profile to equal %s, got %s\n", message.RDH10, d.Profile())
+		t.Fail()
+	}
+}
+
+func TestRDHDialogINILetter(t *testing.T) {
+	d, err := NewRDHDialog(RDHConfig{
+		BankID:      domain.BankID{CountryCode: 280, ID: "10000000"},
+		HBCIURL:     "localhost",
+		UserID:      "12345",
+		HBCIVersion: segment.FINTS300,
+		Profile:     &message.RDH10,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	letter := d.INILetter()
+
+	if letter.SecurityProfile != "RDH-10" {
+		t.Logf("Expected security profile to equal %q, got %q\n", "RDH-10", letter.SecurityProfile)
+		t.Fail()
+	}
+	if len(letter.Keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %d\n", len(letter.Keys))
+	}
+	for i, keyType := range []string{"S", "V"} {
+		key := letter.Keys[i]
+		if key.KeyName.KeyType != keyType || key.KeyName.KeyNumber != 1 || key.KeyName.KeyVersion != 1 {
+			t.Logf("Expected key %d to be the first version of key %s, got %#v\n", i, keyType, key.KeyName)
+			t.Fail()
+		}
+		if len(key.Modulus) != domain.RDHKeySize/8 {
+			t.Logf("Expected modulus to have %d bytes, got %d\n", domain.RDHKeySize/8, len(key.Modulus))
+			t.Fail()
+		}
+		if len(key.Hash) != 32 {
+			t.Logf("Expected SHA-256 hash, got %d bytes\n", len(key.Hash))
+			t.Fail()
+		}
+	}
+}
+
+func TestRDHDialogRequireSignatures(t *testing.T) {
+	bankKey, err := domain.GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	bankSigningKey := domain.NewRSAKey(
+		domain.NewRSAPublicKey("S", bankKey.Modulus, bankKey.Exponent),
+		domain.NewInitialKeyName(280, "10000000", "10000000", "S").NextVersion(),
+	)
+	tests := []struct {
+		description       string
+		bankSigningKey    *domain.RSAKey
+		requireSignatures bool
+		expectError       bool
+		expectedStatus    message.SignatureStatus
+	}{
+		{"signatures required", bankSigningKey, true, true, message.SignatureMissing},
+		{"signatures not required", bankSigningKey, false, false, message.SignatureMissing},
+		{"missing bank key", nil, true, true, message.SignatureUnverified},
+		{"missing bank key, signatures not required", nil, false, false, message.SignatureUnverified},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			d, err := NewRDHDialog(RDHConfig{
+				BankID:            domain.BankID{CountryCode: 280, ID: "10000000"},
+				HBCIURL:           "localhost",
+				UserID:            "12345",
+				HBCIVersion:       segment.FINTS300,
+				Profile:           &message.RDH10,
+				BankSigningKey:    test.bankSigningKey,
+				RequireSignatures: test.requireSignatures,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+			header := segment.NewMessageHeaderSegment(1, 300, "abcde", 1)
+			unsignedMessage, err := message.NewDecryptedMessage(header, nil, []byte("HIRMG:2:2:1+0010::Nachricht entgegengenommen'"))
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			err = d.verifySignature(unsignedMessage)
+
+			if test.expectError && err == nil {
+				t.Logf("Expected error, got nil\n")
+				t.Fail()
+			}
+			if !test.expectError && err != nil {
+				t.Logf("Expected no error, got %T:%v\n", err, err)
+				t.Fail()
+			}
+			if unsignedMessage.SignatureStatus() != test.expectedStatus {
+				t.Logf("Expected signature status %s, got %s\n", test.expectedStatus, unsignedMessage.SignatureStatus())
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestRDHDialogUnencryptedResponse(t *testing.T) {
+	bankSigningKey, err := domain.GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	bankEncryptionKey, err := domain.GenerateEncryptionKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	tests := []struct {
+		description       string
+		requireSignatures bool
+		anonymous         bool
+		expectError       bool
+	}{
+		{"authenticated message, signatures required", true, false, true},
+		{"authenticated message, signatures not required", false, false, false},
+		{"anonymous message, signatures required", true, true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			transport := &mockHTTPSTransport{}
+			transport.SetResponseMessages([][]byte{
+				unencryptedTestMessage("abcde", "HIRMG:2:2:1+0010::Nachricht entgegengenommen'"),
+				unencryptedTestMessage("abcde", "HIRMG:2:2:1+0100::Dialog beendet'"),
+			})
+			d, err := NewRDHDialog(RDHConfig{
+				BankID:            domain.BankID{CountryCode: 280, ID: "10000000"},
+				HBCIURL:           "localhost",
+				UserID:            "12345",
+				HBCIVersion:       segment.FINTS300,
+				Profile:           &message.RDH10,
+				BankSigningKey:    domain.NewRSAKey(bankSigningKey, domain.NewInitialKeyName(280, "10000000", "10000000", "S").NextVersion()),
+				BankEncryptionKey: domain.NewRSAKey(bankEncryptionKey, domain.NewInitialKeyName(280, "10000000", "10000000", "V").NextVersion()),
+				RequireSignatures: test.requireSignatures,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+			d.transport = transport
+			d.ClientSystemID = "abcde"
+
+			if test.anonymous {
+				err = d.FetchBankParameterData()
+			} else {
+				err = d.init()
+			}
+
+			if test.expectError && err == nil {
+				t.Logf("Expected error, got nil\n")
+				t.Fail()
+			}
+			if !test.expectError && err != nil {
+				t.Logf("Expected no error, got %T:%v\n", err, err)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestRDHDialogNewKeyRenewalSegments(t *testing.T) {
+	d, err := NewRDHDialog(RDHConfig{
+		BankID:      domain.BankID{CountryCode: 280, ID: "10000000"},
+		HBCIURL:     "localhost",
+		UserID:      "12345",
+		HBCIVersion: segment.FINTS300,
+		Profile:     &message.RAH10,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	signingKeyRenewal, encryptionKeyRenewal, err := d.newKeyRenewalSegments(d.signingKey, d.encryptionKey)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if mode := signingKeyRenewal.PublicKey.OperationMode.Val(); mode != element.SignatureModePSS {
+		t.Logf("Expected signing key operation mode %q, got %q\n", element.SignatureModePSS, mode)
+		t.Fail()
+	}
+	if mode := encryptionKeyRenewal.PublicKey.OperationMode.Val(); mode != element.EncryptionModePKCS1 {
+		t.Logf("Expected encryption key operation mode %q, got %q\n", element.EncryptionModePKCS1, mode)
+		t.Fail()
+	}
+}
+
+func TestDialogCompressionFunction(t *testing.T) {
+	rdhDialog, err := NewRDHDialog(RDHConfig{
+		BankID:      domain.BankID{CountryCode: 280, ID: "10000000"},
+		HBCIURL:     "localhost",
+		UserID:      "12345",
+		HBCIVersion: segment.FINTS300,
+		Profile:     &message.RDH10,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	pinTanDialog := newTestPinTanDialog(nil)
+	tests := []struct {
+		description string
+		dialog      *dialog
+		expected    string
+	}{
+		{"RDH", rdhDialog.dialog, message.CompressionZLIB},
+		{"PIN/TAN", pinTanDialog.dialog, message.CompressionNone},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			test.dialog.BankParameterData.CompressionFunctions = []string{"5", "6"}
+
+			fn := test.dialog.compressionFunction()
+
+			if fn != test.expected {
+				t.Logf("Expected compression function %q, got %q\n", test.expected, fn)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func unencryptedTestMessage(dialogID string, segments ...string) []byte {
+	body := strings.Join(segments, "")
+	messageEnd := fmt.Sprintf("HNHBS:%d:1+1'", len(segments)+2)
+	messageHeader := fmt.Sprintf("HNHBK:1:3+%012d+300+%s+1+'", 31+len(dialogID)+len(body)+len(messageEnd), dialogID)
+	return []byte(messageHeader + body + messageEnd)
+}
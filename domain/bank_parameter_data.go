@@ -3,14 +3,68 @@ package domain
 // BankParameterData represent metadata prvided by a bank institute that
 // reflect limitations and limits when talking to that institute
 type BankParameterData struct {
-	Version                    int
-	BankID                     BankID
-	BankName                   string
-	MaxTransactionsPerMessage  int
-	MaxMessageSize             int
-	MinTimeout                 int
-	MaxTimeout                 int
+	Version                   int
+	BankID                    BankID
+	BankName                  string
+	MaxTransactionsPerMessage int
+	MaxMessageSize            int
+	MinTimeout                int
+	MaxTimeout                int
+	// SupportedLanguages contains the languages offered by the institute
+	SupportedLanguages []Language
+	// SupportedHBCIVersions contains the HBCI versions offered by the
+	// institute, e.g. 300 for FinTS 3.0
+	SupportedHBCIVersions []int
+	// StandardLanguage is the default language of the institute as provided
+	// along with the CommunicationParameters
+	StandardLanguage Language
+	// CommunicationParameters contains the access points of the institute
+	CommunicationParameters []CommunicationParameter
+	// SecurityMethods contains the security methods offered by the
+	// institute. If SecurityMethodsMixAllowed is true, the signatures of a
+	// message may use different security methods.
+	SecurityMethods            []SecurityMethod
+	SecurityMethodsMixAllowed  bool
 	PinTanBusinessTransactions map[string]bool
+	// CompressionFunctions contains the codes of the compression functions
+	// supported by the institute
+	CompressionFunctions []string
+	// BusinessTransactions contains the parameters of all jobs supported by
+	// the institute, one entry per job and version
+	BusinessTransactions []BusinessTransactionParameters
+}
+
+// BusinessTransaction returns the parameters of the highest version of the
+// job with the given ID, e.g. "HKKAZ". It returns false if the institute does
+// not support the job.
+func (b BankParameterData) BusinessTransaction(id string) (BusinessTransactionParameters, bool) {
+	var params BusinessTransactionParameters
+	found := false
+	for _, transaction := range b.BusinessTransactions {
+		if transaction.ID == id && (!found || transaction.Version > params.Version) {
+			params = transaction
+			found = true
+		}
+	}
+	return params, found
+}
+
+// BusinessTransactionVersions returns all versions of the job with the given
+// ID supported by the institute
+func (b BankParameterData) BusinessTransactionVersions(id string) []int {
+	var versions []int
+	for _, transaction := range b.BusinessTransactions {
+		if transaction.ID == id {
+			versions = append(versions, transaction.Version)
+		}
+	}
+	return versions
+}
+
+// TANRequired returns true if the job with the given ID has to be
+// authorized with a TAN within the PIN/TAN procedure
+func (b BankParameterData) TANRequired(id string) bool {
+	return b.PinTanBusinessTransactions[id]
 }
 
 // PinTanBusinessTransaction provides information about whether a given Segment
@@ -19,3 +73,80 @@ type PinTanBusinessTransaction struct {
 	SegmentID string
 	NeedsTan  bool
 }
+
+// SecurityMethod represents a security method offered by an institute, e.g.
+// "PIN" or "RDH", along with its supported versions
+type SecurityMethod struct {
+	Code     string
+	Versions []int
+}
+
+// BusinessTransactionParameters contains the parameters of a job as provided
+// by the institute within the job specific parameter segment, e.g. HIKAZS for
+// HKKAZ.
+type BusinessTransactionParameters struct {
+	// ID is the segment ID of the job, e.g. "HKKAZ"
+	ID string
+	// Version is the segment version of the job
+	Version int
+	// MaxJobs is the maximum number of jobs of this kind per message
+	MaxJobs int
+	// MinSignatures is the minimum number of signatures needed for the job
+	MinSignatures int
+	// SecurityClass is the security class of the job. It is zero if the
+	// institute does not provide one.
+	SecurityClass int
+	// AccountTransactions contains the job specific parameters of account
+	// transaction jobs like HKKAZ or HKCAZ. It is nil for other jobs and for
+	// segment versions with unknown layout.
+	AccountTransactions *AccountTransactionParameters
+	// SepaAccountInformation contains the job specific parameters of HKSPA.
+	// It is nil for other jobs and for segment versions with unknown layout.
+	SepaAccountInformation *SepaAccountInformationParameters
+}
+
+// SepaFormats returns the SEPA formats supported for the job, e.g.
+// "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03" as provided by HISPAS or
+// the camt formats provided by HICAZS
+func (b BusinessTransactionParameters) SepaFormats() []string {
+	if b.SepaAccountInformation != nil {
+		return b.SepaAccountInformation.SupportedFormats
+	}
+	if b.AccountTransactions != nil {
+		return b.AccountTransactions.SupportedFormats
+	}
+	return nil
+}
+
+// AccountTransactionParameters contains the job specific parameters of
+// account transaction jobs
+type AccountTransactionParameters struct {
+	// StorageDuration is the number of days transactions are kept by the
+	// institute
+	StorageDuration int
+	// EntryCountAllowed is true if the number of entries per response may be
+	// limited by the client
+	EntryCountAllowed bool
+	// AllAccountsAllowed is true if the transactions of all accounts may be
+	// requested at once
+	AllAccountsAllowed bool
+	// SupportedFormats contains the supported camt formats of HKCAZ
+	SupportedFormats []string
+}
+
+// SepaAccountInformationParameters contains the job specific parameters of
+// HKSPA
+type SepaAccountInformationParameters struct {
+	// SingleAccountQueryAllowed is true if the SEPA account connection of a
+	// single account may be requested
+	SingleAccountQueryAllowed bool
+	// NationalAccountAllowed is true if national account connections are
+	// allowed within SEPA jobs
+	NationalAccountAllowed bool
+	// StructuredPurposeAllowed is true if structured purposes are allowed
+	// within SEPA jobs
+	StructuredPurposeAllowed bool
+	// SupportedFormats contains the supported SEPA formats, e.g.
+	// "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+	SupportedFormats []string
+}
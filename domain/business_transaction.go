@@ -2,10 +2,21 @@ package domain
 
 // Amount represents a value associated with a currency
 type Amount struct {
-	Amount   float64
+	Amount   Decimal
 	Currency string
 }
 
+// Rounded returns the amount rounded to the minor units of its currency
+func (a Amount) Rounded() Amount {
+	return Amount{a.Amount.Round(MinorUnits(a.Currency)), a.Currency}
+}
+
+// String returns the amount formatted with the minor units of its currency,
+// followed by the currency, e.g. "1000.15 EUR"
+func (a Amount) String() string {
+	return a.Amount.StringFixed(MinorUnits(a.Currency)) + " " + a.Currency
+}
+
 // BusinessTransaction provides information about a transaction and whether
 // there is a signature needed or not
 type BusinessTransaction struct {
@@ -0,0 +1,46 @@
+package domain
+
+// defaultMinorUnits is the number of minor units used for currencies not
+// contained in currencyMinorUnits
+const defaultMinorUnits = 2
+
+// currencyMinorUnits contains all ISO 4217 currencies with a number of minor
+// units other than 2
+var currencyMinorUnits = map[string]int{
+	"BHD": 3,
+	"BIF": 0,
+	"CLF": 4,
+	"CLP": 0,
+	"DJF": 0,
+	"GNF": 0,
+	"IQD": 3,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KMF": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"LYD": 3,
+	"OMR": 3,
+	"PYG": 0,
+	"RWF": 0,
+	"TND": 3,
+	"UGX": 0,
+	"UYI": 0,
+	"UYW": 4,
+	"VND": 0,
+	"VUV": 0,
+	"XAF": 0,
+	"XOF": 0,
+	"XPF": 0,
+}
+
+// MinorUnits returns the number of minor units, i.e. fractional digits, of
+// the ISO 4217 currency code currency. Unknown currencies default to 2 minor
+// units.
+func MinorUnits(currency string) int {
+	if units, ok := currencyMinorUnits[currency]; ok {
+		return units
+	}
+	return defaultMinorUnits
+}
@@ -0,0 +1,275 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalScale is the maximum number of fractional digits a Decimal can
+// hold without losing precision
+const maxDecimalScale = 18
+
+// Decimal represents an exact decimal number. It is defined as an unscaled
+// integer value and a scale, i.e. the value is unscaled * 10^-scale.
+//
+// Decimals are always kept in their shortest form, i.e. trailing fractional
+// zeros are removed. Thus equal values have equal representations and can be
+// compared with == or reflect.DeepEqual. The zero value represents 0.
+type Decimal struct {
+	unscaled int64
+	scale    int
+}
+
+// NewDecimal returns a Decimal representing unscaled * 10^-scale
+func NewDecimal(unscaled int64, scale uint) Decimal {
+	return Decimal{unscaled, int(scale)}.normalize()
+}
+
+// NewDecimalFromInt returns a Decimal representing value
+func NewDecimalFromInt(value int64) Decimal {
+	return Decimal{unscaled: value}
+}
+
+// ParseDecimal parses value into a Decimal. The fractional digits can be
+// separated by either a point or a comma and may be omitted after the
+// separator, e.g. "1000,15", "-20.5" and "20," are all valid values.
+func ParseDecimal(value string) (Decimal, error) {
+	str := strings.TrimSpace(value)
+	negative := false
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		negative = str[0] == '-'
+		str = str[1:]
+	}
+	integer, fraction := str, ""
+	if idx := strings.IndexAny(str, ".,"); idx != -1 {
+		integer, fraction = str[:idx], str[idx+1:]
+	}
+	if integer == "" && fraction == "" {
+		return Decimal{}, fmt.Errorf("Malformed decimal: %q", value)
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("Malformed decimal: %q: too many fractional digits", value)
+	}
+	var unscaled int64
+	for _, r := range integer + fraction {
+		if r < '0' || r > '9' {
+			return Decimal{}, fmt.Errorf("Malformed decimal: %q", value)
+		}
+		digit := int64(r - '0')
+		if unscaled > (math.MaxInt64-digit)/10 {
+			return Decimal{}, fmt.Errorf("Malformed decimal: %q: value out of range", value)
+		}
+		unscaled = unscaled*10 + digit
+	}
+	if negative {
+		unscaled = -unscaled
+	}
+	return NewDecimal(unscaled, uint(len(fraction))), nil
+}
+
+// MustParseDecimal parses value like ParseDecimal but panics if value is not
+// a valid decimal
+func MustParseDecimal(value string) Decimal {
+	d, err := ParseDecimal(value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) normalize() Decimal {
+	if d.unscaled == 0 {
+		return Decimal{}
+	}
+	for d.scale > 0 && d.unscaled%10 == 0 {
+		d.unscaled /= 10
+		d.scale--
+	}
+	return d
+}
+
+// rescale returns the unscaled value of d for the given scale, which must not
+// be smaller than the scale of d. It returns false if the value overflows.
+func (d Decimal) rescale(scale int) (int64, bool) {
+	unscaled := d.unscaled
+	for i := d.scale; i < scale; i++ {
+		if unscaled > math.MaxInt64/10 || unscaled < math.MinInt64/10 {
+			return 0, false
+		}
+		unscaled *= 10
+	}
+	return unscaled, true
+}
+
+// bigRescale returns the unscaled value of d for the given scale, which must
+// not be smaller than the scale of d
+func (d Decimal) bigRescale(scale int) *big.Int {
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-d.scale)), nil)
+	return factor.Mul(factor, big.NewInt(d.unscaled))
+}
+
+// Unscaled returns the unscaled value of d
+func (d Decimal) Unscaled() int64 { return d.unscaled }
+
+// Scale returns the number of fractional digits of d
+func (d Decimal) Scale() int { return d.scale }
+
+// Add returns d + other. It returns an error if the sum exceeds the range of
+// a Decimal.
+func (d Decimal) Add(other Decimal) (Decimal, error) {
+	scale := maxInt(d.scale, other.scale)
+	a, ok := d.rescale(scale)
+	b, ok2 := other.rescale(scale)
+	sum := a + b
+	if !ok || !ok2 || (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return Decimal{}, fmt.Errorf("Decimal overflow: %s + %s", d, other)
+	}
+	return NewDecimal(sum, uint(scale)), nil
+}
+
+// Sub returns d - other. It returns an error if the difference exceeds the
+// range of a Decimal.
+func (d Decimal) Sub(other Decimal) (Decimal, error) {
+	if other.unscaled == math.MinInt64 {
+		return Decimal{}, fmt.Errorf("Decimal overflow: %s - %s", d, other)
+	}
+	return d.Add(other.Neg())
+}
+
+// Neg returns -d. The negation of the smallest representable unscaled value
+// overflows like for int64.
+func (d Decimal) Neg() Decimal {
+	return Decimal{-d.unscaled, d.scale}
+}
+
+// Abs returns the absolute value of d
+func (d Decimal) Abs() Decimal {
+	if d.unscaled < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Sign returns -1 if d is negative, 0 if d is zero and 1 if d is positive
+func (d Decimal) Sign() int {
+	switch {
+	case d.unscaled < 0:
+		return -1
+	case d.unscaled > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsZero returns true if d equals zero
+func (d Decimal) IsZero() bool {
+	return d.unscaled == 0
+}
+
+// Cmp compares d and other and returns -1 if d < other, 0 if d == other and
+// 1 if d > other
+func (d Decimal) Cmp(other Decimal) int {
+	scale := maxInt(d.scale, other.scale)
+	return d.bigRescale(scale).Cmp(other.bigRescale(scale))
+}
+
+// Equal returns true if d and other represent the same value
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// Round rounds d half away from zero to the given number of fractional
+// digits
+func (d Decimal) Round(places int) Decimal {
+	if places < 0 || d.scale <= places {
+		return d
+	}
+	unscaled := d.unscaled
+	var remainder int64
+	for i := d.scale; i > places; i-- {
+		remainder = unscaled % 10
+		unscaled /= 10
+	}
+	if remainder >= 5 {
+		unscaled++
+	} else if remainder <= -5 {
+		unscaled--
+	}
+	return NewDecimal(unscaled, uint(places))
+}
+
+// Float64 returns the nearest float64 value of d
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String returns the shortest representation of d with a point as decimal
+// separator
+func (d Decimal) String() string {
+	return d.format(d.scale, '.')
+}
+
+// StringFixed returns the representation of d rounded to places fractional
+// digits with a point as decimal separator
+func (d Decimal) StringFixed(places int) string {
+	return d.Round(places).format(places, '.')
+}
+
+// Text returns the shortest representation of d with separator as decimal
+// separator. The separator is always present, even for integral values, e.g.
+// "20," for 20 with a comma separator.
+func (d Decimal) Text(separator byte) string {
+	str := d.format(d.scale, separator)
+	if d.scale == 0 {
+		str += string(separator)
+	}
+	return str
+}
+
+func (d Decimal) format(places int, separator byte) string {
+	digits := d.bigRescale(places).String()
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if places == 0 {
+		return sign + digits
+	}
+	if len(digits) <= places {
+		digits = strings.Repeat("0", places-len(digits)+1) + digits
+	}
+	idx := len(digits) - places
+	return sign + digits[:idx] + string(separator) + digits[idx:]
+}
+
+// MarshalJSON marshals d as JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON unmarshals a JSON number or string into d. Like for other
+// types, null leaves d unchanged.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := ParseDecimal(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*d = value
+	return nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
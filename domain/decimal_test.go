@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected Decimal
+		err      bool
+	}{
+		{"1000,15", NewDecimal(100015, 2), false},
+		{"1000.15", NewDecimal(100015, 2), false},
+		{"-20.50", NewDecimal(-205, 1), false},
+		{"20,", NewDecimalFromInt(20), false},
+		{",5", NewDecimal(5, 1), false},
+		{"0,00", Decimal{}, false},
+		{"", Decimal{}, true},
+		{",", Decimal{}, true},
+		{"1,2,3", Decimal{}, true},
+		{"1e3", Decimal{}, true},
+	}
+	for _, test := range tests {
+		actual, err := ParseDecimal(test.in)
+
+		if test.err && err == nil {
+			t.Logf("Input: %q: Expected error, got nil\n", test.in)
+			t.Fail()
+		}
+		if !test.err && err != nil {
+			t.Logf("Input: %q: Expected no error, got %T:%v\n", test.in, err, err)
+			t.Fail()
+		}
+		if !reflect.DeepEqual(test.expected, actual) {
+			t.Logf("Input: %q: Expected decimal to equal %s, got %s\n", test.in, test.expected, actual)
+			t.Fail()
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	sum, err := MustParseDecimal("0.1").Add(MustParseDecimal("0.2"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if !reflect.DeepEqual(MustParseDecimal("0.3"), sum) {
+		t.Logf("Expected 0.1 + 0.2 to equal 0.3, got %s\n", sum)
+		t.Fail()
+	}
+
+	difference, err := MustParseDecimal("1000.15").Sub(MustParseDecimal("1499.85"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if difference.String() != "-499.7" {
+		t.Logf("Expected difference to equal -499.7, got %s\n", difference)
+		t.Fail()
+	}
+	if difference.Sign() != -1 || difference.Abs().Sign() != 1 {
+		t.Logf("Expected sign of %s to be negative and of its absolute value positive\n", difference)
+		t.Fail()
+	}
+	if MustParseDecimal("2.5").Cmp(MustParseDecimal("2.50")) != 0 {
+		t.Logf("Expected 2.5 to equal 2.50\n")
+		t.Fail()
+	}
+}
+
+func TestDecimalOverflow(t *testing.T) {
+	max := NewDecimalFromInt(math.MaxInt64)
+	min := NewDecimalFromInt(math.MinInt64)
+	tests := []struct {
+		description string
+		operation   func() (Decimal, error)
+	}{
+		{"sum too large", func() (Decimal, error) { return max.Add(NewDecimalFromInt(1)) }},
+		{"sum too small", func() (Decimal, error) { return min.Add(NewDecimalFromInt(-1)) }},
+		{"difference too small", func() (Decimal, error) { return min.Sub(NewDecimalFromInt(1)) }},
+		{"subtrahend not negatable", func() (Decimal, error) { return NewDecimalFromInt(-1).Sub(min) }},
+		{"rescale too large", func() (Decimal, error) { return max.Add(NewDecimal(1, 2)) }},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, err := test.operation()
+
+			if err == nil {
+				t.Logf("Expected error, got nil\n")
+				t.Fail()
+			}
+		})
+	}
+
+	if max.Cmp(NewDecimal(1, 2)) != 1 {
+		t.Logf("Expected %s to be greater than 0.01\n", max)
+		t.Fail()
+	}
+	if actual := max.StringFixed(2); actual != "9223372036854775807.00" {
+		t.Logf("Expected StringFixed(2) of %s to equal %q, got %q\n", max, "9223372036854775807.00", actual)
+		t.Fail()
+	}
+}
+
+func TestDecimalFormat(t *testing.T) {
+	tests := []struct {
+		value     Decimal
+		places    int
+		fixed     string
+		withComma string
+	}{
+		{NewDecimal(100015, 2), 2, "1000.15", "1000,15"},
+		{NewDecimalFromInt(20), 2, "20.00", "20,"},
+		{NewDecimal(-5, 2), 2, "-0.05", "-0,05"},
+		{NewDecimal(12345, 3), 2, "12.35", "12,345"},
+		{NewDecimal(-12345, 3), 0, "-12", "-12,345"},
+		{NewDecimal(5, 3), 3, "0.005", "0,005"},
+	}
+	for _, test := range tests {
+		if actual := test.value.StringFixed(test.places); actual != test.fixed {
+			t.Logf("Expected StringFixed(%d) of %s to equal %q, got %q\n", test.places, test.value, test.fixed, actual)
+			t.Fail()
+		}
+		if actual := test.value.Text(','); actual != test.withComma {
+			t.Logf("Expected Text(',') of %s to equal %q, got %q\n", test.value, test.withComma, actual)
+			t.Fail()
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	amount := Amount{Amount: NewDecimal(-100015, 2), Currency: "EUR"}
+
+	marshaled, err := json.Marshal(amount)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	expected := `{"Amount":-1000.15,"Currency":"EUR"}`
+	if string(marshaled) != expected {
+		t.Logf("Expected JSON to equal %s, got %s\n", expected, marshaled)
+		t.Fail()
+	}
+
+	var unmarshaled Amount
+	err = json.Unmarshal(marshaled, &unmarshaled)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if !reflect.DeepEqual(amount, unmarshaled) {
+		t.Logf("Expected amount to equal %#v, got %#v\n", amount, unmarshaled)
+		t.Fail()
+	}
+}
+
+func TestDecimalUnmarshalJSONNull(t *testing.T) {
+	var balance struct {
+		Amount    Decimal
+		Available *Decimal
+	}
+	balance.Amount = NewDecimal(15, 1)
+
+	err := json.Unmarshal([]byte(`{"Amount":null,"Available":null}`), &balance)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if !reflect.DeepEqual(NewDecimal(15, 1), balance.Amount) {
+		t.Logf("Expected amount to stay 1.5, got %s\n", balance.Amount)
+		t.Fail()
+	}
+	if balance.Available != nil {
+		t.Logf("Expected available amount to be nil, got %s\n", balance.Available)
+		t.Fail()
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	tests := []struct {
+		amount   Amount
+		expected string
+	}{
+		{Amount{NewDecimal(1005, 1), "EUR"}, "100.50 EUR"},
+		{Amount{NewDecimalFromInt(1500), "JPY"}, "1500 JPY"},
+		{Amount{NewDecimal(12345, 4), "KWD"}, "1.235 KWD"},
+	}
+	for _, test := range tests {
+		if actual := test.amount.String(); actual != test.expected {
+			t.Logf("Expected amount to equal %q, got %q\n", test.expected, actual)
+			t.Fail()
+		}
+	}
+}
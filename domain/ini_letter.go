@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// hexBytesPerLine is the number of bytes per line when printing key data
+const hexBytesPerLine = 16
+
+// INILetter contains the data of the INI letter a RDH user has to send to
+// the bank institute after submitting the public keys. The institute
+// unlocks the keys once the hashes on the letter match the submitted keys.
+type INILetter struct {
+	BankID BankID
+	UserID string
+	// SecurityProfile is the name of the security profile, e.g. "RDH-10"
+	SecurityProfile string
+	Date            time.Time
+	Keys            []INILetterKey
+}
+
+// INILetterKey contains the printed data of a single public key
+type INILetterKey struct {
+	KeyName  KeyName
+	Exponent []byte
+	Modulus  []byte
+	// Hash is the hash of the key as defined by the security profile
+	Hash []byte
+}
+
+// ExponentLines returns the exponent as lines of hex encoded bytes
+func (i INILetterKey) ExponentLines() []string {
+	return HexLines(i.Exponent)
+}
+
+// ModulusLines returns the modulus as lines of hex encoded bytes
+func (i INILetterKey) ModulusLines() []string {
+	return HexLines(i.Modulus)
+}
+
+// HashLines returns the hash as lines of hex encoded bytes
+func (i INILetterKey) HashLines() []string {
+	return HexLines(i.Hash)
+}
+
+// String returns the INI letter as plain text, ready to print
+func (i INILetter) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "INI letter\n\n")
+	fmt.Fprintf(&buf, "Date:             %s\n", i.Date.Format("02.01.2006 15:04:05"))
+	fmt.Fprintf(&buf, "Bank ID:          %s\n", i.BankID.ID)
+	fmt.Fprintf(&buf, "User ID:          %s\n", i.UserID)
+	fmt.Fprintf(&buf, "Security profile: %s\n", i.SecurityProfile)
+	for _, key := range i.Keys {
+		keyUsage := "signing key"
+		if key.KeyName.KeyType == "V" {
+			keyUsage = "encryption key"
+		}
+		fmt.Fprintf(&buf, "\nPublic %s (number %d, version %d)\n", keyUsage, key.KeyName.KeyNumber, key.KeyName.KeyVersion)
+		fmt.Fprintf(&buf, "\nExponent:\n%s\n", strings.Join(key.ExponentLines(), "\n"))
+		fmt.Fprintf(&buf, "\nModulus:\n%s\n", strings.Join(key.ModulusLines(), "\n"))
+		fmt.Fprintf(&buf, "\nHash:\n%s\n", strings.Join(key.HashLines(), "\n"))
+	}
+	fmt.Fprintf(&buf, "\nI confirm that the keys above were generated by me.\n\n\n")
+	fmt.Fprintf(&buf, "______________________________    ______________________________\n")
+	fmt.Fprintf(&buf, "Place, date                       Signature\n")
+	return buf.String()
+}
+
+// HexLines returns data as upper case hex encoded bytes separated by spaces,
+// 16 bytes per line
+func HexLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		n := hexBytesPerLine
+		if len(data) < n {
+			n = len(data)
+		}
+		lines = append(lines, fmt.Sprintf("% X", data[:n]))
+		data = data[n:]
+	}
+	return lines
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHexLines(t *testing.T) {
+	data := make([]byte, 20)
+	for i := range data {
+		data[i] = byte(i + 0xF0)
+	}
+
+	actual := HexLines(data)
+
+	expected := []string{
+		"F0 F1 F2 F3 F4 F5 F6 F7 F8 F9 FA FB FC FD FE FF",
+		"00 01 02 03",
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Logf("Expected lines to equal\n%q\n\tgot\n%q\n", expected, actual)
+		t.Fail()
+	}
+}
+
+func TestINILetterString(t *testing.T) {
+	letter := INILetter{
+		BankID:          BankID{CountryCode: 280, ID: "10000000"},
+		UserID:          "12345",
+		SecurityProfile: "RDH-10",
+		Date:            time.Date(2016, 3, 1, 12, 30, 0, 0, time.UTC),
+		Keys: []INILetterKey{
+			{
+				KeyName:  KeyName{KeyType: "V", KeyNumber: 1, KeyVersion: 2},
+				Exponent: []byte{0x01, 0x00, 0x01},
+				Modulus:  []byte{0xAB, 0xCD},
+				Hash:     []byte{0x12, 0x34},
+			},
+		},
+	}
+
+	actual := letter.String()
+
+	for _, expected := range []string{
+		"Date:             01.03.2016 12:30:00\n",
+		"Bank ID:          10000000\n",
+		"User ID:          12345\n",
+		"Security profile: RDH-10\n",
+		"Public encryption key (number 1, version 2)\n",
+		"Exponent:\n01 00 01\n",
+		"Modulus:\nAB CD\n",
+		"Hash:\n12 34\n",
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Logf("Expected letter to contain\n%q\n\tgot\n%q\n", expected, actual)
+			t.Fail()
+		}
+	}
+}
@@ -3,7 +3,10 @@ package domain
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"fmt"
 	"math/big"
+
+	hbcicrypto "github.com/mitch000001/go-hbci/crypto"
 )
 
 // Key provides an interface to an encryption/signing key
@@ -68,15 +71,60 @@ func (k *KeyName) SetInitial() {
 	k.KeyVersion = 999
 }
 
+// maxKeyVersion is the highest version of a key, as 999 is reserved for
+// initial KeyNames
+const maxKeyVersion = 998
+
+// NextVersion returns the KeyName of the next version of the key, as used
+// when changing keys. The version starts again at 1 after 998. For an initial
+// KeyName the first version of the first key is returned.
+func (k *KeyName) NextVersion() *KeyName {
+	next := *k
+	if k.IsInitial() {
+		next.KeyNumber = 1
+		next.KeyVersion = 1
+		return &next
+	}
+	next.KeyVersion = k.KeyVersion%maxKeyVersion + 1
+	return &next
+}
+
+// A PinProvider provides the PIN of a user on demand, e.g. by asking the
+// user
+type PinProvider interface {
+	// Pin returns the PIN of the user. It is called whenever a message has
+	// to be signed.
+	Pin() (string, error)
+	// PinRejected is called when the institute rejected the PIN. The
+	// provider should forget the PIN, so the user is asked again for the
+	// next message.
+	PinRejected()
+}
+
+// A PinChangeListener is a PinProvider which is told when the PIN of the
+// user was changed, e.g. to update a cached PIN
+type PinChangeListener interface {
+	// PinChanged is called after the institute accepted newPin as the new
+	// PIN of the user
+	PinChanged(newPin string)
+}
+
 // NewPinKey returns a new PinKey
 func NewPinKey(pin string, keyName *KeyName) *PinKey {
 	return &PinKey{pin: pin, keyName: keyName}
 }
 
+// NewPinProviderKey returns a PinKey which asks provider for the PIN
+// whenever a message is signed, instead of keeping the PIN
+func NewPinProviderKey(provider PinProvider, keyName *KeyName) *PinKey {
+	return &PinKey{provider: provider, keyName: keyName}
+}
+
 // PinKey represents a Key used for pin/tan flow
 type PinKey struct {
-	pin     string
-	keyName *KeyName
+	pin      string
+	provider PinProvider
+	keyName  *KeyName
 }
 
 // KeyName returns the KeyName
@@ -104,14 +152,31 @@ func (p *PinKey) CanEncrypt() bool {
 	return true
 }
 
-// Pin returns the pin within this key
+// Pin returns the pin within this key. It is empty for keys using a
+// PinProvider.
 func (p *PinKey) Pin() string {
 	return p.pin
 }
 
-// Sign signs message
+// HasPin returns true if the key contains a PIN or can ask a PinProvider
+// for it
+func (p *PinKey) HasPin() bool {
+	return p.pin != "" || p.provider != nil
+}
+
+// Sign signs message. For PIN/TAN, the signature is the PIN itself.
 func (p *PinKey) Sign(message []byte) ([]byte, error) {
-	return []byte(p.pin), nil
+	if p.provider == nil {
+		return []byte(p.pin), nil
+	}
+	pin, err := p.provider.Pin()
+	if err != nil {
+		return nil, fmt.Errorf("Error while getting PIN: %v", err)
+	}
+	if pin == "" {
+		return nil, fmt.Errorf("Empty PIN")
+	}
+	return []byte(pin), nil
 }
 
 // Encrypt encryptes the message
@@ -130,19 +195,42 @@ func (p *PinKey) Decrypt(encryptedMessage []byte) ([]byte, error) {
 	return decMessage, nil
 }
 
+// RDHKeySize defines the modulus length in bits for RDH-2 keys
+const RDHKeySize = 2048
+
 // GenerateSigningKey generates a new signing key
 func GenerateSigningKey() (*PublicKey, error) {
-	rsaKey, err := rsa.GenerateKey(rand.Reader, 768)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, RDHKeySize)
 	if err != nil {
 		return nil, err
 	}
-	p := PublicKey{
-		Type:          "S",
+	return NewPrivateKey("S", rsaKey), nil
+}
+
+// GenerateEncryptionKey generates a new encryption key. The key can be used
+// to decrypt messages encrypted with its public part.
+func GenerateEncryptionKey() (*PublicKey, error) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, RDHKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKey("V", rsaKey), nil
+}
+
+// NewPrivateKey creates a key of type keyType from the private RSA key, e.g.
+// when loading stored keys. Keys of type "V" can also be used to encrypt
+// messages with the public part of the key.
+func NewPrivateKey(keyType string, rsaKey *rsa.PrivateKey) *PublicKey {
+	p := &PublicKey{
+		Type:          keyType,
 		Modulus:       rsaKey.N.Bytes(),
 		Exponent:      big.NewInt(int64(rsaKey.E)).Bytes(),
 		rsaPrivateKey: rsaKey,
 	}
-	return &p, nil
+	if keyType == "V" {
+		p.rsaPublicKey = &rsaKey.PublicKey
+	}
+	return p
 }
 
 // NewRSAKey returns a new RSA key
@@ -183,11 +271,17 @@ func (r *RSAKey) CanEncrypt() bool {
 
 // NewEncryptionKey creates a new RSA encryption key
 func NewEncryptionKey(modulus, exponent []byte) *PublicKey {
+	return NewRSAPublicKey("V", modulus, exponent)
+}
+
+// NewRSAPublicKey creates a new public RSA key of type keyType, i.e. "S" for
+// signing keys and "V" for encryption keys
+func NewRSAPublicKey(keyType string, modulus, exponent []byte) *PublicKey {
 	p := &PublicKey{
-		Type: "V",
+		Type:     keyType,
+		Modulus:  append([]byte(nil), modulus...),
+		Exponent: append([]byte(nil), exponent...),
 	}
-	copy(p.Modulus, modulus)
-	copy(p.Exponent, exponent)
 	mod := new(big.Int).SetBytes(modulus)
 	exp := new(big.Int).SetBytes(exponent)
 	pubKey := rsa.PublicKey{
@@ -212,12 +306,33 @@ func (p *PublicKey) SigningKey() *rsa.PrivateKey {
 	return p.rsaPrivateKey
 }
 
-// Sign signs message with the private key
+// EncryptionKey returns the RSA public key to encrypt with, or nil when not
+// set
+func (p *PublicKey) EncryptionKey() *rsa.PublicKey {
+	return p.rsaPublicKey
+}
+
+// DecryptionKey returns the RSA private key to decrypt with, or nil when not
+// set
+func (p *PublicKey) DecryptionKey() *rsa.PrivateKey {
+	return p.rsaPrivateKey
+}
+
+// Sign signs message with the private key. message is expected to be the
+// hash sum of the data to sign, as the ISO 9796-1 padding used for RDH
+// only allows inputs of half the key size.
 func (p *PublicKey) Sign(message []byte) ([]byte, error) {
-	return rsa.SignPKCS1v15(rand.Reader, p.rsaPrivateKey, 0, message)
+	return hbcicrypto.SignISO9796d1(message, p.rsaPrivateKey)
 }
 
-// Encrypt encryptes the message with the public key
+// Encrypt encryptes the message with the public key. As defined for RDH, the
+// message is padded with leading zeros to the length of the modulus.
 func (p *PublicKey) Encrypt(message []byte) ([]byte, error) {
-	return rsa.EncryptPKCS1v15(rand.Reader, p.rsaPublicKey, message)
+	return hbcicrypto.EncryptZeroPadded(message, p.rsaPublicKey)
+}
+
+// Decrypt decrypts the encryptedMessage with the private key. The result
+// keeps the leading zero padding, i.e. it has the length of the modulus.
+func (p *PublicKey) Decrypt(encryptedMessage []byte) ([]byte, error) {
+	return hbcicrypto.DecryptZeroPadded(encryptedMessage, p.rsaPrivateKey)
 }
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyNameNextVersion(t *testing.T) {
+	bankID := BankID{CountryCode: 280, ID: "10000000"}
+	tests := []struct {
+		keyName  KeyName
+		expected KeyName
+	}{
+		{
+			*NewInitialKeyName(280, "10000000", "12345", "S"),
+			KeyName{BankID: bankID, UserID: "12345", KeyType: "S", KeyNumber: 1, KeyVersion: 1},
+		},
+		{
+			KeyName{BankID: bankID, UserID: "12345", KeyType: "V", KeyNumber: 1, KeyVersion: 1},
+			KeyName{BankID: bankID, UserID: "12345", KeyType: "V", KeyNumber: 1, KeyVersion: 2},
+		},
+		{
+			KeyName{BankID: bankID, UserID: "12345", KeyType: "S", KeyNumber: 2, KeyVersion: 998},
+			KeyName{BankID: bankID, UserID: "12345", KeyType: "S", KeyNumber: 2, KeyVersion: 1},
+		},
+	}
+	for _, test := range tests {
+		keyName := test.keyName
+
+		actual := keyName.NextVersion()
+
+		if !reflect.DeepEqual(test.expected, *actual) {
+			t.Logf("Expected next version of %#v to equal\n%#v\n\tgot\n%#v\n", test.keyName, test.expected, *actual)
+			t.Fail()
+		}
+		if !reflect.DeepEqual(test.keyName, keyName) {
+			t.Logf("Expected key name to be unchanged, got %#v\n", keyName)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+// TanMediumClass defines the kind of a TAN medium
+type TanMediumClass string
+
+// Possible TanMediumClasses
+const (
+	// AllTanMediumClasses is used to list the TAN media of all classes
+	AllTanMediumClasses TanMediumClass = "A"
+	TanList             TanMediumClass = "L"
+	TanGenerator        TanMediumClass = "G"
+	MobilePhone         TanMediumClass = "M"
+	Secoder             TanMediumClass = "S"
+)
+
+// TanMediumStatus defines whether a TAN medium can be used
+type TanMediumStatus int
+
+// Possible TanMediumStatus values
+const (
+	TanMediumActive                TanMediumStatus = 1
+	TanMediumAvailable             TanMediumStatus = 2
+	TanMediumActiveFollowUpCard    TanMediumStatus = 3
+	TanMediumAvailableFollowUpCard TanMediumStatus = 4
+)
+
+// IsActive returns true if the TAN medium can be used to create TANs
+func (t TanMediumStatus) IsActive() bool {
+	return t == TanMediumActive || t == TanMediumActiveFollowUpCard
+}
+
+// TanMedium represents a TAN medium registered for the user, e.g. a TAN
+// generator or a mobile phone
+type TanMedium struct {
+	Class  TanMediumClass
+	Status TanMediumStatus
+	// CardNumber and CardSequenceNumber identify the card of a TAN generator
+	CardNumber         string
+	CardSequenceNumber string
+	// Account is the account the TAN medium is issued for, if any
+	Account   InternationalAccountConnection
+	ValidFrom time.Time
+	ValidTo   time.Time
+	// ListNumber identifies a TAN list
+	ListNumber string
+	// Name is the name of the TAN medium given by the user. It is used to
+	// choose the TAN medium for a job.
+	Name string
+	// MaskedMobileNumber is the number of a mobile phone with some digits
+	// hidden
+	MaskedMobileNumber string
+	MobileNumber       string
+	// FreeTANs is the number of unused TANs of a TAN list
+	FreeTANs    int
+	LastUsed    time.Time
+	ActivatedOn time.Time
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/mitch000001/go-hbci/charset"
 	"github.com/mitch000001/go-hbci/domain"
@@ -44,8 +45,78 @@ func (s *SupportedSecurityMethodDataElement) GroupDataElements() []DataElement {
 
 // UnmarshalHBCI unmarshals the value to a SupportedSecurityMethodDataElement
 func (s *SupportedSecurityMethodDataElement) UnmarshalHBCI(value []byte) error {
-	s.DataElement = NewDataElementGroup(supportedSecurityMethodDEG, 2, s)
-	return s.DataElement.UnmarshalHBCI(value)
+	elements, err := ExtractElements(value)
+	if err != nil {
+		return err
+	}
+	if len(elements) < 2 {
+		return fmt.Errorf("Malformed marshaled value")
+	}
+	versions := make([]int, len(elements)-1)
+	for i, elem := range elements[1:] {
+		versions[i], err = strconv.Atoi(charset.ToUTF8(elem))
+		if err != nil {
+			return fmt.Errorf("Malformed security method version: %v", err)
+		}
+	}
+	*s = *NewSupportedSecurityMethod(charset.ToUTF8(elements[0]), versions...)
+	return nil
+}
+
+// Val returns the SecurityMethod represented by s
+func (s *SupportedSecurityMethodDataElement) Val() domain.SecurityMethod {
+	method := domain.SecurityMethod{Code: s.MethodCode.Val()}
+	for _, version := range s.Versions.Versions() {
+		method.Versions = append(method.Versions, version.Val())
+	}
+	return method
+}
+
+// NewSupportedSecurityMethods returns a new
+// SupportedSecurityMethodsDataElement
+func NewSupportedSecurityMethods(methods ...domain.SecurityMethod) *SupportedSecurityMethodsDataElement {
+	methodDEs := make([]DataElement, len(methods))
+	for i, method := range methods {
+		methodDEs[i] = NewSupportedSecurityMethod(method.Code, method.Versions...)
+	}
+	s := &SupportedSecurityMethodsDataElement{}
+	s.arrayElementGroup = newArrayElementGroup(supportedSecurityMethodDEG, 1, 9, methodDEs)
+	return s
+}
+
+// SupportedSecurityMethodsDataElement represents all security methods
+// supported by an institute
+type SupportedSecurityMethodsDataElement struct {
+	*arrayElementGroup
+}
+
+// UnmarshalHBCI unmarshals the value to a
+// SupportedSecurityMethodsDataElement
+func (s *SupportedSecurityMethodsDataElement) UnmarshalHBCI(value []byte) error {
+	elements, err := extractDataElements(value)
+	if err != nil {
+		return err
+	}
+	methods := make([]DataElement, len(elements))
+	for i, elem := range elements {
+		method := &SupportedSecurityMethodDataElement{}
+		err := method.UnmarshalHBCI(elem)
+		if err != nil {
+			return err
+		}
+		methods[i] = method
+	}
+	s.arrayElementGroup = newArrayElementGroup(supportedSecurityMethodDEG, 1, 9, methods)
+	return nil
+}
+
+// Val returns the SecurityMethods represented by s
+func (s *SupportedSecurityMethodsDataElement) Val() []domain.SecurityMethod {
+	methods := make([]domain.SecurityMethod, len(s.array))
+	for i, method := range s.array {
+		methods[i] = method.(*SupportedSecurityMethodDataElement).Val()
+	}
+	return methods
 }
 
 // NewSecurityMethodVersions returns a new SecurityMethodVersionsDataElement
@@ -106,6 +177,15 @@ type SupportedHBCIVersionsDataElement struct {
 	*arrayElementGroup
 }
 
+// Versions returns the supported HBCI versions
+func (s *SupportedHBCIVersionsDataElement) Versions() []int {
+	versions := make([]int, len(s.arrayElementGroup.array))
+	for i, version := range s.arrayElementGroup.array {
+		versions[i] = version.(*NumberDataElement).Val()
+	}
+	return versions
+}
+
 // UnmarshalHBCI unmarshals the value to a SupportedHBCIVersionsDataElement
 func (s *SupportedHBCIVersionsDataElement) UnmarshalHBCI(value []byte) error {
 	elements, err := ExtractElements(value)
@@ -180,12 +260,85 @@ func (s *SupportedLanguagesDataElement) UnmarshalHBCI(value []byte) error {
 	return nil
 }
 
+// compressionFunctionNames maps the names of compression functions to their
+// codes
+var compressionFunctionNames = map[string]string{
+	"NULL":  "0",
+	"LZW":   "1",
+	"COM":   "2",
+	"LZSS":  "3",
+	"LZHUF": "4",
+	"ZIP":   "5",
+	"GZIP":  "6",
+	"ZLIB":  "6",
+	"BZIP2": "7",
+	"ZZZ":   "999",
+}
+
+var compressionFunctionCodes = []string{"0", "1", "2", "3", "4", "5", "6", "7", "999"}
+
+// NewSupportedCompressionMethods returns a new
+// SupportedCompressionMethodsDataElement for the given compression function
+// codes
+func NewSupportedCompressionMethods(compressionFunctions ...string) *SupportedCompressionMethodsDataElement {
+	methods := make([]DataElement, len(compressionFunctions))
+	for i, fn := range compressionFunctions {
+		methods[i] = NewCode(fn, 3, compressionFunctionCodes)
+	}
+	s := &SupportedCompressionMethodsDataElement{}
+	s.arrayElementGroup = newArrayElementGroup(supportedCompressionMethodsDEG, 1, 9, methods)
+	return s
+}
+
 // SupportedCompressionMethodsDataElement represents the compression methods
 // supported by the bank institute
 type SupportedCompressionMethodsDataElement struct {
 	*arrayElementGroup
 }
 
+// Val returns the codes of the supported compression functions
+func (s *SupportedCompressionMethodsDataElement) Val() []string {
+	if s.arrayElementGroup == nil {
+		return nil
+	}
+	codes := make([]string, len(s.array))
+	for i, elem := range s.array {
+		codes[i] = elem.(*CodeDataElement).Val()
+	}
+	return codes
+}
+
+// UnmarshalHBCI unmarshals the value into the
+// SupportedCompressionMethodsDataElement. The compression functions are
+// accepted as codes as well as names, e.g. "6" or "ZLIB". Unknown values are
+// ignored.
+func (s *SupportedCompressionMethodsDataElement) UnmarshalHBCI(value []byte) error {
+	// The methods are separate data elements, so the value has to be
+	// terminated to be extracted as a whole
+	if !bytes.HasSuffix(value, []byte("'")) {
+		value = append(value[:len(value):len(value)], '\'')
+	}
+	elements, err := ExtractElements(value)
+	if err != nil {
+		return err
+	}
+	var methods []DataElement
+	seen := make(map[string]bool)
+	for _, elem := range elements {
+		fn := strings.ToUpper(charset.ToUTF8(elem))
+		if code, ok := compressionFunctionNames[fn]; ok {
+			fn = code
+		}
+		if _, err := strconv.Atoi(fn); err != nil || seen[fn] {
+			continue
+		}
+		seen[fn] = true
+		methods = append(methods, NewCode(fn, 3, compressionFunctionCodes))
+	}
+	s.arrayElementGroup = newArrayElementGroup(supportedCompressionMethodsDEG, 1, 9, methods)
+	return nil
+}
+
 // A BusinessTransactionParameter defines parameters for a specific business
 // transaction.
 type BusinessTransactionParameter struct {
@@ -1,6 +1,9 @@
 package element
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+)
 
 func TestPinTanBusinessTransactionParametersUnmarshalHBCI(t *testing.T) {
 	test := "HKSAL:N:HKUEB:J"
@@ -64,3 +67,32 @@ func TestPinTanBusinessTransactionParameterUnmarshalHBCI(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSupportedCompressionMethodsUnmarshalHBCI(t *testing.T) {
+	tests := []struct {
+		marshaled string
+		codes     []string
+	}{
+		{"6", []string{"6"}},
+		{"5+6", []string{"5", "6"}},
+		{"ZLIB:6+BZIP2:7", []string{"6", "7"}},
+		{"zip", []string{"5"}},
+	}
+
+	for _, test := range tests {
+		element := &SupportedCompressionMethodsDataElement{}
+
+		err := element.UnmarshalHBCI([]byte(test.marshaled))
+
+		if err != nil {
+			t.Logf("Expected no error, got %T:%v\n", err, err)
+			t.Fail()
+		}
+
+		actual := element.Val()
+		if fmt.Sprintf("%v", actual) != fmt.Sprintf("%v", test.codes) {
+			t.Logf("Expected codes of %q to equal %v, got %v\n", test.marshaled, test.codes, actual)
+			t.Fail()
+		}
+	}
+}
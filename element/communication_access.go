@@ -1,6 +1,12 @@
 package element
 
-import "github.com/mitch000001/go-hbci/domain"
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/mitch000001/go-hbci/charset"
+	"github.com/mitch000001/go-hbci/domain"
+)
 
 // NewCommunicationParameter returns a new CommunicationParameterDataElement
 func NewCommunicationParameter(params domain.CommunicationParameter) *CommunicationParameterDataElement {
@@ -55,3 +61,92 @@ func (c *CommunicationParameterDataElement) GroupDataElements() []DataElement {
 		c.FilterFunctionVersion,
 	}
 }
+
+// UnmarshalHBCI unmarshals value into the CommunicationParameterDataElement
+func (c *CommunicationParameterDataElement) UnmarshalHBCI(value []byte) error {
+	elements, err := ExtractElements(value)
+	if err != nil {
+		return err
+	}
+	if len(elements) < 2 {
+		return fmt.Errorf("Malformed marshaled value")
+	}
+	protocol, err := strconv.Atoi(charset.ToUTF8(elements[0]))
+	if err != nil {
+		return fmt.Errorf("Malformed protocol: %v", err)
+	}
+	params := domain.CommunicationParameter{
+		Protocol: protocol,
+		Address:  unescape(charset.ToUTF8(elements[1])),
+	}
+	if len(elements) > 2 {
+		params.AddressAddition = unescape(charset.ToUTF8(elements[2]))
+	}
+	if len(elements) > 3 {
+		params.FilterFunction = charset.ToUTF8(elements[3])
+	}
+	if len(elements) > 4 && len(elements[4]) > 0 {
+		params.FilterFunctionVersion, err = strconv.Atoi(charset.ToUTF8(elements[4]))
+		if err != nil {
+			return fmt.Errorf("Malformed filter function version: %v", err)
+		}
+	}
+	*c = *NewCommunicationParameter(params)
+	return nil
+}
+
+// Val returns the CommunicationParameter represented by c
+func (c *CommunicationParameterDataElement) Val() domain.CommunicationParameter {
+	return domain.CommunicationParameter{
+		Protocol:              c.Protocol.Val(),
+		Address:               c.Address.Val(),
+		AddressAddition:       c.AddressAddition.Val(),
+		FilterFunction:        c.FilterFunction.Val(),
+		FilterFunctionVersion: c.FilterFunctionVersion.Val(),
+	}
+}
+
+// NewCommunicationParameters returns a new CommunicationParametersDataElement
+func NewCommunicationParameters(params ...domain.CommunicationParameter) *CommunicationParametersDataElement {
+	paramDEs := make([]DataElement, len(params))
+	for i, param := range params {
+		paramDEs[i] = NewCommunicationParameter(param)
+	}
+	c := &CommunicationParametersDataElement{}
+	c.arrayElementGroup = newArrayElementGroup(communicationParameterDEG, 1, 9, paramDEs)
+	return c
+}
+
+// CommunicationParametersDataElement represents all ways of communicating
+// with a HBCI server offered by an institute
+type CommunicationParametersDataElement struct {
+	*arrayElementGroup
+}
+
+// UnmarshalHBCI unmarshals value into the CommunicationParametersDataElement
+func (c *CommunicationParametersDataElement) UnmarshalHBCI(value []byte) error {
+	elements, err := extractDataElements(value)
+	if err != nil {
+		return err
+	}
+	params := make([]DataElement, len(elements))
+	for i, elem := range elements {
+		param := &CommunicationParameterDataElement{}
+		err := param.UnmarshalHBCI(elem)
+		if err != nil {
+			return err
+		}
+		params[i] = param
+	}
+	c.arrayElementGroup = newArrayElementGroup(communicationParameterDEG, 1, 9, params)
+	return nil
+}
+
+// Val returns the CommunicationParameters represented by c
+func (c *CommunicationParametersDataElement) Val() []domain.CommunicationParameter {
+	params := make([]domain.CommunicationParameter, len(c.array))
+	for i, param := range c.array {
+		params[i] = param.(*CommunicationParameterDataElement).Val()
+	}
+	return params
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/mitch000001/go-hbci/charset"
+	"github.com/mitch000001/go-hbci/domain"
 )
 
 // DataElement represent the general interface of a DataElement used by HBCI
@@ -355,25 +356,21 @@ func (n *NumberDataElement) UnmarshalHBCI(value []byte) error {
 }
 
 // NewFloat returns a new FloatDataElement
-func NewFloat(val float64, maxLength int) *FloatDataElement {
+func NewFloat(val domain.Decimal, maxLength int) *FloatDataElement {
 	return &FloatDataElement{&basicDataElement{val, floatDE, maxLength, false}}
 }
 
-// FloatDataElement represents a float in HBCI
+// FloatDataElement represents a float in HBCI. The value is held as exact
+// decimal and uses a comma as decimal separator on the wire.
 type FloatDataElement struct {
 	*basicDataElement
 }
 
-// Val returns the value of f as float64
-func (f *FloatDataElement) Val() float64 { return f.val.(float64) }
+// Val returns the value of f as domain.Decimal
+func (f *FloatDataElement) Val() domain.Decimal { return f.val.(domain.Decimal) }
 
 func (f *FloatDataElement) String() string {
-	str := strconv.FormatFloat(f.Val(), 'f', -1, 64)
-	str = strings.Replace(str, ".", ",", 1)
-	if !strings.Contains(str, ",") {
-		str = str + ","
-	}
-	return str
+	return f.Val().Text(',')
 }
 
 // MarshalHBCI marshals f into HBCI wire format
@@ -383,8 +380,11 @@ func (f *FloatDataElement) MarshalHBCI() ([]byte, error) {
 
 // UnmarshalHBCI unmarshals value into f
 func (f *FloatDataElement) UnmarshalHBCI(value []byte) error {
-	str := strings.Replace(charset.ToUTF8(value), ",", ".", 1)
-	val, err := strconv.ParseFloat(str, 64)
+	str := charset.ToUTF8(value)
+	if strings.Contains(str, ".") {
+		return fmt.Errorf("Malformed float: %q", str)
+	}
+	val, err := domain.ParseDecimal(str)
 	if err != nil {
 		return err
 	}
@@ -736,7 +736,7 @@ func (c *CurrencyDataElement) UnmarshalHBCI(value []byte) error {
 }
 
 // NewValue returns a new ValueDataElement
-func NewValue(val float64) *ValueDataElement {
+func NewValue(val domain.Decimal) *ValueDataElement {
 	f := NewFloat(val, 15)
 	f.typ = valueDE
 	return &ValueDataElement{f}
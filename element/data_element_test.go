@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/mitch000001/go-hbci/charset"
+	"github.com/mitch000001/go-hbci/domain"
 )
 
 type testDataElementData struct {
@@ -366,6 +367,50 @@ func TestBinaryDataElementUnmarshalHBCI(t *testing.T) {
 	}
 }
 
+func TestValueDataElementString(t *testing.T) {
+	tests := []struct {
+		in       domain.Decimal
+		expected string
+	}{
+		{domain.NewDecimal(100015, 2), "1000,15"},
+		{domain.NewDecimal(1005, 1), "100,5"},
+		{domain.NewDecimalFromInt(500), "500,"},
+	}
+	for _, test := range tests {
+		actual := NewValue(test.in).String()
+
+		if actual != test.expected {
+			t.Logf("Expected ValueDataElement to serialize to %q, got %q\n", test.expected, actual)
+			t.Fail()
+		}
+	}
+}
+
+func TestValueDataElementUnmarshalHBCI(t *testing.T) {
+	var v ValueDataElement
+
+	err := v.UnmarshalHBCI([]byte("1000,15"))
+
+	if err != nil {
+		t.Logf("Expected no error, got %T:%v\n", err, err)
+		t.Fail()
+	}
+
+	expectedVal := domain.NewDecimal(100015, 2)
+
+	if !reflect.DeepEqual(expectedVal, v.Val()) {
+		t.Logf("Expected Val() to return %s, got %s\n", expectedVal, v.Val())
+		t.Fail()
+	}
+
+	err = v.UnmarshalHBCI([]byte("1000.15"))
+
+	if err == nil {
+		t.Logf("Expected error for point as decimal separator, got nil\n")
+		t.Fail()
+	}
+}
+
 type testDataElement struct {
 	alpha *AlphaNumericDataElement
 	num   *NumberDataElement
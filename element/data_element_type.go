@@ -62,6 +62,7 @@ const (
 	allowedBusinessTransactionDEG
 	disposalEligiblePersonDEG
 	securityProfileDEG
+	supportedCompressionMethodsDEG
 )
 
 var typeName = map[DataElementType]string{
@@ -93,25 +94,26 @@ var typeName = map[DataElementType]string{
 	acknowlegdementParamsGDEG:              "Rückmeldungsparameter",
 	pinTanBusinessTransactionParameterGDEG: "Geschäftsvorfallspezifische PIN-TAN-Informationen",
 	// DataElementGroups
-	segmentHeaderDEG:              "Segmentkopf",
-	referenceMessageDEG:           "Bezugsnachricht",
-	acknowledgementDEG:            "Rückmeldung",
-	securityIdentificationDEG:     "Sicherheitsidentifikation, Details",
-	securityDateDEG:               "Sicherheitsdatum und -uhrzeit",
-	hashAlgorithmDEG:              "Hashalgorithmus",
-	signatureAlgorithmDEG:         "Signaturalgorithmus",
-	encryptionAlgorithmDEG:        "Verschlüsselungsalgorithmus",
-	keyNameDEG:                    "Schlüsselname",
-	certificateDEG:                "Zertifikat",
-	publicKeyDEG:                  "Öffentlicher Schlüssel",
-	supportedLanguagesDEG:         "Unterstützte Sprachen",
-	supportedHBCIVersionDEG:       "Unterstützte HBCI-Versionen",
-	communicationParameterDEG:     "Kommunikationsparameter",
-	pinTanDEG:                     "PIN-TAN",
-	accountLimitDEG:               "Kontolimit",
-	allowedBusinessTransactionDEG: "Erlaubte Geschäftsvorfälle",
-	disposalEligiblePersonDEG:     "Verfügungsberechtigte",
-	securityProfileDEG:            "Sicherheitsprofil",
+	segmentHeaderDEG:               "Segmentkopf",
+	referenceMessageDEG:            "Bezugsnachricht",
+	acknowledgementDEG:             "Rückmeldung",
+	securityIdentificationDEG:      "Sicherheitsidentifikation, Details",
+	securityDateDEG:                "Sicherheitsdatum und -uhrzeit",
+	hashAlgorithmDEG:               "Hashalgorithmus",
+	signatureAlgorithmDEG:          "Signaturalgorithmus",
+	encryptionAlgorithmDEG:         "Verschlüsselungsalgorithmus",
+	keyNameDEG:                     "Schlüsselname",
+	certificateDEG:                 "Zertifikat",
+	publicKeyDEG:                   "Öffentlicher Schlüssel",
+	supportedLanguagesDEG:          "Unterstützte Sprachen",
+	supportedHBCIVersionDEG:        "Unterstützte HBCI-Versionen",
+	communicationParameterDEG:      "Kommunikationsparameter",
+	pinTanDEG:                      "PIN-TAN",
+	accountLimitDEG:                "Kontolimit",
+	allowedBusinessTransactionDEG:  "Erlaubte Geschäftsvorfälle",
+	disposalEligiblePersonDEG:      "Verfügungsberechtigte",
+	securityProfileDEG:             "Sicherheitsprofil",
+	supportedCompressionMethodsDEG: "Unterstützte Komprimierungsverfahren",
 }
 
 func (d DataElementType) String() string {
@@ -3,6 +3,7 @@ package element
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 
 	"github.com/mitch000001/go-hbci/token"
 )
@@ -16,16 +17,41 @@ func ExtractElements(dataElementGroup []byte) ([][]byte, error) {
 // newElementExtractor creates a new GroupExtractor ready to use
 func newElementExtractor(dataElementGroup []byte) *groupExtractor {
 	// TODO: workaround to get the lexer work properly for us. Maybe we should adopt the lexer?
-	if bytes.LastIndexFunc(dataElementGroup, func(r rune) bool {
-		return r == '+' || r == '\''
-	}) == -1 {
-		dataElementGroup = append(dataElementGroup, '+')
+	if !endsWithDelimiter(dataElementGroup) {
+		dataElementGroup = append(dataElementGroup[:len(dataElementGroup):len(dataElementGroup)], '+')
 	}
 	return &groupExtractor{
 		rawDataElementGroup: dataElementGroup,
 	}
 }
 
+// endsWithDelimiter reports whether value ends with an unescaped data element
+// separator or segment end marker. Escaped characters and the content of
+// binary data are skipped, as they may contain any byte.
+func endsWithDelimiter(value []byte) bool {
+	delimiterAtEnd := false
+	for i := 0; i < len(value); i++ {
+		delimiterAtEnd = false
+		switch value[i] {
+		case '?':
+			i++
+		case '@':
+			end := bytes.IndexByte(value[i+1:], '@')
+			if end == -1 {
+				return false
+			}
+			length, err := strconv.Atoi(string(value[i+1 : i+1+end]))
+			if err != nil {
+				continue
+			}
+			i += end + 1 + length
+		case '+', '\'':
+			delimiterAtEnd = true
+		}
+	}
+	return delimiterAtEnd
+}
+
 // An groupExtractor extracts DataElements from DataElementGroups
 type groupExtractor struct {
 	rawDataElementGroup []byte
@@ -53,3 +79,30 @@ func (e *groupExtractor) Extract() ([][]byte, error) {
 	copy(result, e.elements)
 	return result, nil
 }
+
+// extractDataElements splits value into its data elements. Other than
+// ExtractElements it keeps data element groups together, so it can be used to
+// unmarshal repeated data element groups.
+func extractDataElements(value []byte) ([][]byte, error) {
+	if !bytes.HasSuffix(value, []byte("'")) || bytes.HasSuffix(value, []byte("?'")) {
+		value = append(value[:len(value):len(value)], '\'')
+	}
+	var elements [][]byte
+	var current []byte
+	lexer := token.NewLexer("DataElementExtractor", value)
+	for lexer.HasNext() {
+		t := lexer.Next()
+		switch t.Type() {
+		case token.ERROR:
+			return nil, fmt.Errorf("SyntaxError at position %d: %q\n(%q)", t.Pos(), t.Value(), value)
+		case token.DATA_ELEMENT_SEPARATOR, token.SEGMENT_END_MARKER:
+			elements = append(elements, current)
+			current = []byte{}
+		case token.GROUP_DATA_ELEMENT_SEPARATOR:
+			current = append(current, ':')
+		default:
+			current = append(current, t.Value()...)
+		}
+	}
+	return elements, nil
+}
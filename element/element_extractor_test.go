@@ -52,6 +52,16 @@ func Test_ExtractElements(t *testing.T) {
 			},
 			nil,
 		},
+		{
+			"2:13:@4@a+'b:6",
+			[]string{
+				"2",
+				"13",
+				"@4@a+'b",
+				"6",
+			},
+			nil,
+		},
 		{
 			"abcde:123:012",
 			[]string{
@@ -17,14 +17,30 @@ func NewPinTanEncryptionAlgorithm() *EncryptionAlgorithmDataElement {
 	return e
 }
 
+// Encryption algorithm codes as used within the EncryptionAlgorithmDataElement
+const (
+	// EncryptionAlgorithmTripleDES represents 2-Key-Triple-DES
+	EncryptionAlgorithmTripleDES = "13"
+	// EncryptionAlgorithmAES256 represents AES-256
+	EncryptionAlgorithmAES256 = "14"
+)
+
 // NewRDHEncryptionAlgorithm returns an EncryptionAlgorithmDataElement ready to
-// use in CardReader flow
-func NewRDHEncryptionAlgorithm(pubKey []byte) *EncryptionAlgorithmDataElement {
+// use in CardReader flow. encryptedKey is the message key encrypted with the
+// public key of the recipient.
+func NewRDHEncryptionAlgorithm(encryptedKey []byte) *EncryptionAlgorithmDataElement {
+	return NewEncryptionAlgorithm(EncryptionAlgorithmTripleDES, encryptedKey)
+}
+
+// NewEncryptionAlgorithm returns an EncryptionAlgorithmDataElement for
+// messages encrypted in CBC mode with the given algorithm. encryptedKey is
+// the message key encrypted with the public key of the recipient.
+func NewEncryptionAlgorithm(algorithm string, encryptedKey []byte) *EncryptionAlgorithmDataElement {
 	e := &EncryptionAlgorithmDataElement{
 		Usage:                      NewAlphaNumeric("2", 3),
 		OperationMode:              NewAlphaNumeric("2", 3),
-		Algorithm:                  NewAlphaNumeric("13", 3),
-		Key:                        NewBinary(pubKey, 512),
+		Algorithm:                  NewAlphaNumeric(algorithm, 3),
+		Key:                        NewBinary(encryptedKey, 512),
 		KeyParamID:                 NewAlphaNumeric("6", 3),
 		InitializationValueParamID: NewAlphaNumeric("1", 3),
 	}
@@ -40,6 +56,7 @@ type EncryptionAlgorithmDataElement struct {
 	// "2" for CBC, Cipher Block Chaining.
 	OperationMode *AlphaNumericDataElement
 	// "13" for 2-Key-Triple-DES
+	// "14" for AES-256
 	Algorithm *AlphaNumericDataElement
 	Key       *BinaryDataElement
 	// "5" for KYE, Symmetric key, en-/decryption with a symmetric key (DDV)
@@ -64,12 +81,12 @@ func (e *EncryptionAlgorithmDataElement) GroupDataElements() []DataElement {
 
 // UnmarshalHBCI unmarshals value into the DataElement
 func (e *EncryptionAlgorithmDataElement) UnmarshalHBCI(value []byte) error {
-	e = &EncryptionAlgorithmDataElement{}
 	elements, err := ExtractElements(value)
 	if err != nil {
 		return err
 	}
-	if len(elements) < 7 {
+	// The initialization value is optional
+	if len(elements) < 6 {
 		return fmt.Errorf("Malformed marshaled value")
 	}
 	e.DataElement = NewDataElementGroup(encryptionAlgorithmDEG, 5, e)
@@ -2,7 +2,6 @@ package element
 
 import (
 	"fmt"
-	"math"
 	"strconv"
 	"time"
 
@@ -11,7 +10,7 @@ import (
 )
 
 // NewAmount returns a new AmountDataElement
-func NewAmount(value float64, currency string) *AmountDataElement {
+func NewAmount(value domain.Decimal, currency string) *AmountDataElement {
 	a := &AmountDataElement{
 		Amount:   NewValue(value),
 		Currency: NewCurrency(currency),
@@ -288,14 +287,14 @@ func (i *InternationalAccountConnectionDataElement) Val() domain.InternationalAc
 // NewBalance returns a new BalanceDataElement
 func NewBalance(amount domain.Amount, date time.Time, withTime bool) *BalanceDataElement {
 	var debitCredit string
-	if amount.Amount < 0 {
+	if amount.Amount.Sign() < 0 {
 		debitCredit = "D"
 	} else {
 		debitCredit = "C"
 	}
 	b := &BalanceDataElement{
 		DebitCreditIndicator: NewAlphaNumeric(debitCredit, 1),
-		Amount:               NewValue(math.Abs(amount.Amount)),
+		Amount:               NewValue(amount.Amount.Abs()),
 		Currency:             NewCurrency(amount.Currency),
 		TransmissionDate:     NewDate(date),
 	}
@@ -332,7 +331,7 @@ func (b *BalanceDataElement) Balance() domain.Balance {
 	sign := b.DebitCreditIndicator.Val()
 	val := b.Amount.Val()
 	if sign == "D" {
-		val = -val
+		val = val.Neg()
 	}
 	currency := b.Currency.Val()
 	amount := domain.Amount{
@@ -2,19 +2,38 @@ package element
 
 import (
 	"fmt"
-	"reflect"
+	"math/big"
 
 	"github.com/mitch000001/go-hbci/domain"
 )
 
-// NewPublicKey creates a new PublicKeyElement from pubKey
-func NewPublicKey(pubKey *domain.PublicKey) *PublicKeyDataElement {
-	if !reflect.DeepEqual(pubKey.Exponent, []byte("65537")) {
-		panic(fmt.Errorf("Exponent must equal 65537 (% X)", "65537"))
+const (
+	// PublicKeyUsageEncryption marks a public key as encryption key (OCF)
+	PublicKeyUsageEncryption = "5"
+	// PublicKeyUsageSigning marks a public key as signing key (OSG)
+	PublicKeyUsageSigning = "6"
+)
+
+// NewPublicKey creates a new PublicKeyElement from pubKey. It returns an
+// error if the exponent of pubKey does not equal 65537.
+func NewPublicKey(pubKey *domain.PublicKey) (*PublicKeyDataElement, error) {
+	return NewPublicKeyWithOperationMode(pubKey, SignatureModeISO9796d1)
+}
+
+// NewPublicKeyWithOperationMode creates a new PublicKeyElement from pubKey
+// for the given operation mode, e.g. SignatureModePKCS1 for RDH-10 signing
+// keys. It returns an error if the exponent of pubKey does not equal 65537.
+func NewPublicKeyWithOperationMode(pubKey *domain.PublicKey, operationMode string) (*PublicKeyDataElement, error) {
+	if new(big.Int).SetBytes(pubKey.Exponent).Cmp(big.NewInt(65537)) != 0 {
+		return nil, fmt.Errorf("Exponent must equal 65537 (% X)", pubKey.Exponent)
+	}
+	usage := PublicKeyUsageSigning
+	if pubKey.Type == "V" {
+		usage = PublicKeyUsageEncryption
 	}
 	p := &PublicKeyDataElement{
-		Usage:         NewAlphaNumeric(pubKey.Type, 3),
-		OperationMode: NewAlphaNumeric("16", 3),
+		Usage:         NewAlphaNumeric(usage, 3),
+		OperationMode: NewAlphaNumeric(operationMode, 3),
 		Cipher:        NewAlphaNumeric("10", 3),
 		Modulus:       NewBinary(pubKey.Modulus, 512),
 		ModulusID:     NewAlphaNumeric("12", 3),
@@ -22,7 +41,7 @@ func NewPublicKey(pubKey *domain.PublicKey) *PublicKeyDataElement {
 		ExponentID:    NewAlphaNumeric("13", 3),
 	}
 	p.DataElement = NewDataElementGroup(publicKeyDEG, 7, p)
-	return p
+	return p, nil
 }
 
 // PublicKeyDataElement represents a public key
@@ -32,6 +51,8 @@ type PublicKeyDataElement struct {
 	// "6" for OSG, Owner Signing (Signing key)
 	Usage *AlphaNumericDataElement
 	// "16" for DSMR (ISO 9796)
+	// "18" for RSASSA-PKCS#1 V1.5
+	// "19" for RSASSA-PSS
 	OperationMode *AlphaNumericDataElement
 	// "10" for RSA
 	Cipher  *AlphaNumericDataElement
@@ -57,11 +78,60 @@ func (p *PublicKeyDataElement) GroupDataElements() []DataElement {
 	}
 }
 
-// Val returns the public key
+// Val returns the public key. The key type is "V" for encryption keys and
+// "S" for signing keys.
 func (p *PublicKeyDataElement) Val() *domain.PublicKey {
-	return &domain.PublicKey{
-		Type:     p.Usage.Val(),
-		Modulus:  p.Modulus.Val(),
-		Exponent: p.Exponent.Val(),
+	keyType := "S"
+	if p.Usage.Val() == PublicKeyUsageEncryption {
+		keyType = "V"
+	}
+	return domain.NewRSAPublicKey(keyType, p.Modulus.Val(), p.Exponent.Val())
+}
+
+// UnmarshalHBCI unmarshals value into the DataElement
+func (p *PublicKeyDataElement) UnmarshalHBCI(value []byte) error {
+	elements, err := ExtractElements(value)
+	if err != nil {
+		return err
+	}
+	if len(elements) < 7 {
+		return fmt.Errorf("Malformed marshaled value")
+	}
+	p.DataElement = NewDataElementGroup(publicKeyDEG, 7, p)
+	p.Usage = &AlphaNumericDataElement{}
+	err = p.Usage.UnmarshalHBCI(elements[0])
+	if err != nil {
+		return err
+	}
+	p.OperationMode = &AlphaNumericDataElement{}
+	err = p.OperationMode.UnmarshalHBCI(elements[1])
+	if err != nil {
+		return err
+	}
+	p.Cipher = &AlphaNumericDataElement{}
+	err = p.Cipher.UnmarshalHBCI(elements[2])
+	if err != nil {
+		return err
+	}
+	p.Modulus = &BinaryDataElement{}
+	err = p.Modulus.UnmarshalHBCI(elements[3])
+	if err != nil {
+		return err
+	}
+	p.ModulusID = &AlphaNumericDataElement{}
+	err = p.ModulusID.UnmarshalHBCI(elements[4])
+	if err != nil {
+		return err
+	}
+	p.Exponent = &BinaryDataElement{}
+	err = p.Exponent.UnmarshalHBCI(elements[5])
+	if err != nil {
+		return err
+	}
+	p.ExponentID = &AlphaNumericDataElement{}
+	err = p.ExponentID.UnmarshalHBCI(elements[6])
+	if err != nil {
+		return err
 	}
+	return nil
 }
@@ -0,0 +1,45 @@
+package element
+
+import (
+	"testing"
+
+	"github.com/mitch000001/go-hbci/domain"
+)
+
+func TestNewPublicKeyWithOperationMode(t *testing.T) {
+	tests := []struct {
+		description string
+		exponent    []byte
+		expectError bool
+	}{
+		{"exponent 65537", []byte{0x01, 0x00, 0x01}, false},
+		{"exponent 3", []byte{0x03}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			pubKey := domain.NewRSAPublicKey("V", []byte{0xAB, 0xCD}, test.exponent)
+
+			publicKey, err := NewPublicKeyWithOperationMode(pubKey, EncryptionModePKCS1)
+
+			if test.expectError {
+				if err == nil {
+					t.Logf("Expected error, got nil\n")
+					t.Fail()
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+			if publicKey.Usage.Val() != PublicKeyUsageEncryption {
+				t.Logf("Expected usage %q, got %q\n", PublicKeyUsageEncryption, publicKey.Usage.Val())
+				t.Fail()
+			}
+			if publicKey.OperationMode.Val() != EncryptionModePKCS1 {
+				t.Logf("Expected operation mode %q, got %q\n", EncryptionModePKCS1, publicKey.OperationMode.Val())
+				t.Fail()
+			}
+		})
+	}
+}
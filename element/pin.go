@@ -1,5 +1,7 @@
 package element
 
+import "fmt"
+
 const defaultPinTan = "\x00\x00\x00\x00\x00\x00\x00\x00"
 
 // NewCustomSignature returns a new CustomSignatureDataElement for the pin and
@@ -50,3 +52,36 @@ func (p *PinTanDataElement) GroupDataElements() []DataElement {
 		p.TAN,
 	}
 }
+
+// UnmarshalHBCI unmarshals value into p
+func (p *PinTanDataElement) UnmarshalHBCI(value []byte) error {
+	elements, err := ExtractElements(value)
+	if err != nil {
+		return err
+	}
+	if len(elements) == 0 {
+		return fmt.Errorf("Malformed marshaled value")
+	}
+	p.DataElement = NewDataElementGroup(pinTanDEG, 2, p)
+	if len(elements[0]) > 0 {
+		p.PIN = &AlphaNumericDataElement{}
+		err = p.PIN.UnmarshalHBCI(elements[0])
+		if err != nil {
+			return err
+		}
+	}
+	if len(elements) > 1 && len(elements[1]) > 0 {
+		p.TAN = &AlphaNumericDataElement{}
+		err = p.TAN.UnmarshalHBCI(elements[1])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalHBCI unmarshals value into c
+func (c *CustomSignatureDataElement) UnmarshalHBCI(value []byte) error {
+	c.PinTanDataElement = &PinTanDataElement{}
+	return c.PinTanDataElement.UnmarshalHBCI(value)
+}
@@ -2,8 +2,10 @@ package element
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/mitch000001/go-hbci/charset"
 	"github.com/mitch000001/go-hbci/domain"
 )
 
@@ -118,6 +120,38 @@ type SecurityDateDataElement struct {
 	Time           *TimeDataElement
 }
 
+// UnmarshalHBCI unmarshals value into s
+func (s *SecurityDateDataElement) UnmarshalHBCI(value []byte) error {
+	elements, err := ExtractElements(value)
+	if err != nil {
+		return err
+	}
+	if len(elements) < 1 {
+		return fmt.Errorf("Malformed marshaled value")
+	}
+	s.DataElement = NewDataElementGroup(securityDateDEG, 3, s)
+	s.DateIdentifier = &AlphaNumericDataElement{}
+	err = s.DateIdentifier.UnmarshalHBCI(elements[0])
+	if err != nil {
+		return err
+	}
+	if len(elements) > 1 && len(elements[1]) > 0 {
+		s.Date = &DateDataElement{}
+		err = s.Date.UnmarshalHBCI(elements[1])
+		if err != nil {
+			return err
+		}
+	}
+	if len(elements) > 2 && len(elements[2]) > 0 {
+		s.Time = &TimeDataElement{}
+		err = s.Time.UnmarshalHBCI(elements[2])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GroupDataElements returns the grouped DataElements
 func (s *SecurityDateDataElement) GroupDataElements() []DataElement {
 	return []DataElement{
@@ -127,12 +161,26 @@ func (s *SecurityDateDataElement) GroupDataElements() []DataElement {
 	}
 }
 
+// Hash algorithm codes as used within the HashAlgorithmDataElement
+const (
+	// HashAlgorithmSHA256 represents SHA-256
+	HashAlgorithmSHA256 = "3"
+	// HashAlgorithmRIPEMD160 represents ZZZ, i.e. RIPEMD-160
+	HashAlgorithmRIPEMD160 = "999"
+)
+
 // NewDefaultHashAlgorithm creates a default HashAlgorithmDataElement with
 // values ready to use for initial dialog comm
 func NewDefaultHashAlgorithm() *HashAlgorithmDataElement {
+	return NewHashAlgorithm(HashAlgorithmRIPEMD160)
+}
+
+// NewHashAlgorithm creates a HashAlgorithmDataElement for the given hash
+// algorithm code
+func NewHashAlgorithm(algorithm string) *HashAlgorithmDataElement {
 	h := &HashAlgorithmDataElement{
 		Usage:            NewAlphaNumeric("1", 3),
-		Algorithm:        NewAlphaNumeric("999", 3),
+		Algorithm:        NewAlphaNumeric(algorithm, 3),
 		AlgorithmParamID: NewAlphaNumeric("1", 3),
 	}
 	h.DataElement = NewDataElementGroup(hashAlgorithmDEG, 4, h)
@@ -144,6 +192,7 @@ type HashAlgorithmDataElement struct {
 	DataElement
 	// "1" for OHA, Owner Hashing
 	Usage *AlphaNumericDataElement
+	// "3" for SHA-256
 	// "999" for ZZZ (RIPEMD-160)
 	Algorithm *AlphaNumericDataElement
 	// "1" for IVC, Initialization value, clear text
@@ -162,12 +211,72 @@ func (h *HashAlgorithmDataElement) GroupDataElements() []DataElement {
 	}
 }
 
+// UnmarshalHBCI unmarshals value into the DataElement
+func (h *HashAlgorithmDataElement) UnmarshalHBCI(value []byte) error {
+	elements, err := ExtractElements(value)
+	if err != nil {
+		return err
+	}
+	if len(elements) < 3 {
+		return fmt.Errorf("Malformed marshaled value")
+	}
+	h.DataElement = NewDataElementGroup(hashAlgorithmDEG, 4, h)
+	h.Usage = &AlphaNumericDataElement{}
+	err = h.Usage.UnmarshalHBCI(elements[0])
+	if err != nil {
+		return err
+	}
+	h.Algorithm = &AlphaNumericDataElement{}
+	err = h.Algorithm.UnmarshalHBCI(elements[1])
+	if err != nil {
+		return err
+	}
+	h.AlgorithmParamID = &AlphaNumericDataElement{}
+	err = h.AlgorithmParamID.UnmarshalHBCI(elements[2])
+	if err != nil {
+		return err
+	}
+	if len(elements) > 3 && len(elements[3]) > 0 {
+		h.AlgorithmParamValue = &BinaryDataElement{}
+		err = h.AlgorithmParamValue.UnmarshalHBCI(elements[3])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Operation modes of the SignatureAlgorithmDataElement
+const (
+	// SignatureModeISO9796d1 represents DSMR, signing with ISO 9796-1
+	SignatureModeISO9796d1 = "16"
+	// SignatureModePKCS1 represents RSASSA-PKCS#1 V1.5
+	SignatureModePKCS1 = "18"
+	// SignatureModePSS represents RSASSA-PSS
+	SignatureModePSS = "19"
+)
+
+// Operation modes of public encryption keys
+const (
+	// EncryptionModeISO9796d1 represents the operation mode of RDH-2
+	// encryption keys, encrypting zero padded message keys with plain RSA
+	EncryptionModeISO9796d1 = "16"
+	// EncryptionModePKCS1 represents RSAES-PKCS#1 V1.5
+	EncryptionModePKCS1 = "18"
+)
+
 // NewRDHSignatureAlgorithm creates a SignatureAlgorithm ready to use for RDH
 func NewRDHSignatureAlgorithm() *SignatureAlgorithmDataElement {
+	return NewSignatureAlgorithm(SignatureModeISO9796d1)
+}
+
+// NewSignatureAlgorithm creates an RSA SignatureAlgorithm with the given
+// operation mode
+func NewSignatureAlgorithm(operationMode string) *SignatureAlgorithmDataElement {
 	s := &SignatureAlgorithmDataElement{
 		Usage:         NewAlphaNumeric("6", 3),
 		Algorithm:     NewAlphaNumeric("10", 3),
-		OperationMode: NewAlphaNumeric("16", 3),
+		OperationMode: NewAlphaNumeric(operationMode, 3),
 	}
 	s.DataElement = NewDataElementGroup(signatureAlgorithmDEG, 3, s)
 	return s
@@ -182,6 +291,8 @@ type SignatureAlgorithmDataElement struct {
 	// "10" for RSA (RDH)
 	Algorithm *AlphaNumericDataElement
 	// "16" for DSMR, Digital Signature Scheme giving Message Recovery: ISO 9796 (RDH)
+	// "18" for RSASSA-PKCS#1 V1.5
+	// "19" for RSASSA-PSS
 	// "999" for ZZZ (DDV)
 	OperationMode *AlphaNumericDataElement
 }
@@ -195,6 +306,34 @@ func (s *SignatureAlgorithmDataElement) GroupDataElements() []DataElement {
 	}
 }
 
+// UnmarshalHBCI unmarshals value into the DataElement
+func (s *SignatureAlgorithmDataElement) UnmarshalHBCI(value []byte) error {
+	elements, err := ExtractElements(value)
+	if err != nil {
+		return err
+	}
+	if len(elements) < 3 {
+		return fmt.Errorf("Malformed marshaled value")
+	}
+	s.DataElement = NewDataElementGroup(signatureAlgorithmDEG, 3, s)
+	s.Usage = &AlphaNumericDataElement{}
+	err = s.Usage.UnmarshalHBCI(elements[0])
+	if err != nil {
+		return err
+	}
+	s.Algorithm = &AlphaNumericDataElement{}
+	err = s.Algorithm.UnmarshalHBCI(elements[1])
+	if err != nil {
+		return err
+	}
+	s.OperationMode = &AlphaNumericDataElement{}
+	err = s.OperationMode.UnmarshalHBCI(elements[2])
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 // NewKeyName creates a new KeyNameDataElement for keyName
 func NewKeyName(keyName domain.KeyName) *KeyNameDataElement {
 	a := &KeyNameDataElement{
@@ -233,6 +372,38 @@ func (k *KeyNameDataElement) Val() domain.KeyName {
 	}
 }
 
+// UnmarshalHBCI unmarshals value into k
+func (k *KeyNameDataElement) UnmarshalHBCI(value []byte) error {
+	elements, err := ExtractElements(value)
+	if err != nil {
+		return err
+	}
+	if len(elements) < 6 {
+		return fmt.Errorf("Malformed marshaled value")
+	}
+	countryCode, err := strconv.Atoi(charset.ToUTF8(elements[0]))
+	if err != nil {
+		return fmt.Errorf("%T: Malformed CountryCode: %q", k, elements[0])
+	}
+	keyNumber, err := strconv.Atoi(charset.ToUTF8(elements[4]))
+	if err != nil {
+		return fmt.Errorf("%T: Malformed key number: %q", k, elements[4])
+	}
+	keyVersion, err := strconv.Atoi(charset.ToUTF8(elements[5]))
+	if err != nil {
+		return fmt.Errorf("%T: Malformed key version: %q", k, elements[5])
+	}
+	keyName := domain.KeyName{
+		BankID:     domain.BankID{CountryCode: countryCode, ID: charset.ToUTF8(elements[1])},
+		UserID:     unescape(charset.ToUTF8(elements[2])),
+		KeyType:    charset.ToUTF8(elements[3]),
+		KeyNumber:  keyNumber,
+		KeyVersion: keyVersion,
+	}
+	*k = *NewKeyName(keyName)
+	return nil
+}
+
 // GroupDataElements returns the grouped DataElements
 func (k *KeyNameDataElement) GroupDataElements() []DataElement {
 	return []DataElement{
@@ -264,6 +435,25 @@ type CertificateDataElement struct {
 	Content         *BinaryDataElement
 }
 
+// UnmarshalHBCI unmarshals value into c
+func (c *CertificateDataElement) UnmarshalHBCI(value []byte) error {
+	elements, err := ExtractElements(value)
+	if err != nil {
+		return err
+	}
+	if len(elements) < 2 {
+		return fmt.Errorf("Malformed marshaled value")
+	}
+	c.DataElement = NewDataElementGroup(certificateDEG, 2, c)
+	c.CertificateType = &NumberDataElement{}
+	err = c.CertificateType.UnmarshalHBCI(elements[0])
+	if err != nil {
+		return err
+	}
+	c.Content = &BinaryDataElement{}
+	return c.Content.UnmarshalHBCI(elements[1])
+}
+
 // GroupDataElements returns the grouped DataElements
 func (c *CertificateDataElement) GroupDataElements() []DataElement {
 	return []DataElement{
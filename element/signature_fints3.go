@@ -13,6 +13,17 @@ func NewPinTanSecurityProfile(securityMethod int) *SecurityProfileDataElement {
 	return s
 }
 
+// NewSecurityProfile returns a new SecurityProfile for the provided security
+// method, e.g. "PIN" or "RDH", and its version
+func NewSecurityProfile(securityMethod string, version int) *SecurityProfileDataElement {
+	s := &SecurityProfileDataElement{
+		SecurityMethod:        NewAlphaNumeric(securityMethod, 3),
+		SecurityMethodVersion: NewNumber(version, 3),
+	}
+	s.DataElement = NewDataElementGroup(securityProfileDEG, 2, s)
+	return s
+}
+
 // SecurityProfileDataElement defines a security method for the dialog flow
 type SecurityProfileDataElement struct {
 	DataElement
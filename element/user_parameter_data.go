@@ -8,7 +8,7 @@ import (
 )
 
 // NewAccountLimit creates a new Account limit
-func NewAccountLimit(kind string, amount float64, currency string, days int) *AccountLimitDataElement {
+func NewAccountLimit(kind string, amount domain.Decimal, currency string, days int) *AccountLimitDataElement {
 	a := &AccountLimitDataElement{
 		Kind:   NewAlphaNumeric(kind, 1),
 		Amount: NewAmount(amount, currency),
@@ -0,0 +1,676 @@
+// Package hbcitest provides a fake bank institute for end to end tests of
+// HBCI clients.
+//
+// The fake bank implements the FinTS 3.0 PIN/TAN dialog over HTTP, including
+// synchronisation, delivery of bank and user parameter data, balances,
+// account transactions with continuation, TAN challenges, PIN changes,
+// access locks and TAN media. It is meant to be used with a
+// httptest.Server:
+//
+//	server := hbcitest.NewServer(hbcitest.Config{
+//		BankID: "10000000",
+//		UserID: "12345",
+//		PIN:    "secret",
+//		Accounts: []hbcitest.Account{...},
+//	})
+//	defer server.Close()
+//
+//	c, err := client.New(client.Config{
+//		BankID:      "10000000",
+//		AccountID:   "12345",
+//		PIN:         "secret",
+//		URL:         server.URL,
+//		HBCIVersion: 300,
+//	})
+//
+// Messages are neither really signed nor encrypted, as the PIN/TAN
+// procedure does not require it.
+package hbcitest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/swift"
+)
+
+// anonymousUserID is the customer ID used by clients in anonymous dialogs
+const anonymousUserID = "9999999999"
+
+// Config defines the behaviour of a Bank
+type Config struct {
+	// BankID is the german bank code of the institute
+	BankID string
+	// BankName is the name of the institute. It defaults to "Testbank".
+	BankName string
+	// UserID is the ID of the only user known to the institute
+	UserID string
+	// PIN is the PIN of the user
+	PIN string
+	// TAN is the TAN accepted for all jobs which require one
+	TAN string
+	// TANRequired lists the IDs of the segments which require a TAN, e.g.
+	// "HKKAZ". Those jobs have to be submitted with a HKTAN segment.
+	TANRequired []string
+	// Accounts are the accounts of the user
+	Accounts []Account
+	// TanMedia are the TAN media of the user listed by HKTAB. TAN generators
+	// can be synchronized with HKTSY using TAN.
+	TanMedia []domain.TanMedium
+	// TransactionsPerMessage limits the number of transactions returned per
+	// HIKAZ segment. If there are more transactions, the institute returns a
+	// continuation reference. If zero, all transactions are returned at once.
+	TransactionsPerMessage int
+	// BPDVersion is the version of the bank parameter data. It defaults
+	// to 1.
+	BPDVersion int
+	// UPDVersion is the version of the user parameter data. It defaults
+	// to 1.
+	UPDVersion int
+	// MaxJobsPerMessage is the maximum number of business transactions per
+	// message announced within HIBPA. Messages with more jobs are rejected.
+	// It defaults to 3.
+	MaxJobsPerMessage int
+	// MaxMessageSize is the maximum message size in kB announced within
+	// HIBPA. Larger messages are rejected. If zero, the size is not limited.
+	MaxMessageSize int
+	// CompressionFunctions are the codes of the compression functions
+	// announced within HIKPV, e.g. "6" for ZLIB. Responses are compressed
+	// like the request they answer. If empty, HIKPV is not sent.
+	CompressionFunctions []string
+}
+
+// An Account is an account held by the user at the fake institute
+type Account struct {
+	AccountID   string
+	IBAN        string
+	Name        string
+	ProductName string
+	// Currency is the currency of the account. It defaults to "EUR".
+	Currency string
+	// Balance is the current booked balance of the account
+	Balance domain.Decimal
+	// Transactions are the booked transactions of the account, sorted by
+	// booking date
+	Transactions []domain.AccountTransaction
+}
+
+// NewServer starts and returns a new httptest.Server serving a Bank
+// configured with config. The caller should call Close when finished, to
+// shut it down.
+func NewServer(config Config) *httptest.Server {
+	return httptest.NewServer(NewBank(config))
+}
+
+// NewBank returns a new Bank configured with config
+func NewBank(config Config) *Bank {
+	if config.BankName == "" {
+		config.BankName = "Testbank"
+	}
+	if config.BPDVersion == 0 {
+		config.BPDVersion = 1
+	}
+	if config.UPDVersion == 0 {
+		config.UPDVersion = 1
+	}
+	if config.MaxJobsPerMessage == 0 {
+		config.MaxJobsPerMessage = 3
+	}
+	for i := range config.Accounts {
+		if config.Accounts[i].Currency == "" {
+			config.Accounts[i].Currency = "EUR"
+		}
+	}
+	return &Bank{
+		config:  config,
+		dialogs: make(map[string]*dialogState),
+	}
+}
+
+// A Bank implements http.Handler and acts as a bank institute speaking the
+// FinTS 3.0 PIN/TAN protocol. Requests and responses are Base64 encoded.
+//
+// A Bank is safe for concurrent use by multiple dialogs.
+type Bank struct {
+	config         Config
+	mu             sync.Mutex
+	dialogs        map[string]*dialogState
+	dialogCount    int
+	referenceCount int
+	failures       []acknowledgement
+	// locked is true once the user locked the access with HKPSA
+	locked bool
+}
+
+type dialogState struct {
+	userID      string
+	anonymous   bool
+	pendingJobs map[string][]requestSegment
+	// continuations maps the continuation references issued within the
+	// dialog to the index of the next transaction. Like with real banks,
+	// they are only valid within their dialog.
+	continuations map[string]int
+}
+
+// OpenDialogs returns the number of dialogs which were initialized but not
+// yet ended
+func (b *Bank) OpenDialogs() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.dialogs)
+}
+
+// FailNext makes the Bank reject the next n messages with a message
+// acknowledgement with the given code and text, e.g. "9800" and "Dialog
+// abgebrochen". The dialogs of the rejected messages are discarded.
+func (b *Bank) FailNext(n int, code, text string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := 0; i < n; i++ {
+		b.failures = append(b.failures, acknowledgement{code: code, text: text})
+	}
+}
+
+// ServeHTTP answers the HBCI message contained in r
+func (b *Bank) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, r.Body))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Malformed request: %v", err), http.StatusBadRequest)
+		return
+	}
+	req, err := parseRequest(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Malformed request: %v", err), http.StatusBadRequest)
+		return
+	}
+	req.url = "http://" + r.Host + strings.TrimSuffix(r.URL.Path, "/")
+	b.mu.Lock()
+	res := b.process(req)
+	b.mu.Unlock()
+	w.Header().Set("Content-Type", "application/vnd.hbci")
+	w.Write([]byte(base64.StdEncoding.EncodeToString(res.marshal(b.config))))
+}
+
+// process answers req. It must be called with b.mu held.
+func (b *Bank) process(req *request) *response {
+	res := &response{
+		dialogID:      req.dialogID,
+		messageNumber: req.messageNumber,
+		encrypted:     req.encrypted,
+		compression:   req.compression,
+	}
+	if len(b.failures) > 0 {
+		res.failed = true
+		res.messageAcks = append(res.messageAcks, b.failures[0])
+		b.failures = b.failures[1:]
+		delete(b.dialogs, req.dialogID)
+		return res
+	}
+	identification := req.find("HKIDN")
+	state, ok := b.dialogs[req.dialogID]
+	if !ok {
+		if identification == nil || req.dialogID != "0" {
+			res.abort("9010", "Dialog unbekannt")
+			return res
+		}
+		b.dialogCount++
+		state = &dialogState{
+			userID:        identification.value(2, 1),
+			pendingJobs:   make(map[string][]requestSegment),
+			continuations: make(map[string]int),
+		}
+		state.anonymous = state.userID == anonymousUserID
+		res.dialogID = fmt.Sprintf("DIALOG%d", b.dialogCount)
+	}
+	if !state.anonymous {
+		if state.userID != b.config.UserID {
+			res.abort("9931", "Anmeldename oder PIN ist falsch")
+			delete(b.dialogs, req.dialogID)
+			return res
+		}
+		signatureEnd := req.find("HNSHA")
+		if signatureEnd == nil {
+			res.abort("9110", "Signatur fehlt")
+			delete(b.dialogs, req.dialogID)
+			return res
+		}
+		if signatureEnd.value(3, 1) != b.config.PIN {
+			res.abort("9942", "PIN falsch")
+			delete(b.dialogs, req.dialogID)
+			return res
+		}
+		if !ok && b.locked {
+			res.abort("9930", "Zugang gesperrt")
+			return res
+		}
+		req.tan = signatureEnd.value(3, 2)
+	}
+	if !ok {
+		b.dialogs[res.dialogID] = state
+	}
+	if max := b.config.MaxMessageSize; max > 0 && req.size > max*1024 {
+		res.failed = true
+		res.messageAck("9120", fmt.Sprintf("Nachricht größer als %d kB", max))
+		return res
+	}
+	if jobs := len(req.businessTransactions()); jobs > b.config.MaxJobsPerMessage {
+		res.failed = true
+		res.messageAck("9120", fmt.Sprintf("Mehr als %d Aufträge pro Nachricht", b.config.MaxJobsPerMessage))
+		return res
+	}
+	for _, seg := range req.jobs() {
+		if req.isPending(seg) {
+			continue
+		}
+		b.processJob(state, req, seg, res)
+	}
+	if res.failed {
+		res.messageAck("9050", "Die Nachricht enthält Fehler")
+	} else if res.ended {
+		res.messageAck("0100", "Dialog beendet")
+		delete(b.dialogs, res.dialogID)
+	} else {
+		res.messageAck("0010", "Nachricht entgegengenommen")
+	}
+	return res
+}
+
+func (b *Bank) processJob(state *dialogState, req *request, seg requestSegment, res *response) {
+	if state.anonymous && seg.id != "HKIDN" && seg.id != "HKVVB" && seg.id != "HKEND" {
+		res.fail("9010", "Auftrag im anonymen Dialog nicht erlaubt", seg)
+		return
+	}
+	if b.tanRequired(seg.id) && !req.authorized {
+		res.fail("9075", "Starke Kundenauthentifizierung notwendig", seg)
+		return
+	}
+	switch seg.id {
+	case "HKIDN":
+		res.ack("0020", "Information fehlerfrei entgegengenommen", seg)
+	case "HKVVB":
+		b.processingPreparation(state, req, seg, res)
+	case "HKSYN":
+		b.synchronisation(seg, res)
+	case "HKEND":
+		res.ack("0100", "Dialog beendet", seg)
+		res.ended = true
+	case "HKTAN":
+		b.tanRequest(state, req, seg, res)
+	case "HKSAL":
+		b.accountBalance(seg, res)
+	case "HKKAZ":
+		b.accountTransactions(state, seg, res)
+	case "HKPAE":
+		b.pinChange(seg, res)
+	case "HKPSA":
+		b.locked = true
+		res.ack("0020", "Zugang gesperrt", seg)
+	case "HKTAB":
+		b.tanMedia(seg, res)
+	case "HKTSY":
+		b.tanGeneratorSync(req, seg, res)
+	default:
+		res.fail("9010", fmt.Sprintf("Geschäftsvorfall %s nicht unterstützt", seg.id), seg)
+	}
+}
+
+func (b *Bank) tanRequired(segmentID string) bool {
+	for _, id := range b.config.TANRequired {
+		if id == segmentID {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bank) processingPreparation(state *dialogState, req *request, seg requestSegment, res *response) {
+	bpdVersion, _ := strconv.Atoi(seg.value(1, 1))
+	if bpdVersion < b.config.BPDVersion {
+		b.bankParameterData(req, seg, res)
+	}
+	if state.anonymous {
+		res.ack("0020", "Dialoginitialisierung erfolgreich", seg)
+		return
+	}
+	updVersion, _ := strconv.Atoi(seg.value(2, 1))
+	if updVersion < b.config.UPDVersion {
+		b.userParameterData(seg, res)
+	}
+	res.ack("3920", "Zugelassene Ein- und Zwei-Schritt-Verfahren für den Benutzer", seg, "999")
+	res.ack("0020", "Dialoginitialisierung erfolgreich", seg)
+}
+
+func (b *Bank) bankParameterData(req *request, seg requestSegment, res *response) {
+	bankParameters := []string{
+		strconv.Itoa(b.config.BPDVersion),
+		"280:" + b.config.BankID,
+		escape(b.config.BankName),
+		strconv.Itoa(b.config.MaxJobsPerMessage),
+		"1",
+		"300",
+	}
+	if b.config.MaxMessageSize > 0 {
+		bankParameters = append(bankParameters, strconv.Itoa(b.config.MaxMessageSize))
+	}
+	res.add("HIBPA", 3, seg.number, bankParameters...)
+	res.add("HIKOM", 4, seg.number, "280:"+b.config.BankID, "1", "3:"+escape(req.url)+"::MIM:1")
+	res.add("HISHV", 3, seg.number, "N", "PIN:1")
+	res.add("HISALS", 5, seg.number, "1", "1")
+	res.add("HIKAZS", 5, seg.number, "1", "1", "360:N")
+	res.add("HIKAZS", 6, seg.number, "1", "1", "0", "360:N:N")
+	res.add("HIPAES", 1, seg.number, "1", "1")
+	res.add("HIPSAS", 1, seg.number, "1", "1")
+	res.add("HITABS", 4, seg.number, "1", "1")
+	res.add("HITABS", 5, seg.number, "1", "1")
+	res.add("HITSYS", 1, seg.number, "1", "1")
+	var pinTanTransactions []string
+	for _, id := range []string{"HKSAL", "HKKAZ", "HKTAN"} {
+		tanRequired := "N"
+		if b.tanRequired(id) {
+			tanRequired = "J"
+		}
+		pinTanTransactions = append(pinTanTransactions, id, tanRequired)
+	}
+	res.add("DIPINS", 1, seg.number, "1", "1", strings.Join(pinTanTransactions, ":"))
+	if len(b.config.CompressionFunctions) > 0 {
+		res.add("HIKPV", 1, seg.number, b.config.CompressionFunctions...)
+	}
+}
+
+func (b *Bank) userParameterData(seg requestSegment, res *response) {
+	res.add("HIUPA", 2, seg.number, escape(b.config.UserID), strconv.Itoa(b.config.UPDVersion), "0")
+	for _, account := range b.config.Accounts {
+		res.add("HIUPD", 6, seg.number,
+			escape(account.AccountID)+"::280:"+b.config.BankID,
+			escape(account.IBAN),
+			escape(b.config.UserID),
+			"1",
+			account.Currency,
+			escape(account.Name),
+			"",
+			escape(account.ProductName),
+			"",
+			"HKSAL:1+HKKAZ:1",
+		)
+	}
+}
+
+func (b *Bank) synchronisation(seg requestSegment, res *response) {
+	mode := seg.value(1, 1)
+	if mode != "0" {
+		res.fail("9010", fmt.Sprintf("Synchronisierungsmodus %s nicht unterstützt", mode), seg)
+		return
+	}
+	b.referenceCount++
+	res.add("HISYN", 4, seg.number, fmt.Sprintf("SYSTEM%d", b.referenceCount))
+	res.ack("0020", "Auftrag ausgeführt", seg)
+}
+
+// tanRequest implements the TAN process 4 and the second step of the TAN
+// process 2. Jobs submitted with a HKTAN in TAN process 4 are held back until
+// the TAN is submitted with TAN process 2.
+func (b *Bank) tanRequest(state *dialogState, req *request, seg requestSegment, res *response) {
+	switch process := seg.value(1, 1); process {
+	case "4":
+		if len(req.pending) == 0 {
+			res.fail("9010", "Kein Auftrag zur Freigabe vorhanden", seg)
+			return
+		}
+		b.referenceCount++
+		reference := fmt.Sprintf("TANREF%d", b.referenceCount)
+		state.pendingJobs[reference] = req.pending
+		res.add("HITAN", seg.version, seg.number, "4", "", escape(reference), escape("Bitte geben Sie die TAN ein"))
+		res.ack("0030", "Auftrag empfangen - Sicherheitsfreigabe erforderlich", seg)
+	case "2":
+		reference := seg.value(3, 1)
+		jobs, ok := state.pendingJobs[reference]
+		if !ok {
+			res.fail("9010", fmt.Sprintf("Auftragsreferenz %s unbekannt", reference), seg)
+			return
+		}
+		if req.tan != b.config.TAN {
+			res.fail("9941", "TAN falsch", seg)
+			return
+		}
+		delete(state.pendingJobs, reference)
+		res.add("HITAN", seg.version, seg.number, "2", "", escape(reference))
+		res.ack("0020", "Der Auftrag wurde ausgeführt", seg)
+		// The results of the jobs refer to the submitted TAN
+		for _, job := range jobs {
+			job.number = seg.number
+			b.processJob(state, &request{authorized: true}, job, res)
+		}
+	default:
+		res.fail("9010", fmt.Sprintf("TAN-Prozess %s nicht unterstützt", process), seg)
+	}
+}
+
+// pinChange changes the PIN of the user. The new PIN is valid for the
+// following messages.
+func (b *Bank) pinChange(seg requestSegment, res *response) {
+	newPin := seg.value(1, 1)
+	if newPin == "" {
+		res.fail("9010", "Neue PIN fehlt", seg)
+		return
+	}
+	b.config.PIN = newPin
+	res.ack("0020", "PIN geändert", seg)
+}
+
+// tanMedia lists the TAN media of the requested class in the layout of the
+// requested segment version
+func (b *Bank) tanMedia(seg requestSegment, res *response) {
+	class := domain.TanMediumClass(seg.value(2, 1))
+	elements := []string{"0"}
+	for _, medium := range b.config.TanMedia {
+		if class != domain.AllTanMediumClasses && class != medium.Class {
+			continue
+		}
+		elements = append(elements, formatTanMedium(medium, seg.version))
+	}
+	res.add("HITAB", seg.version, seg.number, elements...)
+	res.ack("0020", "Auftrag ausgeführt", seg)
+}
+
+// tanGeneratorSync accepts the synchronisation of a TAN generator of the
+// user if the TAN is valid
+func (b *Bank) tanGeneratorSync(req *request, seg requestSegment, res *response) {
+	cardNumber := seg.value(1, 1)
+	var known bool
+	for _, medium := range b.config.TanMedia {
+		if medium.Class == domain.TanGenerator && medium.CardNumber == cardNumber {
+			known = true
+		}
+	}
+	if !known {
+		res.fail("9010", fmt.Sprintf("TAN-Generator %s unbekannt", cardNumber), seg)
+		return
+	}
+	if seg.value(4, 1) != b.config.TAN {
+		res.fail("9941", "TAN falsch", seg)
+		return
+	}
+	res.ack("0020", "TAN-Generator synchronisiert", seg)
+}
+
+func (b *Bank) findAccount(accountID string) (Account, bool) {
+	for _, account := range b.config.Accounts {
+		if account.AccountID == accountID {
+			return account, true
+		}
+	}
+	return Account{}, false
+}
+
+func (b *Bank) accountBalance(seg requestSegment, res *response) {
+	account, ok := b.findAccount(seg.value(1, 1))
+	if !ok {
+		res.fail("9010", "Konto unbekannt", seg)
+		return
+	}
+	now := time.Now()
+	res.add("HISAL", 5, seg.number,
+		escape(account.AccountID)+"::280:"+b.config.BankID,
+		escape(account.ProductName),
+		account.Currency,
+		formatBalance(account.Balance, account.Currency, now),
+	)
+	res.ack("0020", "Auftrag ausgeführt", seg)
+}
+
+func (b *Bank) accountTransactions(state *dialogState, seg requestSegment, res *response) {
+	account, ok := b.findAccount(seg.value(1, 1))
+	if !ok {
+		res.fail("9010", "Konto unbekannt", seg)
+		return
+	}
+	from, _ := time.Parse("20060102", seg.value(3, 1))
+	to, _ := time.Parse("20060102", seg.value(4, 1))
+	// The transactions are sorted, so the requested timeframe is a contiguous
+	// range of them
+	start, end := 0, len(account.Transactions)
+	for start < end && !from.IsZero() && account.Transactions[start].BookingDate.Before(from) {
+		start++
+	}
+	for end > start && !to.IsZero() && account.Transactions[end-1].BookingDate.After(to) {
+		end--
+	}
+	if reference := seg.value(6, 1); reference != "" {
+		offset, ok := state.continuations[reference]
+		if !ok || offset < start || offset > end {
+			res.fail("9010", fmt.Sprintf("Aufsetzpunkt %s ungültig", reference), seg)
+			return
+		}
+		start = offset
+	}
+	limit := end
+	if perMessage := b.config.TransactionsPerMessage; perMessage > 0 && start+perMessage < end {
+		limit = start + perMessage
+	}
+	statement, err := b.statement(account, start, limit)
+	if err != nil {
+		res.fail("9010", fmt.Sprintf("Umsätze nicht verfügbar: %v", err), seg)
+		return
+	}
+	mt940, err := statement.Marshal()
+	if err != nil {
+		res.fail("9010", fmt.Sprintf("Umsätze nicht verfügbar: %v", err), seg)
+		return
+	}
+	res.add("HIKAZ", seg.version, seg.number, binary(mt940))
+	if limit < end {
+		b.referenceCount++
+		reference := fmt.Sprintf("KAZ%d", b.referenceCount)
+		state.continuations[reference] = limit
+		res.ack("3040", "Es liegen weitere Informationen vor", seg, reference)
+	} else {
+		res.ack("0020", "Auftrag ausgeführt", seg)
+	}
+}
+
+// statement returns a MT940 statement containing the transactions
+// account.Transactions[start:end]. The balances are computed backwards from
+// the current balance of account.
+func (b *Bank) statement(account Account, start, end int) (*swift.MT940, error) {
+	closing := account.Balance
+	var err error
+	for _, tr := range account.Transactions[end:] {
+		closing, err = closing.Sub(tr.Amount.Amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+	starting := closing
+	for _, tr := range account.Transactions[start:end] {
+		starting, err = starting.Sub(tr.Amount.Amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+	startingDate, closingDate := time.Now(), time.Now()
+	if start < end {
+		startingDate = account.Transactions[start].BookingDate
+		closingDate = account.Transactions[end-1].BookingDate
+	}
+	return swift.NewMT940(
+		"STARTUMS",
+		domain.AccountConnection{AccountID: account.AccountID, CountryCode: 280, BankID: b.config.BankID},
+		1,
+		domain.Balance{Amount: domain.Amount{Amount: starting, Currency: account.Currency}, TransmissionDate: startingDate},
+		domain.Balance{Amount: domain.Amount{Amount: closing, Currency: account.Currency}, TransmissionDate: closingDate},
+		account.Transactions[start:end],
+	), nil
+}
+
+func formatBalance(amount domain.Decimal, currency string, date time.Time) string {
+	indicator := "C"
+	if amount.Sign() < 0 {
+		indicator = "D"
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", indicator, amount.Abs().Text(','), currency, date.Format("20060102"))
+}
+
+// formatTanMedium returns the TAN medium as data element group of HITAB.
+// Version 4 contains a ktv as account connection, version 5 a kti.
+func formatTanMedium(medium domain.TanMedium, version int) string {
+	date := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("20060102")
+	}
+	var bankCode string
+	if medium.Account.BankID.ID != "" {
+		bankCode = strconv.Itoa(medium.Account.BankID.CountryCode)
+	}
+	account := []string{
+		escape(medium.Account.AccountID),
+		escape(medium.Account.SubAccountCharacteristics),
+		bankCode,
+		escape(medium.Account.BankID.ID),
+	}
+	if version >= 5 {
+		account = append([]string{escape(medium.Account.IBAN), escape(medium.Account.BIC)}, account...)
+	}
+	var freeTANs string
+	if medium.FreeTANs > 0 {
+		freeTANs = strconv.Itoa(medium.FreeTANs)
+	}
+	fields := []string{
+		string(medium.Class),
+		strconv.Itoa(int(medium.Status)),
+		escape(medium.CardNumber),
+		escape(medium.CardSequenceNumber),
+		"",
+	}
+	fields = append(fields, account...)
+	fields = append(fields,
+		date(medium.ValidFrom),
+		date(medium.ValidTo),
+		escape(medium.ListNumber),
+		escape(medium.Name),
+		escape(medium.MaskedMobileNumber),
+		escape(medium.MobileNumber),
+		// The SMS charge account is not supported
+		"", "", "", "", "", "",
+		freeTANs,
+		date(medium.LastUsed),
+		date(medium.ActivatedOn),
+	)
+	for len(fields) > 0 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	return strings.Join(fields, ":")
+}
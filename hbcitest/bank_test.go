@@ -0,0 +1,774 @@
+package hbcitest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitch000001/go-hbci/charset"
+	"github.com/mitch000001/go-hbci/client"
+	"github.com/mitch000001/go-hbci/domain"
+	middleware "github.com/mitch000001/go-hbci/transport/middleware"
+)
+
+func testConfig() Config {
+	date := func(day int) time.Time {
+		return time.Date(2015, 8, day, 0, 0, 0, 0, time.UTC)
+	}
+	var transactions []domain.AccountTransaction
+	for i, amount := range []string{"100", "-20,5", "-9,99", "1200", "-300"} {
+		transactions = append(transactions, domain.AccountTransaction{
+			Amount:      domain.Amount{Amount: domain.MustParseDecimal(amount), Currency: "EUR"},
+			ValutaDate:  date(i + 1),
+			BookingDate: date(i + 1),
+			BookingText: "Gutschrift",
+			Name:        "Max Muster",
+			Purpose:     fmt.Sprintf("Transaction %d", i+1),
+		})
+	}
+	return Config{
+		BankID: "10000000",
+		UserID: "12345",
+		PIN:    "secret",
+		TAN:    "123456",
+		Accounts: []Account{
+			{
+				AccountID:    "100000000",
+				IBAN:         "DE89100000000100000000",
+				Name:         "Max Muster",
+				ProductName:  "Girokonto",
+				Balance:      domain.MustParseDecimal("1500,15"),
+				Transactions: transactions,
+			},
+		},
+		TransactionsPerMessage: 2,
+	}
+}
+
+func newTestClient(t *testing.T, url, pin string) *client.Client {
+	c, err := client.New(client.Config{
+		BankID:      "10000000",
+		AccountID:   "12345",
+		PIN:         pin,
+		URL:         url,
+		HBCIVersion: 300,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	return c
+}
+
+func TestBankWithClient(t *testing.T) {
+	bank := NewBank(testConfig())
+	server := httptest.NewServer(bank)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, "secret")
+
+	accounts, err := c.Accounts()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if len(accounts) != 1 {
+		t.Fatalf("Expected one account, got %d\n", len(accounts))
+	}
+	expectedConnection := domain.AccountConnection{AccountID: "100000000", CountryCode: 280, BankID: "10000000"}
+	if accounts[0].AccountConnection != expectedConnection {
+		t.Logf("Expected account connection to equal\n%#v\n\tgot\n%#v\n", expectedConnection, accounts[0].AccountConnection)
+		t.Fail()
+	}
+
+	balances, err := c.AccountBalances(expectedConnection, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if len(balances) != 1 {
+		t.Fatalf("Expected one balance, got %d\n", len(balances))
+	}
+	if balance := balances[0].BookedBalance.Amount.String(); balance != "1500.15 EUR" {
+		t.Logf("Expected booked balance to equal %q, got %q\n", "1500.15 EUR", balance)
+		t.Fail()
+	}
+
+	timeframe := domain.Timeframe{
+		StartDate: domain.NewShortDate(time.Date(2015, 8, 1, 0, 0, 0, 0, time.UTC)),
+		EndDate:   domain.NewShortDate(time.Date(2015, 8, 31, 0, 0, 0, 0, time.UTC)),
+	}
+	transactions, err := c.AccountTransactions(expectedConnection, timeframe, false, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	var purposes []string
+	for _, tr := range transactions {
+		purposes = append(purposes, tr.Purpose)
+	}
+	expectedPurposes := "Transaction 1,Transaction 2,Transaction 3,Transaction 4,Transaction 5"
+	if strings.Join(purposes, ",") != expectedPurposes {
+		t.Logf("Expected transactions\n%s\n\tgot\n%s\n", expectedPurposes, strings.Join(purposes, ","))
+		t.Fail()
+	}
+
+	if open := bank.OpenDialogs(); open != 0 {
+		t.Logf("Expected all dialogs to be ended, got %d open dialogs\n", open)
+		t.Fail()
+	}
+}
+
+func TestBankAnnouncingCompression(t *testing.T) {
+	config := testConfig()
+	config.CompressionFunctions = []string{"5", "6"}
+	bank := NewBank(config)
+	var compressionFunctions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		decoded, _ := base64.StdEncoding.DecodeString(string(body))
+		if req, err := parseRequest(decoded); err == nil && req.encrypted {
+			compressionFunctions = append(compressionFunctions, req.compression)
+		}
+		r.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+		bank.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, "secret")
+
+	account := domain.AccountConnection{AccountID: "100000000", CountryCode: 280, BankID: "10000000"}
+	timeframe := domain.Timeframe{
+		StartDate: domain.NewShortDate(time.Date(2015, 8, 1, 0, 0, 0, 0, time.UTC)),
+		EndDate:   domain.NewShortDate(time.Date(2015, 8, 31, 0, 0, 0, 0, time.UTC)),
+	}
+	transactions, err := c.AccountTransactions(account, timeframe, false, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if len(transactions) != 5 {
+		t.Logf("Expected 5 transactions, got %d\n", len(transactions))
+		t.Fail()
+	}
+
+	// The PIN/TAN profile fixes the compression function, so no message is
+	// compressed even though the bank announces ZLIB
+	if len(compressionFunctions) < 3 {
+		t.Fatalf("Expected at least 3 encrypted messages, got compression functions %v\n", compressionFunctions)
+	}
+	for i, fn := range compressionFunctions {
+		if fn != "0" {
+			t.Logf("Expected message %d not to be compressed, got %q\n", i+1, fn)
+			t.Fail()
+		}
+	}
+}
+
+func TestBankWithBatchingClient(t *testing.T) {
+	config := testConfig()
+	config.MaxJobsPerMessage = 2
+	for _, accountID := range []string{"200000000", "300000000", "400000000", "500000000"} {
+		config.Accounts = append(config.Accounts, Account{
+			AccountID:   accountID,
+			Name:        "Max Muster",
+			ProductName: "Sparkonto",
+			Balance:     domain.MustParseDecimal("10"),
+		})
+	}
+	bank := NewBank(config)
+	var dialogIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		decoded, _ := base64.StdEncoding.DecodeString(string(body))
+		if req, err := parseRequest(decoded); err == nil && req.find("HKSAL") != nil {
+			dialogIDs = append(dialogIDs, req.dialogID)
+		}
+		r.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+		bank.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, "secret")
+
+	var accounts []domain.AccountConnection
+	for _, account := range config.Accounts {
+		accounts = append(accounts, domain.AccountConnection{AccountID: account.AccountID, CountryCode: 280, BankID: "10000000"})
+	}
+	balances, err := c.BatchAccountBalances(accounts, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if len(balances) != 5 {
+		t.Fatalf("Expected 5 balances, got %d\n", len(balances))
+	}
+	for i, balance := range balances {
+		if balance.Account.AccountID != accounts[i].AccountID {
+			t.Logf("Expected balance %d to belong to account %q, got %q\n", i, accounts[i].AccountID, balance.Account.AccountID)
+			t.Fail()
+		}
+	}
+
+	if len(dialogIDs) != 3 {
+		t.Fatalf("Expected jobs to be spread across 3 messages, got %d\n", len(dialogIDs))
+	}
+	for _, dialogID := range dialogIDs[1:] {
+		if dialogID != dialogIDs[0] {
+			t.Logf("Expected all messages to be sent within one dialog, got dialogs %v\n", dialogIDs)
+			t.Fail()
+			break
+		}
+	}
+	if open := bank.OpenDialogs(); open != 0 {
+		t.Logf("Expected all dialogs to be ended, got %d open dialogs\n", open)
+		t.Fail()
+	}
+}
+
+func TestBankParameterData(t *testing.T) {
+	config := testConfig()
+	config.TANRequired = []string{"HKKAZ"}
+	server := NewServer(config)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, "secret")
+
+	bpd, err := c.BankParameterData()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if !reflect.DeepEqual(bpd.SupportedHBCIVersions, []int{300}) {
+		t.Logf("Expected supported HBCI versions to equal %v, got %v\n", []int{300}, bpd.SupportedHBCIVersions)
+		t.Fail()
+	}
+	if len(bpd.CommunicationParameters) != 1 || bpd.CommunicationParameters[0].Address != server.URL {
+		t.Logf("Expected communication parameters with address %q, got %+v\n", server.URL, bpd.CommunicationParameters)
+		t.Fail()
+	}
+	expectedSecurityMethods := []domain.SecurityMethod{{Code: "PIN", Versions: []int{1}}}
+	if !reflect.DeepEqual(expectedSecurityMethods, bpd.SecurityMethods) {
+		t.Logf("Expected security methods to equal %+v, got %+v\n", expectedSecurityMethods, bpd.SecurityMethods)
+		t.Fail()
+	}
+	if !bpd.TANRequired("HKKAZ") || bpd.TANRequired("HKSAL") {
+		t.Logf("Expected only HKKAZ to require a TAN, got %v\n", bpd.PinTanBusinessTransactions)
+		t.Fail()
+	}
+
+	accountTransactions, ok := bpd.BusinessTransaction("HKKAZ")
+	if !ok {
+		t.Fatalf("Expected parameters for HKKAZ, got %+v\n", bpd.BusinessTransactions)
+	}
+	if accountTransactions.Version != 6 {
+		t.Logf("Expected highest version of HKKAZ to equal 6, got %d\n", accountTransactions.Version)
+		t.Fail()
+	}
+	if accountTransactions.AccountTransactions == nil || accountTransactions.AccountTransactions.StorageDuration != 360 {
+		t.Logf("Expected storage duration of 360 days, got %+v\n", accountTransactions.AccountTransactions)
+		t.Fail()
+	}
+	if versions := bpd.BusinessTransactionVersions("HKKAZ"); !reflect.DeepEqual(versions, []int{5, 6}) {
+		t.Logf("Expected HKKAZ versions to equal %v, got %v\n", []int{5, 6}, versions)
+		t.Fail()
+	}
+}
+
+func TestBankCapabilities(t *testing.T) {
+	config := testConfig()
+	config.TANRequired = []string{"HKKAZ"}
+	bank := NewBank(config)
+	server := httptest.NewServer(bank)
+	defer server.Close()
+
+	anonymousClient, err := client.NewAnonymous(client.Config{
+		BankID:      "10000000",
+		URL:         server.URL,
+		HBCIVersion: 300,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	anonymousCapabilities, err := anonymousClient.Capabilities()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	expectedJobs := []client.JobCapability{
+		{ID: "HKKAZ", Versions: []int{5, 6}, TANRequired: true, MaxJobs: 1, MinSignatures: 1},
+		{ID: "HKPAE", Versions: []int{1}, MaxJobs: 1, MinSignatures: 1},
+		{ID: "HKPSA", Versions: []int{1}, MaxJobs: 1, MinSignatures: 1},
+		{ID: "HKSAL", Versions: []int{5}, MaxJobs: 1, MinSignatures: 1},
+		{ID: "HKTAB", Versions: []int{4, 5}, MaxJobs: 1, MinSignatures: 1},
+		{ID: "HKTSY", Versions: []int{1}, MaxJobs: 1, MinSignatures: 1},
+	}
+	if !reflect.DeepEqual(expectedJobs, anonymousCapabilities.Jobs) {
+		t.Logf("Expected jobs to equal\n%+v\n\tgot\n%+v\n", expectedJobs, anonymousCapabilities.Jobs)
+		t.Fail()
+	}
+	if len(anonymousCapabilities.Accounts) != 0 {
+		t.Logf("Expected no accounts for anonymous capabilities, got %+v\n", anonymousCapabilities.Accounts)
+		t.Fail()
+	}
+
+	c := newTestClient(t, server.URL, "secret")
+	capabilities, err := c.Capabilities()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if !reflect.DeepEqual(expectedJobs, capabilities.Jobs) {
+		t.Logf("Expected jobs to equal\n%+v\n\tgot\n%+v\n", expectedJobs, capabilities.Jobs)
+		t.Fail()
+	}
+	expectedAccounts := []client.AccountCapabilities{
+		{
+			Account: domain.AccountConnection{AccountID: "100000000", CountryCode: 280, BankID: "10000000"},
+			Jobs:    []string{"HKSAL", "HKKAZ"},
+		},
+	}
+	if !reflect.DeepEqual(expectedAccounts, capabilities.Accounts) {
+		t.Logf("Expected accounts to equal\n%+v\n\tgot\n%+v\n", expectedAccounts, capabilities.Accounts)
+		t.Fail()
+	}
+	if open := bank.OpenDialogs(); open != 0 {
+		t.Logf("Expected all dialogs to be ended, got %d open dialogs\n", open)
+		t.Fail()
+	}
+}
+
+func TestBankWrongPIN(t *testing.T) {
+	server := NewServer(testConfig())
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, "wrong")
+
+	_, err := c.Accounts()
+	if err == nil {
+		t.Fatalf("Expected error, got nil\n")
+	}
+	if !strings.Contains(err.Error(), "9942") {
+		t.Logf("Expected error to contain code 9942, got %v\n", err)
+		t.Fail()
+	}
+}
+
+// pinProvider returns the given PINs one after another, moving on to the
+// next PIN when the current one gets rejected
+type pinProvider struct {
+	pins     []string
+	calls    int
+	rejected int
+}
+
+func (p *pinProvider) Pin() (string, error) {
+	p.calls++
+	return p.pins[p.rejected], nil
+}
+
+func (p *pinProvider) PinRejected() {
+	p.rejected++
+}
+
+func TestBankWithPinProvider(t *testing.T) {
+	server := NewServer(testConfig())
+	defer server.Close()
+
+	provider := &pinProvider{pins: []string{"wrong", "secret"}}
+	c, err := client.New(client.Config{
+		BankID:      "10000000",
+		AccountID:   "12345",
+		URL:         server.URL,
+		HBCIVersion: 300,
+		PinProvider: provider,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if provider.calls != 0 {
+		t.Logf("Expected provider not to be asked for the PIN before signing, got %d calls\n", provider.calls)
+		t.Fail()
+	}
+
+	_, err = c.Accounts()
+	if err == nil {
+		t.Fatalf("Expected error, got nil\n")
+	}
+	if provider.rejected != 1 {
+		t.Logf("Expected provider to be told about the rejected PIN once, got %d\n", provider.rejected)
+		t.Fail()
+	}
+
+	accounts, err := c.Accounts()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if len(accounts) != 1 {
+		t.Logf("Expected 1 account, got %d\n", len(accounts))
+		t.Fail()
+	}
+	if provider.rejected != 1 {
+		t.Logf("Expected no further rejected PIN, got %d\n", provider.rejected)
+		t.Fail()
+	}
+}
+
+func TestBankChangePin(t *testing.T) {
+	server := NewServer(testConfig())
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, "secret")
+
+	err := c.ChangePin("newsecret")
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	_, err = c.AccountBalances(domain.AccountConnection{AccountID: "100000000", CountryCode: 280, BankID: "10000000"}, false)
+	if err != nil {
+		t.Logf("Expected client to use the new PIN, got %T:%v\n", err, err)
+		t.Fail()
+	}
+	_, err = newTestClient(t, server.URL, "secret").Accounts()
+	if err == nil || !strings.Contains(err.Error(), "9942") {
+		t.Logf("Expected old PIN to be rejected with code 9942, got %v\n", err)
+		t.Fail()
+	}
+}
+
+// cachingPinProvider returns its PIN until it is told about a new one
+type cachingPinProvider struct {
+	pin      string
+	rejected int
+}
+
+func (p *cachingPinProvider) Pin() (string, error) { return p.pin, nil }
+func (p *cachingPinProvider) PinRejected() {
+	p.pin = ""
+	p.rejected++
+}
+func (p *cachingPinProvider) PinChanged(newPin string) {
+	p.pin = newPin
+}
+
+func TestBankChangePinWithPinProvider(t *testing.T) {
+	cachingProvider := &cachingPinProvider{pin: "secret"}
+	provider := &pinProvider{pins: []string{"secret", "newsecret"}}
+	tests := []struct {
+		description      string
+		provider         domain.PinProvider
+		rejected         *int
+		expectedRejected int
+	}{
+		{"provider notified about the change", cachingProvider, &cachingProvider.rejected, 0},
+		{"provider told to forget the old PIN", provider, &provider.rejected, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			server := NewServer(testConfig())
+			defer server.Close()
+			c, err := client.New(client.Config{
+				BankID:      "10000000",
+				AccountID:   "12345",
+				URL:         server.URL,
+				HBCIVersion: 300,
+				PinProvider: test.provider,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			err = c.ChangePin("newsecret")
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			_, err = c.AccountBalances(domain.AccountConnection{AccountID: "100000000", CountryCode: 280, BankID: "10000000"}, false)
+			if err != nil {
+				t.Logf("Expected client to use the new PIN, got %T:%v\n", err, err)
+				t.Fail()
+			}
+			if *test.rejected != test.expectedRejected {
+				t.Logf("Expected provider to be told %d times about a rejected PIN, was told %d times\n", test.expectedRejected, *test.rejected)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestBankLockAccess(t *testing.T) {
+	server := NewServer(testConfig())
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, "secret")
+
+	err := c.LockAccess()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	_, err = newTestClient(t, server.URL, "secret").Accounts()
+	if err == nil || !strings.Contains(err.Error(), "9930") {
+		t.Logf("Expected locked access to be rejected with code 9930, got %v\n", err)
+		t.Fail()
+	}
+}
+
+func TestBankTanMedia(t *testing.T) {
+	generator := domain.TanMedium{
+		Class:              domain.TanGenerator,
+		Status:             domain.TanMediumActive,
+		CardNumber:         "5109972878",
+		CardSequenceNumber: "1",
+		Account:            domain.InternationalAccountConnection{IBAN: "DE89100000000100000000", BIC: "BANKDEFFXXX"},
+		ValidTo:            time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC),
+		Name:               "Karte 1",
+	}
+	phone := domain.TanMedium{
+		Class:              domain.MobilePhone,
+		Status:             domain.TanMediumAvailable,
+		Name:               "Handy",
+		MaskedMobileNumber: "0170***123",
+	}
+	config := testConfig()
+	config.TanMedia = []domain.TanMedium{generator, phone}
+	server := NewServer(config)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL, "secret")
+
+	media, err := c.TanMedia(domain.AllTanMediumClasses)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	expected := []domain.TanMedium{generator, phone}
+	if !reflect.DeepEqual(expected, media) {
+		t.Logf("Expected TAN media to equal\n%+v\n\tgot\n%+v\n", expected, media)
+		t.Fail()
+	}
+
+	generators, err := c.TanMedia(domain.TanGenerator)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if len(generators) != 1 || generators[0].CardNumber != generator.CardNumber {
+		t.Fatalf("Expected the TAN generator only, got %+v\n", generators)
+	}
+
+	err = c.SyncTanGenerator(generators[0], 42, "654321")
+	if err == nil || !strings.Contains(err.Error(), "9941") {
+		t.Logf("Expected wrong TAN to be rejected with code 9941, got %v\n", err)
+		t.Fail()
+	}
+	err = c.SyncTanGenerator(generators[0], 42, "123456")
+	if err != nil {
+		t.Logf("Expected no error, got %T:%v\n", err, err)
+		t.Fail()
+	}
+	err = c.SyncTanGenerator(phone, 42, "123456")
+	if err == nil {
+		t.Logf("Expected error for TAN medium without card, got nil\n")
+		t.Fail()
+	}
+}
+
+func TestBankFailNextWithRetryingClient(t *testing.T) {
+	bank := NewBank(testConfig())
+	server := httptest.NewServer(bank)
+	defer server.Close()
+
+	c, err := client.New(client.Config{
+		BankID:      "10000000",
+		AccountID:   "12345",
+		PIN:         "secret",
+		URL:         server.URL,
+		HBCIVersion: 300,
+		RetryPolicy: &middleware.RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	account := domain.AccountConnection{AccountID: "100000000", CountryCode: 280, BankID: "10000000"}
+	if _, err := c.Accounts(); err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	bank.FailNext(2, "9800", "Dialog abgebrochen")
+
+	_, err = c.AccountBalances(account, false)
+	if err != nil {
+		t.Logf("Expected temporary errors to be retried, got %T:%v\n", err, err)
+		t.Fail()
+	}
+
+	bank.FailNext(3, "9800", "Dialog abgebrochen")
+
+	_, err = c.AccountBalances(account, false)
+	if err == nil {
+		t.Logf("Expected error after exceeding max retries, got nil\n")
+		t.Fail()
+	}
+
+	bank.FailNext(1, "9942", "PIN falsch")
+
+	_, err = c.AccountBalances(account, false)
+	if err == nil || !strings.Contains(err.Error(), "9942") {
+		t.Logf("Expected permanent error not to be retried, got %v\n", err)
+		t.Fail()
+	}
+}
+
+func TestBankTANChallenge(t *testing.T) {
+	config := testConfig()
+	config.TANRequired = []string{"HKKAZ"}
+	server := NewServer(config)
+	defer server.Close()
+
+	initResponse := post(t, server.URL,
+		"HNHBK:1:3+000000000000+300+0+1'",
+		"HKIDN:2:2+280:10000000+12345+0+1'",
+		"HKVVB:3:3+0+0+0+go-hbci+0.1'",
+		"HNSHA:4:2+1++secret'",
+		"HNHBS:5:1+1'",
+	)
+	dialogID := strings.Split(initResponse[0], "+")[3]
+
+	response := post(t, server.URL,
+		"HNHBK:1:3+000000000000+300+"+dialogID+"+2'",
+		"HKKAZ:2:6+100000000::280:10000000+N'",
+		"HNSHA:3:2+1++secret'",
+		"HNHBS:4:1+2'",
+	)
+	expectSegment(t, response, "HIRMS:3:2:2+9075::Starke Kundenauthentifizierung notwendig'")
+
+	response = post(t, server.URL,
+		"HNHBK:1:3+000000000000+300+"+dialogID+"+3'",
+		"HKKAZ:2:6+100000000::280:10000000+N'",
+		"HKTAN:3:6+4+HKKAZ'",
+		"HNSHA:4:2+1++secret'",
+		"HNHBS:5:1+3'",
+	)
+	expectSegment(t, response, "HIRMS:3:2:3+0030::Auftrag empfangen - Sicherheitsfreigabe erforderlich'")
+	challenge := findSegment(response, "HITAN")
+	if challenge == "" {
+		t.Fatalf("Expected HITAN segment, got %q\n", response)
+	}
+	if findSegment(response, "HIKAZ") != "" {
+		t.Logf("Expected job to be held back until TAN is submitted\n")
+		t.Fail()
+	}
+	reference := strings.Split(challenge, "+")[3]
+
+	response = post(t, server.URL,
+		"HNHBK:1:3+000000000000+300+"+dialogID+"+4'",
+		"HKTAN:2:6+2++"+reference+"'",
+		"HNSHA:3:2+1++secret:654321'",
+		"HNHBS:4:1+4'",
+	)
+	expectSegment(t, response, "HIRMS:3:2:2+9941::TAN falsch'")
+
+	response = post(t, server.URL,
+		"HNHBK:1:3+000000000000+300+"+dialogID+"+5'",
+		"HKTAN:2:6+2++"+reference+"'",
+		"HNSHA:3:2+1++secret:123456'",
+		"HNHBS:4:1+5'",
+	)
+	expectSegment(t, response, "HIRMS:3:2:2+0020::Der Auftrag wurde ausgeführt+3040::Es liegen weitere Informationen vor:KAZ2'")
+	if findSegment(response, "HIKAZ") == "" {
+		t.Logf("Expected HIKAZ segment, got %q\n", response)
+		t.Fail()
+	}
+
+	response = post(t, server.URL,
+		"HNHBK:1:3+000000000000+300+"+dialogID+"+6'",
+		"HKEND:2:1+"+dialogID+"'",
+		"HNSHA:3:2+1++secret'",
+		"HNHBS:4:1+6'",
+	)
+	expectSegment(t, response, "HIRMG:2:2+0100::Dialog beendet'")
+}
+
+func TestBankContinuationReferenceBoundToDialog(t *testing.T) {
+	server := NewServer(testConfig())
+	defer server.Close()
+	initDialog := func() string {
+		response := post(t, server.URL,
+			"HNHBK:1:3+000000000000+300+0+1'",
+			"HKIDN:2:2+280:10000000+12345+0+1'",
+			"HKVVB:3:3+0+0+0+go-hbci+0.1'",
+			"HNSHA:4:2+1++secret'",
+			"HNHBS:5:1+1'",
+		)
+		return strings.Split(response[0], "+")[3]
+	}
+	firstDialogID, secondDialogID := initDialog(), initDialog()
+
+	response := post(t, server.URL,
+		"HNHBK:1:3+000000000000+300+"+firstDialogID+"+2'",
+		"HKKAZ:2:6+100000000::280:10000000+N'",
+		"HNSHA:3:2+1++secret'",
+		"HNHBS:4:1+2'",
+	)
+	expectSegment(t, response, "HIRMS:3:2:2+3040::Es liegen weitere Informationen vor:KAZ1'")
+
+	response = post(t, server.URL,
+		"HNHBK:1:3+000000000000+300+"+secondDialogID+"+2'",
+		"HKKAZ:2:6+100000000::280:10000000+N++++KAZ1'",
+		"HNSHA:3:2+1++secret'",
+		"HNHBS:4:1+2'",
+	)
+	expectSegment(t, response, "HIRMS:3:2:2+9010::Aufsetzpunkt KAZ1 ungültig'")
+
+	response = post(t, server.URL,
+		"HNHBK:1:3+000000000000+300+"+firstDialogID+"+3'",
+		"HKKAZ:2:6+100000000::280:10000000+N++++KAZ1'",
+		"HNSHA:3:2+1++secret'",
+		"HNHBS:4:1+3'",
+	)
+	if findSegment(response, "HIKAZ") == "" {
+		t.Logf("Expected HIKAZ segment, got %q\n", response)
+		t.Fail()
+	}
+}
+
+// post sends the unencrypted message built from segments to url and returns
+// the segments of the response
+func post(t *testing.T, url string, segments ...string) []string {
+	body := base64.StdEncoding.EncodeToString([]byte(strings.Join(segments, "")))
+	res, err := http.Post(url, "application/vnd.hbci", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, res.Body))
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	extracted, err := extractSegments(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	var response []string
+	for _, seg := range extracted {
+		response = append(response, charset.ToUTF8(seg))
+	}
+	return response
+}
+
+func findSegment(segments []string, id string) string {
+	for _, seg := range segments {
+		if strings.HasPrefix(seg, id+":") {
+			return seg
+		}
+	}
+	return ""
+}
+
+func expectSegment(t *testing.T, segments []string, expected string) {
+	for _, seg := range segments {
+		if seg == expected {
+			return
+		}
+	}
+	t.Logf("Expected response to contain\n%q\n\tgot\n%q\n", expected, segments)
+	t.Fail()
+}
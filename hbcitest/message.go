@@ -0,0 +1,391 @@
+package hbcitest
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitch000001/go-hbci/charset"
+	"github.com/mitch000001/go-hbci/element"
+	"github.com/mitch000001/go-hbci/message"
+	"github.com/mitch000001/go-hbci/segment"
+)
+
+// envelopeSegments are the segments which frame, sign and encrypt the jobs of
+// a message
+var envelopeSegments = map[string]bool{
+	"HNHBK": true,
+	"HNHBS": true,
+	"HNVSK": true,
+	"HNVSD": true,
+	"HNSHK": true,
+	"HNSHA": true,
+}
+
+// A requestSegment is a segment sent by the client, split into its data
+// elements
+type requestSegment struct {
+	id       string
+	number   int
+	version  int
+	elements [][]byte
+}
+
+func parseRequestSegment(marshaledSegment []byte) (requestSegment, error) {
+	elements, err := segment.ExtractElements(marshaledSegment)
+	if err != nil {
+		return requestSegment{}, err
+	}
+	if len(elements) == 0 {
+		return requestSegment{}, fmt.Errorf("Malformed segment: %q", marshaledSegment)
+	}
+	header, err := element.ExtractElements(elements[0])
+	if err != nil {
+		return requestSegment{}, err
+	}
+	if len(header) < 3 {
+		return requestSegment{}, fmt.Errorf("Malformed segment header: %q", elements[0])
+	}
+	number, err := strconv.Atoi(string(header[1]))
+	if err != nil {
+		return requestSegment{}, fmt.Errorf("Malformed segment number: %q", header[1])
+	}
+	version, err := strconv.Atoi(string(header[2]))
+	if err != nil {
+		return requestSegment{}, fmt.Errorf("Malformed segment version: %q", header[2])
+	}
+	return requestSegment{
+		id:       string(header[0]),
+		number:   number,
+		version:  version,
+		elements: elements[1:],
+	}, nil
+}
+
+// value returns the unescaped value of the data element at position
+// elem, starting with 1, and the group data element at position group,
+// starting with 1. It returns an empty string if there is no such element.
+func (r requestSegment) value(elem, group int) string {
+	if elem < 1 || elem > len(r.elements) {
+		return ""
+	}
+	raw := r.elements[elem-1]
+	if bytes.HasPrefix(raw, []byte("@")) {
+		if group != 1 {
+			return ""
+		}
+		return string(raw[bytes.IndexByte(raw[1:], '@')+2:])
+	}
+	groupElements, err := element.ExtractElements(raw)
+	if err != nil || group < 1 || group > len(groupElements) {
+		return ""
+	}
+	return unescape(string(groupElements[group-1]))
+}
+
+// A request is a message sent by the client
+type request struct {
+	dialogID      string
+	messageNumber int
+	encrypted     bool
+	// size is the size of the marshaled message
+	size int
+	// url is the URL the request was sent to
+	url string
+	// compression is the compression function of the encrypted data
+	compression string
+	segments    []requestSegment
+	// tan is the TAN sent within the signature
+	tan string
+	// authorized is true if the jobs are submitted with a TAN request
+	authorized bool
+	// pending are the jobs to be held back until a TAN is submitted
+	pending []requestSegment
+}
+
+func parseRequest(marshaledMessage []byte) (*request, error) {
+	segments, err := extractSegments(marshaledMessage)
+	if err != nil {
+		return nil, err
+	}
+	req := &request{size: len(marshaledMessage)}
+	for _, marshaledSegment := range segments {
+		seg, err := parseRequestSegment(marshaledSegment)
+		if err != nil {
+			return nil, err
+		}
+		if seg.id == "HNVSK" {
+			req.compression = seg.value(7, 1)
+			if seg.version == 3 {
+				req.compression = seg.value(8, 1)
+			}
+		}
+		if seg.id == "HNVSD" {
+			req.encrypted = true
+			data, err := message.Decompress(req.compression, []byte(seg.value(1, 1)))
+			if err != nil {
+				return nil, err
+			}
+			inner, err := extractSegments(data)
+			if err != nil {
+				return nil, err
+			}
+			for _, marshaledInnerSegment := range inner {
+				innerSegment, err := parseRequestSegment(marshaledInnerSegment)
+				if err != nil {
+					return nil, err
+				}
+				req.segments = append(req.segments, innerSegment)
+			}
+			continue
+		}
+		req.segments = append(req.segments, seg)
+	}
+	header := req.find("HNHBK")
+	if header == nil {
+		return nil, fmt.Errorf("Malformed message: missing message header")
+	}
+	req.dialogID = header.value(3, 1)
+	req.messageNumber, err = strconv.Atoi(header.value(4, 1))
+	if err != nil {
+		return nil, fmt.Errorf("Malformed message number: %q", header.value(4, 1))
+	}
+	if tanRequest := req.find("HKTAN"); tanRequest != nil {
+		req.authorized = true
+		if tanRequest.value(1, 1) == "4" {
+			req.pending = req.businessTransactions()
+		}
+	}
+	return req, nil
+}
+
+func extractSegments(marshaledMessage []byte) ([][]byte, error) {
+	return message.NewSegmentExtractor(marshaledMessage).Extract()
+}
+
+// find returns the first segment with the given ID or nil if there is none
+func (r *request) find(id string) *requestSegment {
+	for i := range r.segments {
+		if r.segments[i].id == id {
+			return &r.segments[i]
+		}
+	}
+	return nil
+}
+
+// jobs returns all segments except the message envelope
+func (r *request) jobs() []requestSegment {
+	var jobs []requestSegment
+	for _, seg := range r.segments {
+		if !envelopeSegments[seg.id] {
+			jobs = append(jobs, seg)
+		}
+	}
+	return jobs
+}
+
+// businessTransactions returns all jobs except the ones managing the dialog
+// and the TAN process
+func (r *request) businessTransactions() []requestSegment {
+	var transactions []requestSegment
+	for _, job := range r.jobs() {
+		switch job.id {
+		case "HKTAN", "HKIDN", "HKVVB", "HKEND":
+		default:
+			transactions = append(transactions, job)
+		}
+	}
+	return transactions
+}
+
+func (r *request) isPending(seg requestSegment) bool {
+	for _, job := range r.pending {
+		if job.number == seg.number {
+			return true
+		}
+	}
+	return false
+}
+
+// A responseSegment is a segment sent by the institute. The elements must
+// already be escaped, binary data elements are created with binary.
+type responseSegment struct {
+	id        string
+	version   int
+	reference int
+	elements  []string
+}
+
+type acknowledgement struct {
+	code   string
+	text   string
+	params []string
+}
+
+func (a acknowledgement) String() string {
+	elements := []string{a.code, "", escape(a.text)}
+	for _, param := range a.params {
+		elements = append(elements, escape(param))
+	}
+	return strings.Join(elements, ":")
+}
+
+// A response collects the acknowledgements and segments answering a request
+type response struct {
+	dialogID        string
+	messageNumber   int
+	encrypted       bool
+	compression     string
+	failed          bool
+	ended           bool
+	messageAcks     []acknowledgement
+	segmentAcks     map[int][]acknowledgement
+	segmentAckOrder []int
+	segments        []responseSegment
+}
+
+func (r *response) add(id string, version, reference int, elements ...string) {
+	r.segments = append(r.segments, responseSegment{id, version, reference, elements})
+}
+
+func (r *response) messageAck(code, text string, params ...string) {
+	r.messageAcks = append(r.messageAcks, acknowledgement{code, text, params})
+}
+
+// abort rejects the whole message and ends the dialog
+func (r *response) abort(code, text string) {
+	r.failed = true
+	r.messageAck("9800", "Dialog abgebrochen")
+	r.messageAck(code, text)
+}
+
+func (r *response) ack(code, text string, seg requestSegment, params ...string) {
+	if r.segmentAcks == nil {
+		r.segmentAcks = make(map[int][]acknowledgement)
+	}
+	if _, ok := r.segmentAcks[seg.number]; !ok {
+		r.segmentAckOrder = append(r.segmentAckOrder, seg.number)
+	}
+	r.segmentAcks[seg.number] = append(r.segmentAcks[seg.number], acknowledgement{code, text, params})
+}
+
+func (r *response) fail(code, text string, seg requestSegment, params ...string) {
+	r.failed = true
+	r.ack(code, text, seg, params...)
+}
+
+// marshal returns the marshaled response message. If the request was
+// encrypted, the segments are wrapped into an encrypted data segment.
+func (r *response) marshal(config Config) []byte {
+	segments := []responseSegment{{id: "HIRMG", version: 2, elements: joinAcks(r.messageAcks)}}
+	for _, reference := range r.segmentAckOrder {
+		segments = append(segments, responseSegment{
+			id:        "HIRMS",
+			version:   2,
+			reference: reference,
+			elements:  joinAcks(r.segmentAcks[reference]),
+		})
+	}
+	segments = append(segments, r.segments...)
+	var body bytes.Buffer
+	for i, seg := range segments {
+		body.Write(seg.marshal(i + 2))
+	}
+	messageEndNumber := len(segments) + 2
+	if r.encrypted {
+		compression := r.compression
+		if compression == "" {
+			compression = message.CompressionNone
+		}
+		data, err := message.Compress(compression, body.Bytes())
+		if err != nil {
+			// Some compression functions can only be decompressed
+			compression = message.CompressionNone
+			data = body.Bytes()
+		}
+		now := time.Now()
+		encryptionHeader := responseSegment{id: "HNVSK", version: 3, elements: []string{
+			"PIN:1",
+			"998",
+			"1",
+			"2::0",
+			"1:" + now.Format("20060102") + ":" + now.Format("150405"),
+			"2:2:13:@8@00000000:5:1",
+			fmt.Sprintf("280:%s:%s:V:0:0", config.BankID, escape(config.UserID)),
+			compression,
+		}}
+		// The encrypted data is already converted, so it is marshaled
+		// directly
+		encryptedData := append([]byte(fmt.Sprintf("HNVSD:999:1+@%d@", len(data))), data...)
+		encryptedData = append(encryptedData, '\'')
+		body.Reset()
+		body.Write(encryptionHeader.marshal(998))
+		body.Write(encryptedData)
+		messageEndNumber = 2
+	}
+	messageEnd := responseSegment{id: "HNHBS", version: 1, elements: []string{strconv.Itoa(r.messageNumber)}}
+	body.Write(messageEnd.marshal(messageEndNumber))
+	// The size of the message header is fixed as the message size is
+	// formatted with 12 digits
+	header := func(size int) []byte {
+		return responseSegment{id: "HNHBK", version: 3, elements: []string{
+			fmt.Sprintf("%012d", size),
+			"300",
+			escape(r.dialogID),
+			strconv.Itoa(r.messageNumber),
+			fmt.Sprintf("%s:%d", escape(r.dialogID), r.messageNumber),
+		}}.marshal(1)
+	}
+	size := len(header(0)) + body.Len()
+	return append(header(size), body.Bytes()...)
+}
+
+// marshal returns the segment converted to ISO-8859-1. Binary data elements,
+// starting with "@", are converted as well and get their length adjusted.
+func (r responseSegment) marshal(number int) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s:%d:%d", r.id, number, r.version)
+	if r.reference != 0 {
+		fmt.Fprintf(&buf, ":%d", r.reference)
+	}
+	for _, elem := range r.elements {
+		buf.WriteByte('+')
+		if strings.HasPrefix(elem, "@") {
+			data := charset.ToISO8859_1(elem[strings.Index(elem[1:], "@")+2:])
+			fmt.Fprintf(&buf, "@%d@", len(data))
+			buf.Write(data)
+			continue
+		}
+		buf.Write(charset.ToISO8859_1(elem))
+	}
+	buf.WriteByte('\'')
+	return buf.Bytes()
+}
+
+// binary returns data as binary data element
+func binary(data []byte) string {
+	return fmt.Sprintf("@%d@%s", len(data), data)
+}
+
+func joinAcks(acks []acknowledgement) []string {
+	var elements []string
+	for _, ack := range acks {
+		elements = append(elements, ack.String())
+	}
+	return elements
+}
+
+var (
+	escaper   = strings.NewReplacer("?", "??", "@", "?@", "'", "?'", ":", "?:", "+", "?+")
+	unescaper = strings.NewReplacer("??", "?", "?@", "@", "?'", "'", "?:", ":", "?+", "+")
+)
+
+func escape(value string) string {
+	return escaper.Replace(value)
+}
+
+func unescape(value string) string {
+	return unescaper.Replace(value)
+}
@@ -0,0 +1,165 @@
+package message
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Compression functions as used within the encryption header and announced
+// by institutes within HIKPV
+const (
+	CompressionNone  = "0"
+	CompressionLZW   = "1"
+	CompressionCOM   = "2"
+	CompressionLZSS  = "3"
+	CompressionLZHuf = "4"
+	CompressionZIP   = "5"
+	CompressionZLIB  = "6"
+	CompressionBZIP2 = "7"
+)
+
+// maxDecompressedSize limits the size of decompressed messages. Messages are
+// decompressed before their signature is verified, so a small message must
+// not decompress to an arbitrary amount of data.
+const maxDecompressedSize = 64 << 20
+
+// SupportedCompressionFunctions contains the compression functions the client
+// is able to compress messages with, in order of preference
+var SupportedCompressionFunctions = []string{
+	CompressionZLIB,
+	CompressionZIP,
+}
+
+// decompressors contains the compression functions the client is able to
+// decompress. BZIP2 can only be decompressed.
+var decompressors = map[string]func([]byte) ([]byte, error){
+	CompressionZIP:   decompressZIP,
+	CompressionZLIB:  decompressZLIB,
+	CompressionBZIP2: decompressBZIP2,
+}
+
+// SelectCompressionFunction returns the first of the
+// SupportedCompressionFunctions which is also supported by the institute. If
+// there is none, CompressionNone is returned.
+func SelectCompressionFunction(institute []string) string {
+	for _, fn := range SupportedCompressionFunctions {
+		for _, instituteFn := range institute {
+			if fn == instituteFn {
+				return fn
+			}
+		}
+	}
+	return CompressionNone
+}
+
+// Compress compresses data with the given compression function
+func Compress(compressionFunction string, data []byte) ([]byte, error) {
+	switch compressionFunction {
+	case "", CompressionNone:
+		return data, nil
+	case CompressionZLIB:
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZIP:
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		f, err := w.Create("hbci")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("Unsupported compression function: %q", compressionFunction)
+	}
+}
+
+// Decompress decompresses data compressed with the given compression
+// function
+func Decompress(compressionFunction string, data []byte) ([]byte, error) {
+	if compressionFunction == "" || compressionFunction == CompressionNone {
+		return data, nil
+	}
+	decompress, ok := decompressors[compressionFunction]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported compression function: %q", compressionFunction)
+	}
+	decompressed, err := decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("Error while decompressing message: %v", err)
+	}
+	return decompressed, nil
+}
+
+// decompressZLIB decompresses deflate compressed data. Institutes use the
+// zlib as well as the gzip format or send the raw deflate stream, so the
+// format is detected by the header.
+func decompressZLIB(data []byte) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	switch {
+	case len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b:
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case len(data) >= 2 && data[0]&0x0f == 8 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0:
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readDecompressed(r)
+}
+
+func decompressZIP(data []byte) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	if len(archive.File) != 1 {
+		return nil, fmt.Errorf("Expected one file in ZIP archive, got %d", len(archive.File))
+	}
+	r, err := archive.File[0].Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readDecompressed(r)
+}
+
+func decompressBZIP2(data []byte) ([]byte, error) {
+	return readDecompressed(bzip2.NewReader(bytes.NewReader(data)))
+}
+
+// readDecompressed reads all data from r. It returns an error if the data
+// exceed the maxDecompressedSize.
+func readDecompressed(r io.Reader) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDecompressedSize {
+		return nil, fmt.Errorf("Decompressed message exceeds %d bytes", maxDecompressedSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,111 @@
+package message
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSelectCompressionFunction(t *testing.T) {
+	tests := []struct {
+		institute []string
+		expected  string
+	}{
+		{nil, CompressionNone},
+		{[]string{CompressionLZW, CompressionBZIP2}, CompressionNone},
+		{[]string{CompressionZIP}, CompressionZIP},
+		{[]string{CompressionZIP, CompressionZLIB}, CompressionZLIB},
+	}
+
+	for _, test := range tests {
+		actual := SelectCompressionFunction(test.institute)
+		if actual != test.expected {
+			t.Logf("Expected compression function for %v to equal %q, got %q\n", test.institute, test.expected, actual)
+			t.Fail()
+		}
+	}
+}
+
+func TestCompressDecompress(t *testing.T) {
+	message := []byte("HNSHK:2:3+PIN:1+999+1+1+1+2::clientSystemID+1+1:20150811:120000+1:999:1+6:10:16+280:1:userID:S:0:0'HKSAL:3:5+1234567::280:1+N'HNSHA:4:1+1+1+12345'")
+
+	for _, fn := range append([]string{CompressionNone}, SupportedCompressionFunctions...) {
+		compressed, err := Compress(fn, message)
+		if err != nil {
+			t.Fatalf("%s: Expected no error, got %T:%v\n", fn, err, err)
+		}
+		decompressed, err := Decompress(fn, compressed)
+		if err != nil {
+			t.Fatalf("%s: Expected no error, got %T:%v\n", fn, err, err)
+		}
+		if !bytes.Equal(message, decompressed) {
+			t.Logf("%s: Expected decompressed message to equal\n%q\n\tgot\n%q\n", fn, message, decompressed)
+			t.Fail()
+		}
+	}
+
+	_, err := Compress(CompressionLZHuf, message)
+	if err == nil {
+		t.Logf("Expected error for unsupported compression function, got nil\n")
+		t.Fail()
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	message := []byte("HIRMG:2:2:1+0100::Dialog beendet'")
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write(message)
+	gzipWriter.Close()
+
+	var deflated bytes.Buffer
+	flateWriter, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	flateWriter.Write(message)
+	flateWriter.Close()
+
+	bzipped, _ := hex.DecodeString("425a6839314159265359281675540000089f804088701004e2100036a584002000222680d0340c42800c469a68d38d3094c844082d08066d833f1af5119162c3f177245385090281675540")
+
+	tests := []struct {
+		description         string
+		compressionFunction string
+		data                []byte
+	}{
+		{"gzip format", CompressionZLIB, gzipped.Bytes()},
+		{"raw deflate", CompressionZLIB, deflated.Bytes()},
+		{"bzip2", CompressionBZIP2, bzipped},
+	}
+
+	for _, test := range tests {
+		decompressed, err := Decompress(test.compressionFunction, test.data)
+		if err != nil {
+			t.Logf("%s: Expected no error, got %T:%v\n", test.description, err, err)
+			t.Fail()
+			continue
+		}
+		if !bytes.Equal(message, decompressed) {
+			t.Logf("%s: Expected decompressed message to equal\n%q\n\tgot\n%q\n", test.description, message, decompressed)
+			t.Fail()
+		}
+	}
+}
+
+func TestDecompressExceedingMaxSize(t *testing.T) {
+	message := make([]byte, maxDecompressedSize+1)
+
+	for _, fn := range SupportedCompressionFunctions {
+		compressed, err := Compress(fn, message)
+		if err != nil {
+			t.Fatalf("%s: Expected no error, got %T:%v\n", fn, err, err)
+		}
+
+		_, err = Decompress(fn, compressed)
+
+		if err == nil {
+			t.Logf("%s: Expected error, got nil\n", fn)
+			t.Fail()
+		}
+	}
+}
@@ -15,6 +15,7 @@ type CryptoProvider interface {
 	Encrypt(message []byte) ([]byte, error)
 	Decrypt(encryptedMessage []byte) ([]byte, error)
 	WriteEncryptionHeader(header segment.EncryptionHeader)
+	ReadEncryptionHeader(header segment.EncryptionHeader) error
 }
 
 // NewPinTanCryptoProvider creates a new CryptoProvider for the pin key
@@ -41,7 +42,7 @@ func (p *pinTanCryptoProvider) SetSecurityFunction(securityFn string) {
 }
 
 func (p *pinTanCryptoProvider) Encrypt(message []byte) ([]byte, error) {
-	if p.key.Pin() == "" {
+	if !p.key.HasPin() {
 		return nil, fmt.Errorf("Malformed PIN")
 	}
 	return p.key.Encrypt(message)
@@ -57,3 +58,100 @@ func (p *pinTanCryptoProvider) WriteEncryptionHeader(header segment.EncryptionHe
 	header.SetEncryptionKeyName(p.key.KeyName())
 	header.SetEncryptionAlgorithm(element.NewPinTanEncryptionAlgorithm())
 }
+
+func (p *pinTanCryptoProvider) ReadEncryptionHeader(header segment.EncryptionHeader) error {
+	return nil
+}
+
+// NewRDHCryptoProvider creates a new CryptoProvider for the RDH flow using
+// the algorithms of profile. Messages are encrypted for recipientKey, i.e.
+// the public encryption key of the bank. Received messages are decrypted
+// with decryptionKey, the encryption key of the user.
+func NewRDHCryptoProvider(recipientKey *domain.RSAKey, decryptionKey *domain.RSAKey, clientSystemID string, profile RDHProfile) CryptoProvider {
+	return &rdhCryptoProvider{
+		recipientKey:   recipientKey,
+		decryptionKey:  decryptionKey,
+		clientSystemID: clientSystemID,
+		securityFn:     "4",
+		profile:        profile,
+	}
+}
+
+type rdhCryptoProvider struct {
+	recipientKey        *domain.RSAKey
+	decryptionKey       *domain.RSAKey
+	clientSystemID      string
+	securityFn          string
+	profile             RDHProfile
+	encryptedMessageKey []byte
+	receivedMessageKey  []byte
+}
+
+func (r *rdhCryptoProvider) SetClientSystemID(clientSystemID string) {
+	r.clientSystemID = clientSystemID
+}
+
+func (r *rdhCryptoProvider) SetSecurityFunction(securityFn string) {
+	r.securityFn = securityFn
+}
+
+// Encrypt encrypts message with a newly generated message key. The message
+// key itself is encrypted with the public key of the recipient and written
+// to the EncryptionHeader.
+func (r *rdhCryptoProvider) Encrypt(message []byte) ([]byte, error) {
+	if r.recipientKey == nil || !r.recipientKey.CanEncrypt() {
+		return nil, fmt.Errorf("Missing public encryption key of the recipient")
+	}
+	messageKey, err := generateMessageKey(r.profile.messageKeySize())
+	if err != nil {
+		return nil, fmt.Errorf("Error while generating message key: %v", err)
+	}
+	encryptedMessage, err := encryptCBC(r.profile.EncryptionAlgorithm, messageKey, message)
+	if err != nil {
+		return nil, err
+	}
+	encryptedMessageKey, err := r.profile.encryptMessageKey(r.recipientKey, messageKey)
+	if err != nil {
+		return nil, fmt.Errorf("Error while encrypting message key: %v", err)
+	}
+	r.encryptedMessageKey = encryptedMessageKey
+	return encryptedMessage, nil
+}
+
+// Decrypt decrypts encryptedMessage with the message key read from the
+// EncryptionHeader of the message.
+func (r *rdhCryptoProvider) Decrypt(encryptedMessage []byte) ([]byte, error) {
+	if r.receivedMessageKey == nil {
+		return nil, fmt.Errorf("Missing message key")
+	}
+	return decryptCBC(r.profile.EncryptionAlgorithm, r.receivedMessageKey, encryptedMessage)
+}
+
+func (r *rdhCryptoProvider) WriteEncryptionHeader(header segment.EncryptionHeader) {
+	header.SetSecurityFunction(r.securityFn)
+	header.SetSecurityMethod(r.profile.SecurityMethod, r.profile.Version)
+	header.SetClientSystemID(r.clientSystemID)
+	if r.recipientKey != nil {
+		header.SetEncryptionKeyName(r.recipientKey.KeyName())
+	}
+	header.SetEncryptionAlgorithm(element.NewEncryptionAlgorithm(r.profile.EncryptionAlgorithm, r.encryptedMessageKey))
+}
+
+// ReadEncryptionHeader decrypts the message key from header with the
+// decryption key.
+func (r *rdhCryptoProvider) ReadEncryptionHeader(header segment.EncryptionHeader) error {
+	r.receivedMessageKey = nil
+	encryptedMessageKey := header.EncryptedMessageKey()
+	if len(encryptedMessageKey) == 0 {
+		return fmt.Errorf("Malformed encryption header: missing message key")
+	}
+	if r.decryptionKey == nil || r.decryptionKey.PublicKey == nil {
+		return fmt.Errorf("Missing decryption key")
+	}
+	messageKey, err := r.profile.decryptMessageKey(r.decryptionKey, encryptedMessageKey)
+	if err != nil {
+		return fmt.Errorf("Error while decrypting message key: %v", err)
+	}
+	r.receivedMessageKey = messageKey
+	return nil
+}
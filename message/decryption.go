@@ -1,6 +1,7 @@
 package message
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/mitch000001/go-hbci/domain"
@@ -47,6 +48,7 @@ type decryptedMessage struct {
 	acknowledgements []domain.Acknowledgement
 	unmarshaler      *Unmarshaler
 	hbciVersion      segment.HBCIVersion
+	signatureStatus  SignatureStatus
 }
 
 // MarshalHBCI marshals d to HBCI wire format
@@ -125,3 +127,100 @@ func (d *decryptedMessage) SupportedSegments() []segment.VersionedSegment {
 	}
 	return versionedSegments
 }
+
+func (d *decryptedMessage) SignatureStatus() SignatureStatus {
+	return d.signatureStatus
+}
+
+func (d *decryptedMessage) VerifySignature(verifier SignatureVerifier) error {
+	header, end, signedData, err := d.extractSignature()
+	if err == errMissingSignature {
+		d.signatureStatus = SignatureMissing
+		return err
+	}
+	if err != nil {
+		d.signatureStatus = SignatureInvalid
+		return err
+	}
+	if header.ControlReference() != end.ControlReference() {
+		d.signatureStatus = SignatureInvalid
+		return fmt.Errorf("Control references of signature header and end differ: %q != %q", header.ControlReference(), end.ControlReference())
+	}
+	err = verifier.Verify(header.SigningKeyName(), signedData, end.SignatureValue())
+	if err != nil {
+		d.signatureStatus = SignatureInvalid
+		return fmt.Errorf("Invalid signature: %v", err)
+	}
+	d.signatureStatus = SignatureValid
+	return nil
+}
+
+// errMissingSignature is returned by extractSignature if the message
+// contains no signature header
+var errMissingSignature = fmt.Errorf("Missing signature")
+
+// extractSignature returns the first signature header and the following
+// signature end of the message. The signed data reach from the start of the
+// signature header to the start of the signature end. It returns an error if
+// any data segment lies outside the signed data, as only the message header
+// and end and the encryption segments may precede or follow the signature.
+func (d *decryptedMessage) extractSignature() (*segment.SignatureHeaderSegment, *segment.SignatureEndSegment, []byte, error) {
+	var signedData []byte
+	var header *segment.SignatureHeaderSegment
+	var end *segment.SignatureEndSegment
+	var unsignedSegment []byte
+	for _, seg := range d.unmarshaler.MarshaledSegments() {
+		switch {
+		case header == nil && bytes.HasPrefix(seg, []byte("HNSHK:")):
+			header = &segment.SignatureHeaderSegment{}
+			if err := header.UnmarshalHBCI(seg); err != nil {
+				return nil, nil, nil, fmt.Errorf("Malformed signature header: %v", err)
+			}
+			signedData = append(signedData, seg...)
+		case header == nil:
+			if unsignedSegment == nil && !hasSegmentID(seg, "HNHBK", "HNVSK", "HNVSD") {
+				unsignedSegment = seg
+			}
+		case end == nil && bytes.HasPrefix(seg, []byte("HNSHA:")):
+			end = &segment.SignatureEndSegment{}
+			if err := end.UnmarshalHBCI(seg); err != nil {
+				return nil, nil, nil, fmt.Errorf("Malformed signature end: %v", err)
+			}
+		case end == nil:
+			signedData = append(signedData, seg...)
+		default:
+			if unsignedSegment == nil && !hasSegmentID(seg, "HNHBS") {
+				unsignedSegment = seg
+			}
+		}
+	}
+	if header == nil {
+		return nil, nil, nil, errMissingSignature
+	}
+	if end == nil {
+		return nil, nil, nil, fmt.Errorf("Missing signature end")
+	}
+	if unsignedSegment != nil {
+		return nil, nil, nil, fmt.Errorf("Unsigned segment outside the signature: %q", segmentID(unsignedSegment))
+	}
+	return header, end, signedData, nil
+}
+
+// hasSegmentID returns true if the marshaled segment seg has one of the
+// given IDs
+func hasSegmentID(seg []byte, ids ...string) bool {
+	for _, id := range ids {
+		if bytes.HasPrefix(seg, []byte(id+":")) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentID returns the ID of the marshaled segment seg
+func segmentID(seg []byte) string {
+	if idx := bytes.IndexByte(seg, ':'); idx >= 0 {
+		return string(seg[:idx])
+	}
+	return string(seg)
+}
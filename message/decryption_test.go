@@ -0,0 +1,94 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/segment"
+)
+
+func TestDecryptedMessageVerifySignature(t *testing.T) {
+	key, err := domain.GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	keyName := domain.NewInitialKeyName(280, "10000000", "10000000", "S").NextVersion()
+	bankKey := domain.NewRSAKey(key, keyName)
+	publicBankKey := domain.NewRSAKey(domain.NewRSAPublicKey("S", key.Modulus, key.Exponent), keyName)
+	otherKeyName := keyName.NextVersion()
+	otherBankKeyName := domain.NewInitialKeyName(280, "20000000", "10000000", "S").NextVersion()
+	otherUserKeyName := domain.NewInitialKeyName(280, "10000000", "20000000", "S").NextVersion()
+	body := "HIRMG:3:2+0010::Nachricht entgegengenommen'"
+
+	signedMessage := func(t *testing.T, profile RDHProfile, hbciVersion segment.HBCIVersion) string {
+		provider := NewRDHSignatureProvider(bankKey, 1, profile)
+		header := hbciVersion.SignatureHeader()
+		provider.WriteSignatureHeader(header)
+		header.SetNumber(func() int { return 2 })
+		signedData := header.String() + body
+		signature, err := provider.Sign([]byte(signedData))
+		if err != nil {
+			t.Fatalf("Expected no error, got %T:%v\n", err, err)
+		}
+		end := hbciVersion.SignatureEnd()
+		provider.WriteSignature(end, signature)
+		end.SetNumber(func() int { return 4 })
+		marshaledEnd, err := end.MarshalHBCI()
+		if err != nil {
+			t.Fatalf("Expected no error, got %T:%v\n", err, err)
+		}
+		return signedData + string(marshaledEnd)
+	}
+
+	tests := []struct {
+		profile     RDHProfile
+		hbciVersion segment.HBCIVersion
+	}{
+		{RDH2, segment.HBCI220},
+		{RDH10, segment.FINTS300},
+		{RAH10, segment.FINTS300},
+	}
+	for _, test := range tests {
+		t.Run(test.profile.String(), func(t *testing.T) {
+			signed := signedMessage(t, test.profile, test.hbciVersion)
+			cases := []struct {
+				description    string
+				rawMessage     string
+				verifierKey    *domain.RSAKey
+				expectedStatus SignatureStatus
+			}{
+				{"valid signature", signed, publicBankKey, SignatureValid},
+				{"modified message", strings.Replace(signed, "0010", "0020", 1), publicBankKey, SignatureInvalid},
+				{"unknown key", signed, domain.NewRSAKey(publicBankKey.PublicKey, otherKeyName), SignatureInvalid},
+				{"key of other bank", signed, domain.NewRSAKey(publicBankKey.PublicKey, otherBankKeyName), SignatureInvalid},
+				{"key of other user", signed, domain.NewRSAKey(publicBankKey.PublicKey, otherUserKeyName), SignatureInvalid},
+				{"missing signature", body, publicBankKey, SignatureMissing},
+				{"segment before signature", "HIRMS:1:2:1+0010::Injiziert'" + signed, publicBankKey, SignatureInvalid},
+				{"segment after signature", signed + "HIRMS:5:2:1+0010::Injiziert'", publicBankKey, SignatureInvalid},
+			}
+			for _, c := range cases {
+				header := segment.NewMessageHeaderSegment(1, test.hbciVersion.Version(), "abcde", 1)
+				bankMessage, err := NewDecryptedMessage(header, nil, []byte(c.rawMessage))
+				if err != nil {
+					t.Fatalf("%s: Expected no error, got %T:%v\n", c.description, err, err)
+				}
+
+				err = bankMessage.VerifySignature(NewRDHSignatureVerifier(c.verifierKey, test.profile))
+
+				if c.expectedStatus == SignatureValid && err != nil {
+					t.Logf("%s: Expected no error, got %T:%v\n", c.description, err, err)
+					t.Fail()
+				}
+				if c.expectedStatus != SignatureValid && err == nil {
+					t.Logf("%s: Expected error, got nil\n", c.description)
+					t.Fail()
+				}
+				if bankMessage.SignatureStatus() != c.expectedStatus {
+					t.Logf("%s: Expected signature status %s, got %s\n", c.description, c.expectedStatus, bankMessage.SignatureStatus())
+					t.Fail()
+				}
+			}
+		})
+	}
+}
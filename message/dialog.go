@@ -19,6 +19,8 @@ type DialogInitializationClientMessage struct {
 	PublicSigningKeyRequest    *segment.PublicKeyRequestSegment
 	PublicEncryptionKeyRequest *segment.PublicKeyRequestSegment
 	PublicKeyRequest           *segment.PublicKeyRequestSegment
+	PublicSigningKeyRenewal    *segment.PublicKeyRenewalSegment
+	PublicEncryptionKeyRenewal *segment.PublicKeyRenewalSegment
 	hbciVersion                segment.HBCIVersion
 }
 
@@ -35,6 +37,8 @@ func (d *DialogInitializationClientMessage) HBCISegments() []segment.ClientSegme
 		d.PublicSigningKeyRequest,
 		d.PublicEncryptionKeyRequest,
 		d.PublicKeyRequest,
+		d.PublicSigningKeyRenewal,
+		d.PublicEncryptionKeyRenewal,
 	}
 }
 
@@ -44,6 +48,8 @@ func (d *DialogInitializationClientMessage) jobs() []segment.Segment {
 		d.ProcessingPreparation,
 		d.PublicSigningKeyRequest,
 		d.PublicEncryptionKeyRequest,
+		d.PublicSigningKeyRenewal,
+		d.PublicEncryptionKeyRenewal,
 	}
 }
 
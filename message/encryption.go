@@ -1,16 +1,24 @@
 package message
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
 	"crypto/rand"
+	"fmt"
 
+	"github.com/mitch000001/go-hbci/element"
 	"github.com/mitch000001/go-hbci/segment"
 )
 
-const encryptionInitializationVector = "\x00\x00\x00\x00\x00\x00\x00\x00"
-
 // GenerateMessageKey generates a random key with 16 bytes
 func GenerateMessageKey() ([]byte, error) {
-	b := make([]byte, 16)
+	return generateMessageKey(16)
+}
+
+// generateMessageKey generates a random key with size bytes
+func generateMessageKey(size int) ([]byte, error) {
+	b := make([]byte, size)
 	_, err := rand.Read(b)
 	if err != nil {
 		return nil, err
@@ -18,6 +26,68 @@ func GenerateMessageKey() ([]byte, error) {
 	return b, nil
 }
 
+// newMessageCipher returns the block cipher for the encryption algorithm
+// code and messageKey. For 2-Key-Triple-DES, messageKey has 16 bytes, for
+// AES-256 32 bytes.
+func newMessageCipher(algorithm string, messageKey []byte) (cipher.Block, error) {
+	switch algorithm {
+	case element.EncryptionAlgorithmTripleDES:
+		if len(messageKey) != 16 {
+			return nil, fmt.Errorf("Malformed message key: expected 16 bytes, got %d", len(messageKey))
+		}
+		key := make([]byte, 0, 24)
+		key = append(key, messageKey...)
+		key = append(key, messageKey[:8]...)
+		return des.NewTripleDESCipher(key)
+	case element.EncryptionAlgorithmAES256:
+		if len(messageKey) != 32 {
+			return nil, fmt.Errorf("Malformed message key: expected 32 bytes, got %d", len(messageKey))
+		}
+		return aes.NewCipher(messageKey)
+	default:
+		return nil, fmt.Errorf("Unsupported encryption algorithm: %q", algorithm)
+	}
+}
+
+// encryptCBC encrypts plainMessage with messageKey using the algorithm in CBC
+// mode and an initialization vector of zeros. The message is padded as
+// defined by ANSI X9.23, i.e. with zeros and the number of padding bytes as
+// last byte.
+func encryptCBC(algorithm string, messageKey []byte, plainMessage []byte) ([]byte, error) {
+	block, err := newMessageCipher(algorithm, messageKey)
+	if err != nil {
+		return nil, err
+	}
+	padding := block.BlockSize() - len(plainMessage)%block.BlockSize()
+	paddedMessage := make([]byte, len(plainMessage)+padding)
+	copy(paddedMessage, plainMessage)
+	paddedMessage[len(paddedMessage)-1] = byte(padding)
+	encryptedMessage := make([]byte, len(paddedMessage))
+	iv := make([]byte, block.BlockSize())
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encryptedMessage, paddedMessage)
+	return encryptedMessage, nil
+}
+
+// decryptCBC decrypts encryptedMessage with messageKey using the algorithm
+// in CBC mode and removes the ANSI X9.23 padding.
+func decryptCBC(algorithm string, messageKey []byte, encryptedMessage []byte) ([]byte, error) {
+	block, err := newMessageCipher(algorithm, messageKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(encryptedMessage) == 0 || len(encryptedMessage)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("Malformed encrypted message: length %d is not a multiple of the block size", len(encryptedMessage))
+	}
+	decryptedMessage := make([]byte, len(encryptedMessage))
+	iv := make([]byte, block.BlockSize())
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decryptedMessage, encryptedMessage)
+	padding := int(decryptedMessage[len(decryptedMessage)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return nil, fmt.Errorf("Malformed encrypted message: invalid padding")
+	}
+	return decryptedMessage[:len(decryptedMessage)-padding], nil
+}
+
 // NewEncryptedMessage creates a new encrypted message
 func NewEncryptedMessage(header *segment.MessageHeaderSegment, end *segment.MessageEndSegment, hbciVersion segment.HBCIVersion) *EncryptedMessage {
 	e := &EncryptedMessage{
@@ -48,12 +118,46 @@ func (e *EncryptedMessage) HBCISegments() []segment.ClientSegment {
 	}
 }
 
-// Decrypt decrypts the message using the CryptoProvider
+// encrypt compresses the plain message with compressionFunction, encrypts it
+// and sets it as EncryptedData. The EncryptionHeader is written by the
+// provider after encrypting, as it may carry the message key used. The
+// compression function is recorded in the EncryptionHeader as well.
+func (e *EncryptedMessage) encrypt(provider CryptoProvider, plainMessage []byte, compressionFunction string) error {
+	compressedMessage, err := Compress(compressionFunction, plainMessage)
+	if err != nil {
+		return err
+	}
+	encryptedMessage, err := provider.Encrypt(compressedMessage)
+	if err != nil {
+		return err
+	}
+	provider.WriteEncryptionHeader(e.EncryptionHeader)
+	if compressionFunction != "" {
+		e.EncryptionHeader.SetCompressionFunction(compressionFunction)
+	}
+	e.EncryptedData = segment.NewEncryptedDataSegment(encryptedMessage)
+	return nil
+}
+
+// Decrypt decrypts the message using the CryptoProvider. If the
+// EncryptionHeader announces a compression function, the decrypted message
+// is decompressed.
 func (e *EncryptedMessage) Decrypt(provider CryptoProvider) (BankMessage, error) {
+	if e.EncryptionHeader != nil {
+		if err := provider.ReadEncryptionHeader(e.EncryptionHeader); err != nil {
+			return nil, err
+		}
+	}
 	decryptedMessageBytes, err := provider.Decrypt(e.EncryptedData.Data.Val())
 	if err != nil {
 		return nil, err
 	}
+	if e.EncryptionHeader != nil {
+		decryptedMessageBytes, err = Decompress(e.EncryptionHeader.CompressionFunctionCode(), decryptedMessageBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
 	decryptedMessage, err := NewDecryptedMessage(e.MessageHeader(), e.MessageEnd(), decryptedMessageBytes)
 	if err != nil {
 		return nil, err
@@ -1,6 +1,7 @@
 package message
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 
@@ -40,3 +41,180 @@ func TestEncryptedPinTanMessageDecrypt(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestEncryptedPinTanMessageCompressed(t *testing.T) {
+	keyName := domain.NewPinTanKeyName(domain.BankID{CountryCode: 280, ID: "1"}, "userID", "V")
+	pinKey := domain.NewPinKey("abcde", keyName)
+
+	provider := NewPinTanCryptoProvider(pinKey, "clientSystemID")
+
+	body := "HIRMG:2:2:1+0100::Dialog beendet'HISYN:3:3:8+newClientSystemID'"
+
+	header := segment.NewMessageHeaderSegment(1, 220, "abcde", 1)
+	end := segment.NewMessageEndSegment(4, 1)
+	encryptedMessage := NewEncryptedMessage(header, end, segment.HBCI220)
+	encryptedMessage.EncryptionHeader = segment.HBCI220.PinTanEncryptionHeader("0", *keyName)
+	provider.WriteEncryptionHeader(encryptedMessage.EncryptionHeader)
+
+	err := encryptedMessage.encrypt(provider, []byte(body), CompressionZLIB)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if code := encryptedMessage.EncryptionHeader.CompressionFunctionCode(); code != CompressionZLIB {
+		t.Logf("Expected encryption header to announce compression function %q, got %q\n", CompressionZLIB, code)
+		t.Fail()
+	}
+	if data := encryptedMessage.EncryptedData.Data.Val(); string(data) == body {
+		t.Logf("Expected encrypted data to be compressed\n")
+		t.Fail()
+	}
+
+	decryptedMessage, err := encryptedMessage.Decrypt(provider)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	syncSegment := decryptedMessage.FindMarshaledSegment("HISYN")
+	if string(syncSegment) != "HISYN:3:3:8+newClientSystemID'" {
+		t.Logf("Expected decrypted message to include SynchronisationResponse, got %q\n", syncSegment)
+		t.Fail()
+	}
+}
+
+func TestRDHCryptoProvider(t *testing.T) {
+	bankID := domain.BankID{CountryCode: 280, ID: "10000000"}
+	bankKey, err := domain.GenerateEncryptionKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	bankKeyName := domain.NewInitialKeyName(bankID.CountryCode, bankID.ID, "10000000", "V")
+	clientKey, err := domain.GenerateEncryptionKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	clientKeyName := domain.NewInitialKeyName(bankID.CountryCode, bankID.ID, "userID", "V")
+
+	tests := []struct {
+		profile      RDHProfile
+		hbciVersion  segment.HBCIVersion
+		blockSize    int
+		headerPrefix string
+		algorithm    string
+	}{
+		{RDH2, segment.HBCI220, 8, "HNVSK:998:2+4+1+1::clientSystemID+", "+2:2:13:@256@"},
+		{RDH10, segment.FINTS300, 16, "HNVSK:998:3+RDH:10+4+1+1::clientSystemID+", "+2:2:14:@256@"},
+		{RAH10, segment.FINTS300, 16, "HNVSK:998:3+RAH:10+4+1+1::clientSystemID+", "+2:2:14:@256@"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.profile.String(), func(t *testing.T) {
+			clientProvider := NewRDHCryptoProvider(
+				domain.NewRSAKey(domain.NewEncryptionKey(bankKey.Modulus, bankKey.Exponent), bankKeyName),
+				domain.NewRSAKey(clientKey, clientKeyName),
+				"clientSystemID",
+				test.profile,
+			)
+			bankProvider := NewRDHCryptoProvider(
+				domain.NewRSAKey(domain.NewEncryptionKey(clientKey.Modulus, clientKey.Exponent), clientKeyName),
+				domain.NewRSAKey(bankKey, bankKeyName),
+				"0",
+				test.profile,
+			)
+
+			body := "HKIDN:3:2+280:10000000+userID+clientSystemID+1'HKVVB:4:2+0+0+0+123+1.0'"
+
+			header := segment.NewMessageHeaderSegment(1, test.hbciVersion.Version(), "abcde", 1)
+			end := segment.NewMessageEndSegment(4, 1)
+			encryptedMessage := NewEncryptedMessage(header, end, test.hbciVersion)
+			encryptedMessage.EncryptionHeader = test.hbciVersion.PinTanEncryptionHeader("", domain.KeyName{})
+
+			err = encryptedMessage.encrypt(clientProvider, []byte(body), CompressionNone)
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			if data := encryptedMessage.EncryptedData.Data.Val(); len(data)%test.blockSize != 0 || bytes.Contains(data, []byte("HKIDN")) {
+				t.Logf("Expected data to be encrypted, got %q\n", data)
+				t.Fail()
+			}
+
+			marshaledHeader, err := encryptedMessage.EncryptionHeader.MarshalHBCI()
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+			receivedHeader := &segment.EncryptionHeaderSegment{}
+			err = receivedHeader.UnmarshalHBCI(marshaledHeader)
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			if !bytes.HasPrefix(marshaledHeader, []byte(test.headerPrefix)) {
+				t.Logf("Expected encryption header with security function ENC, got %q\n", marshaledHeader)
+				t.Fail()
+			}
+			if !bytes.Contains(marshaledHeader, []byte("+280:10000000:10000000:V:999:999+")) {
+				t.Logf("Expected encryption header to contain the key name of the bank, got %q\n", marshaledHeader)
+				t.Fail()
+			}
+			if !bytes.Contains(marshaledHeader, []byte(test.algorithm)) {
+				t.Logf("Expected encryption header to contain algorithm %q, got %q\n", test.algorithm, marshaledHeader)
+				t.Fail()
+			}
+			if key := receivedHeader.EncryptedMessageKey(); len(key) != domain.RDHKeySize/8 {
+				t.Logf("Expected encrypted message key of length %d, got %d\n", domain.RDHKeySize/8, len(key))
+				t.Fail()
+			}
+
+			err = bankProvider.ReadEncryptionHeader(receivedHeader)
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+			decryptedBody, err := bankProvider.Decrypt(encryptedMessage.EncryptedData.Data.Val())
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			if string(decryptedBody) != body {
+				t.Logf("Expected bank to decrypt\n%q\ngot\n%q\n", body, decryptedBody)
+				t.Fail()
+			}
+
+			responseBody := "HIRMG:2:2:1+0100::Dialog beendet'HISYN:3:3:8+newClientSystemID'"
+			response := NewEncryptedMessage(header, end, test.hbciVersion)
+			response.EncryptionHeader = test.hbciVersion.PinTanEncryptionHeader("", domain.KeyName{})
+
+			err = response.encrypt(bankProvider, []byte(responseBody), CompressionNone)
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			decryptedMessage, err := response.Decrypt(clientProvider)
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			syncSegment := decryptedMessage.FindMarshaledSegment("HISYN")
+			if string(syncSegment) != "HISYN:3:3:8+newClientSystemID'" {
+				t.Logf("Expected decrypted message to include SynchronisationResponse, got %q\n", syncSegment)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestRDHCryptoProviderWithoutBankKey(t *testing.T) {
+	clientKey, err := domain.GenerateEncryptionKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	keyName := domain.NewInitialKeyName(280, "10000000", "userID", "V")
+	provider := NewRDHCryptoProvider(nil, domain.NewRSAKey(clientKey, keyName), "0", RDH2)
+
+	_, err = provider.Encrypt([]byte("HKIDN:3:2+280:10000000+userID+0+0'"))
+
+	if err == nil {
+		t.Logf("Expected error, got nil\n")
+		t.Fail()
+	}
+}
@@ -0,0 +1,115 @@
+package message
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/segment"
+)
+
+// envelopeSize is the space reserved for the message header and end, the
+// signature and the encryption when spreading jobs across messages
+const envelopeSize = 2048
+
+// A LimitError is returned if a message exceeds a limit of the bank
+// parameter data
+type LimitError struct {
+	// Limit names the exceeded limit
+	Limit string
+	// Value is the value of the message
+	Value int
+	// Max is the limit of the institute
+	Max int
+}
+
+func (l *LimitError) Error() string {
+	return fmt.Sprintf("Message exceeds the %s of the institute: %d > %d", l.Limit, l.Value, l.Max)
+}
+
+// maxMessageSize returns the MaxMessageSize of bpd in bytes. It returns zero
+// if the message size is not limited.
+func maxMessageSize(bpd domain.BankParameterData) int {
+	return bpd.MaxMessageSize * 1024
+}
+
+// CheckLimits returns a *LimitError if the marshaled message exceeds the
+// MaxMessageSize or the message contains more jobs than
+// MaxTransactionsPerMessage. Limits not provided by the institute are not
+// checked.
+func CheckLimits(bpd domain.BankParameterData, hbciMessage HBCIMessage, marshaledMessage []byte) error {
+	if max := maxMessageSize(bpd); max > 0 && len(marshaledMessage) > max {
+		return &LimitError{Limit: "maximum message size", Value: len(marshaledMessage), Max: max}
+	}
+	if max := bpd.MaxTransactionsPerMessage; max > 0 && hbciMessage != nil {
+		if jobs := countJobs(hbciMessage.HBCISegments()); jobs > max {
+			return &LimitError{Limit: "maximum number of transactions per message", Value: jobs, Max: max}
+		}
+	}
+	return nil
+}
+
+// administrativeSegments contains the IDs of segments managing the dialog,
+// the keys and TANs. They are no business transactions and thus do not count
+// against MaxTransactionsPerMessage.
+var administrativeSegments = map[string]bool{
+	"HKIDN": true,
+	"HKVVB": true,
+	"HKSYN": true,
+	"HKEND": true,
+	"HKTAN": true,
+	"HKISA": true,
+	"HKSAK": true,
+	"HKSSP": true,
+}
+
+// countJobs returns the number of business transaction segments within
+// segments
+func countJobs(segments []segment.ClientSegment) int {
+	jobs := 0
+	for _, seg := range segments {
+		if reflect.ValueOf(seg).IsNil() || administrativeSegments[seg.Header().ID.Val()] {
+			continue
+		}
+		jobs++
+	}
+	return jobs
+}
+
+// SplitJobs spreads jobs across as few messages as possible without
+// exceeding the MaxTransactionsPerMessage and the MaxMessageSize of the bank
+// parameter data. The order of the jobs is kept. It returns a *LimitError if
+// a single job exceeds the message size.
+func SplitJobs(bpd domain.BankParameterData, jobs []segment.ClientSegment) ([][]segment.ClientSegment, error) {
+	maxJobs := bpd.MaxTransactionsPerMessage
+	maxSize := maxMessageSize(bpd)
+	var batches [][]segment.ClientSegment
+	var batch []segment.ClientSegment
+	batchSize := 0
+	for _, job := range jobs {
+		jobSize := 0
+		if maxSize > 0 {
+			marshaledJob, err := job.MarshalHBCI()
+			if err != nil {
+				return nil, err
+			}
+			jobSize = len(marshaledJob)
+			if jobSize+envelopeSize > maxSize {
+				return nil, &LimitError{Limit: "maximum message size", Value: jobSize + envelopeSize, Max: maxSize}
+			}
+		}
+		full := maxJobs > 0 && len(batch) == maxJobs
+		tooLarge := maxSize > 0 && batchSize+jobSize+envelopeSize > maxSize
+		if len(batch) > 0 && (full || tooLarge) {
+			batches = append(batches, batch)
+			batch = nil
+			batchSize = 0
+		}
+		batch = append(batch, job)
+		batchSize += jobSize
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches, nil
+}
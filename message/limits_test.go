@@ -0,0 +1,114 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/segment"
+)
+
+func TestCheckLimits(t *testing.T) {
+	account := domain.AccountConnection{AccountID: "100000000", CountryCode: 280, BankID: "10000000"}
+	hbciMessage := NewHBCIMessage(segment.FINTS300,
+		segment.NewAccountBalanceRequestV5(account, false),
+		segment.NewAccountBalanceRequestV5(account, false),
+	)
+
+	tests := []struct {
+		description string
+		bpd         domain.BankParameterData
+		size        int
+		err         bool
+	}{
+		{"no limits", domain.BankParameterData{}, 4096, false},
+		{"within limits", domain.BankParameterData{MaxTransactionsPerMessage: 2, MaxMessageSize: 4}, 4096, false},
+		{"too many jobs", domain.BankParameterData{MaxTransactionsPerMessage: 1}, 100, true},
+		{"too large", domain.BankParameterData{MaxMessageSize: 1}, 1025, true},
+	}
+
+	for _, test := range tests {
+		err := CheckLimits(test.bpd, hbciMessage, []byte(strings.Repeat("x", test.size)))
+		if _, ok := err.(*LimitError); test.err && !ok {
+			t.Logf("%s: Expected LimitError, got %T:%v\n", test.description, err, err)
+			t.Fail()
+		}
+		if !test.err && err != nil {
+			t.Logf("%s: Expected no error, got %T:%v\n", test.description, err, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestCheckLimitsCountsOnlyBusinessTransactions(t *testing.T) {
+	account := domain.AccountConnection{AccountID: "100000000", CountryCode: 280, BankID: "10000000"}
+	hbciMessage := NewHBCIMessage(segment.FINTS300,
+		segment.NewAccountBalanceRequestV5(account, false),
+		segment.NewIdentificationSegment(domain.BankID{CountryCode: 280, ID: "10000000"}, "12345", "xyz", false),
+		segment.NewSynchronisationSegmentV3(segment.SyncModeAquireClientID),
+	)
+	bpd := domain.BankParameterData{MaxTransactionsPerMessage: 1}
+
+	err := CheckLimits(bpd, hbciMessage, nil)
+
+	if err != nil {
+		t.Logf("Expected no error, got %T:%v\n", err, err)
+		t.Fail()
+	}
+}
+
+func TestSplitJobs(t *testing.T) {
+	var jobs []segment.ClientSegment
+	for _, accountID := range []string{"1", "2", "3", "4", "5"} {
+		account := domain.AccountConnection{AccountID: accountID, CountryCode: 280, BankID: "10000000"}
+		jobs = append(jobs, segment.NewAccountBalanceRequestV5(account, false))
+	}
+
+	tests := []struct {
+		description string
+		bpd         domain.BankParameterData
+		batchSizes  []int
+	}{
+		{"no limits", domain.BankParameterData{}, []int{5}},
+		{"transactions per message", domain.BankParameterData{MaxTransactionsPerMessage: 2}, []int{2, 2, 1}},
+		{"message size", domain.BankParameterData{MaxMessageSize: 3}, []int{5}},
+	}
+
+	for _, test := range tests {
+		batches, err := SplitJobs(test.bpd, jobs)
+		if err != nil {
+			t.Fatalf("%s: Expected no error, got %T:%v\n", test.description, err, err)
+		}
+		var batchSizes []int
+		var ordered []segment.ClientSegment
+		for _, batch := range batches {
+			batchSizes = append(batchSizes, len(batch))
+			ordered = append(ordered, batch...)
+		}
+		if len(batchSizes) != len(test.batchSizes) {
+			t.Logf("%s: Expected batch sizes %v, got %v\n", test.description, test.batchSizes, batchSizes)
+			t.Fail()
+			continue
+		}
+		for i := range batchSizes {
+			if batchSizes[i] != test.batchSizes[i] {
+				t.Logf("%s: Expected batch sizes %v, got %v\n", test.description, test.batchSizes, batchSizes)
+				t.Fail()
+				break
+			}
+		}
+		for i := range jobs {
+			if ordered[i] != jobs[i] {
+				t.Logf("%s: Expected jobs to keep their order\n", test.description)
+				t.Fail()
+				break
+			}
+		}
+	}
+
+	_, err := SplitJobs(domain.BankParameterData{MaxMessageSize: 1}, jobs)
+	if _, ok := err.(*LimitError); !ok {
+		t.Logf("Expected LimitError for job exceeding the message size, got %T:%v\n", err, err)
+		t.Fail()
+	}
+}
@@ -25,6 +25,7 @@ type ClientMessage interface {
 	Message
 	MarshalHBCI() ([]byte, error)
 	Encrypt(provider CryptoProvider) (*EncryptedMessage, error)
+	EncryptCompressed(provider CryptoProvider, compressionFunction string) (*EncryptedMessage, error)
 	SetMessageNumber(messageNumber int)
 }
 
@@ -33,6 +34,11 @@ type BankMessage interface {
 	Message
 	Acknowledgements() []domain.Acknowledgement
 	SupportedSegments() []segment.VersionedSegment
+	// VerifySignature verifies the signature of the bank with verifier. It
+	// returns an error if the signature is missing or invalid.
+	VerifySignature(verifier SignatureVerifier) error
+	// SignatureStatus returns the result of the last VerifySignature call
+	SignatureStatus() SignatureStatus
 }
 
 // HBCIMessage represents a basic set of message for introspecting HBCI messages
@@ -257,6 +263,12 @@ func (b *BasicMessage) Sign(provider SignatureProvider) (*BasicSignedMessage, er
 
 // Encrypt encrypts the message using the CryptoProvider
 func (b *BasicMessage) Encrypt(provider CryptoProvider) (*EncryptedMessage, error) {
+	return b.EncryptCompressed(provider, CompressionNone)
+}
+
+// EncryptCompressed compresses the message with compressionFunction and
+// encrypts it using the CryptoProvider
+func (b *BasicMessage) EncryptCompressed(provider CryptoProvider, compressionFunction string) (*EncryptedMessage, error) {
 	if b.HBCIMessage == nil {
 		return nil, fmt.Errorf("HBCIMessage must be set")
 	}
@@ -284,14 +296,11 @@ func (b *BasicMessage) Encrypt(provider CryptoProvider) (*EncryptedMessage, erro
 		}
 		messageBytes = append(messageBytes, sigEndBytes...)
 	}
-	encryptedMessage, err := provider.Encrypt(messageBytes)
-	if err != nil {
-		return nil, err
-	}
 	encryptionMessage := NewEncryptedMessage(b.Header, b.End, b.hbciVersion)
 	encryptionMessage.EncryptionHeader = b.hbciVersion.PinTanEncryptionHeader("", domain.KeyName{})
-	provider.WriteEncryptionHeader(encryptionMessage.EncryptionHeader)
-	encryptionMessage.EncryptedData = segment.NewEncryptedDataSegment(encryptedMessage)
+	if err := encryptionMessage.encrypt(provider, messageBytes, compressionFunction); err != nil {
+		return nil, err
+	}
 	return encryptionMessage, nil
 }
 
@@ -411,6 +420,12 @@ func (b *BasicSignedMessage) Encrypt(provider CryptoProvider) (*EncryptedMessage
 	return b.message.Encrypt(provider)
 }
 
+// EncryptCompressed compresses the message with compressionFunction and
+// encrypts it using the CryptoProvider
+func (b *BasicSignedMessage) EncryptCompressed(provider CryptoProvider, compressionFunction string) (*EncryptedMessage, error) {
+	return b.message.EncryptCompressed(provider, compressionFunction)
+}
+
 type bankMessage interface {
 	dataSegments() []segment.Segment
 }
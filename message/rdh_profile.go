@@ -0,0 +1,206 @@
+package message
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+
+	hbcicrypto "github.com/mitch000001/go-hbci/crypto"
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/element"
+)
+
+// An RDHProfile defines the algorithms used by a RDH or RAH security profile
+// to sign and encrypt messages
+type RDHProfile struct {
+	// SecurityMethod is the code of the security method, "RDH" or "RAH"
+	SecurityMethod string
+	// Version is the version of the security method
+	Version int
+	// HashAlgorithm is the code of the hash algorithm used for signatures
+	HashAlgorithm string
+	// SignatureMode is the operation mode of the RSA signature
+	SignatureMode string
+	// EncryptionMode is the operation mode of the RSA encryption of the
+	// message key
+	EncryptionMode string
+	// EncryptionAlgorithm is the code of the symmetric algorithm used to
+	// encrypt messages
+	EncryptionAlgorithm string
+}
+
+var (
+	// RDH2 signs RIPEMD-160 hashes with ISO 9796-1 and encrypts messages
+	// with 2-Key-Triple-DES. The message key is encrypted with plain RSA.
+	RDH2 = RDHProfile{
+		SecurityMethod:      "RDH",
+		Version:             2,
+		HashAlgorithm:       element.HashAlgorithmRIPEMD160,
+		SignatureMode:       element.SignatureModeISO9796d1,
+		EncryptionMode:      element.EncryptionModeISO9796d1,
+		EncryptionAlgorithm: element.EncryptionAlgorithmTripleDES,
+	}
+	// RDH10 signs SHA-256 hashes with RSASSA-PKCS#1 V1.5 and encrypts
+	// messages with AES-256. The message key is encrypted with
+	// RSAES-PKCS#1 V1.5.
+	RDH10 = RDHProfile{
+		SecurityMethod:      "RDH",
+		Version:             10,
+		HashAlgorithm:       element.HashAlgorithmSHA256,
+		SignatureMode:       element.SignatureModePKCS1,
+		EncryptionMode:      element.EncryptionModePKCS1,
+		EncryptionAlgorithm: element.EncryptionAlgorithmAES256,
+	}
+	// RAH10 signs SHA-256 hashes with RSASSA-PSS and encrypts messages with
+	// AES-256. The message key is encrypted with RSAES-PKCS#1 V1.5.
+	RAH10 = RDHProfile{
+		SecurityMethod:      "RAH",
+		Version:             10,
+		HashAlgorithm:       element.HashAlgorithmSHA256,
+		SignatureMode:       element.SignatureModePSS,
+		EncryptionMode:      element.EncryptionModePKCS1,
+		EncryptionAlgorithm: element.EncryptionAlgorithmAES256,
+	}
+)
+
+// rdhProfiles contains all supported profiles, ordered by preference
+var rdhProfiles = []RDHProfile{RAH10, RDH10, RDH2}
+
+// SelectRDHProfile returns the preferred profile out of the security methods
+// offered by an institute, as provided within HISHV. It returns an error if
+// the institute offers none of the supported profiles.
+func SelectRDHProfile(securityMethods []domain.SecurityMethod) (RDHProfile, error) {
+	for _, profile := range rdhProfiles {
+		for _, method := range securityMethods {
+			if method.Code != profile.SecurityMethod {
+				continue
+			}
+			for _, version := range method.Versions {
+				if version == profile.Version {
+					return profile, nil
+				}
+			}
+		}
+	}
+	return RDHProfile{}, fmt.Errorf("No supported RDH or RAH profile offered: %v", securityMethods)
+}
+
+// String returns the name of the profile, e.g. "RDH-10"
+func (r RDHProfile) String() string {
+	return fmt.Sprintf("%s-%d", r.SecurityMethod, r.Version)
+}
+
+// keyHashFieldSize is the length of the exponent and the modulus within the
+// key hash, unless the modulus is longer
+const keyHashFieldSize = 256
+
+// KeyHash returns the hash of key as printed on the INI letter. Exponent and
+// modulus are padded with leading zeros to 256 bytes each and hashed with
+// the hash algorithm of the profile.
+func (r RDHProfile) KeyHash(key *domain.PublicKey) []byte {
+	size := keyHashFieldSize
+	if len(key.Modulus) > size {
+		size = len(key.Modulus)
+	}
+	data := make([]byte, 2*size)
+	copy(data[size-len(key.Exponent):size], key.Exponent)
+	copy(data[2*size-len(key.Modulus):], key.Modulus)
+	return r.hash(data)
+}
+
+// hash returns the hash sum of message
+func (r RDHProfile) hash(message []byte) []byte {
+	if r.HashAlgorithm == element.HashAlgorithmSHA256 {
+		sum := sha256.Sum256(message)
+		return sum[:]
+	}
+	return HashSum(string(message))
+}
+
+// sign signs message with key
+func (r RDHProfile) sign(key *domain.RSAKey, message []byte) ([]byte, error) {
+	if key == nil || !key.CanSign() {
+		return nil, fmt.Errorf("Missing private signing key")
+	}
+	hash := r.hash(message)
+	switch r.SignatureMode {
+	case element.SignatureModeISO9796d1:
+		return key.Sign(hash)
+	case element.SignatureModePKCS1:
+		return rsa.SignPKCS1v15(nil, key.SigningKey(), crypto.SHA256, hash)
+	case element.SignatureModePSS:
+		return rsa.SignPSS(rand.Reader, key.SigningKey(), crypto.SHA256, hash, &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+		})
+	default:
+		return nil, fmt.Errorf("Unsupported signature mode: %q", r.SignatureMode)
+	}
+}
+
+// verify verifies that signature was created over message with the private
+// part of key
+func (r RDHProfile) verify(key *domain.RSAKey, message, signature []byte) error {
+	if key == nil || key.EncryptionKey() == nil {
+		return fmt.Errorf("Missing public signing key")
+	}
+	hash := r.hash(message)
+	switch r.SignatureMode {
+	case element.SignatureModeISO9796d1:
+		recoveredHash, err := hbcicrypto.RecoverISO9796d1(signature, key.EncryptionKey())
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(hash, recoveredHash) {
+			return fmt.Errorf("Signature does not match the message")
+		}
+		return nil
+	case element.SignatureModePKCS1:
+		return rsa.VerifyPKCS1v15(key.EncryptionKey(), crypto.SHA256, hash, signature)
+	case element.SignatureModePSS:
+		return rsa.VerifyPSS(key.EncryptionKey(), crypto.SHA256, hash, signature, &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthAuto,
+		})
+	default:
+		return fmt.Errorf("Unsupported signature mode: %q", r.SignatureMode)
+	}
+}
+
+// messageKeySize returns the size of the symmetric message key in bytes
+func (r RDHProfile) messageKeySize() int {
+	if r.EncryptionAlgorithm == element.EncryptionAlgorithmAES256 {
+		return 32
+	}
+	return 16
+}
+
+// encryptMessageKey encrypts messageKey with the public key of the
+// recipient. Triple-DES keys are padded with zeros and encrypted with plain
+// RSA, all other keys with RSAES-PKCS#1 V1.5.
+func (r RDHProfile) encryptMessageKey(recipientKey *domain.RSAKey, messageKey []byte) ([]byte, error) {
+	if r.EncryptionAlgorithm == element.EncryptionAlgorithmTripleDES {
+		return recipientKey.Encrypt(messageKey)
+	}
+	return rsa.EncryptPKCS1v15(rand.Reader, recipientKey.EncryptionKey(), messageKey)
+}
+
+// decryptMessageKey decrypts the encryptedMessageKey with the private
+// decryptionKey
+func (r RDHProfile) decryptMessageKey(decryptionKey *domain.RSAKey, encryptedMessageKey []byte) ([]byte, error) {
+	var messageKey []byte
+	var err error
+	if r.EncryptionAlgorithm == element.EncryptionAlgorithmTripleDES {
+		messageKey, err = hbcicrypto.DecryptZeroPadded(encryptedMessageKey, decryptionKey.DecryptionKey())
+	} else {
+		messageKey, err = rsa.DecryptPKCS1v15(rand.Reader, decryptionKey.DecryptionKey(), encryptedMessageKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(messageKey) < r.messageKeySize() {
+		return nil, fmt.Errorf("Message key too short")
+	}
+	return messageKey[len(messageKey)-r.messageKeySize():], nil
+}
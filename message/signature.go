@@ -1,13 +1,14 @@
 package message
 
 import (
-	"crypto/rand"
 	"crypto/rsa"
 	"fmt"
 	"hash/crc32"
 	"io"
 
+	hbcicrypto "github.com/mitch000001/go-hbci/crypto"
 	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/element"
 	"github.com/mitch000001/go-hbci/segment"
 	"golang.org/x/crypto/ripemd160"
 )
@@ -21,7 +22,7 @@ type SignatureProvider interface {
 	WriteSignature(end segment.SignatureEnd, signature []byte)
 }
 
-// HashSum calculates the riemd160 hash sum of message
+// HashSum calculates the RIPEMD-160 hash sum of message
 func HashSum(message string) []byte {
 	h := ripemd160.New()
 	//io.WriteString(h, initializationVector)
@@ -29,9 +30,10 @@ func HashSum(message string) []byte {
 	return h.Sum(nil)
 }
 
-// SignMessageHash signs the messageHash with key
+// SignMessageHash signs the messageHash with key as defined for RDH-2, i.e.
+// with ISO 9796-1 padding
 func SignMessageHash(messageHash []byte, key *rsa.PrivateKey) ([]byte, error) {
-	return rsa.SignPKCS1v15(rand.Reader, key, 0, messageHash)
+	return hbcicrypto.SignISO9796d1(messageHash, key)
 }
 
 func generateControlReference(key domain.Key) string {
@@ -84,52 +86,133 @@ func (p *pinTanSignatureProvider) WriteSignatureHeader(header segment.SignatureH
 	header.SetSignatureID(0)
 }
 
+// WriteSignature writes the PIN returned by Sign as signature
 func (p *pinTanSignatureProvider) WriteSignature(end segment.SignatureEnd, signature []byte) {
-	end.SetPinTan(p.key.Pin(), "")
+	end.SetPinTan(string(signature), "")
 	end.SetControlReference(p.controlReference)
 }
 
-// NewRDHSignatureProvider creates a new SignatureProvider for the given signingKey
-func NewRDHSignatureProvider(signingKey *domain.RSAKey, signatureID int) SignatureProvider {
+// NewRDHSignatureProvider creates a new SignatureProvider for the given
+// signingKey using the algorithms of profile. signatureID is the ID of the
+// first signature.
+func NewRDHSignatureProvider(signingKey *domain.RSAKey, signatureID int, profile RDHProfile) *RDHSignatureProvider {
 	controlReference := generateControlReference(signingKey)
-	return &rdhSignatureProvider{
+	return &RDHSignatureProvider{
 		signingKey:       signingKey,
 		controlReference: controlReference,
 		signatureID:      signatureID,
 		securityFn:       "1",
+		profile:          profile,
 	}
 }
 
-type rdhSignatureProvider struct {
+// RDHSignatureProvider signs messages with a RSA key. As the bank rejects
+// signature IDs used before, the signature ID is increased by one with every
+// signature header written.
+type RDHSignatureProvider struct {
 	signingKey       *domain.RSAKey
 	clientSystemID   string
 	controlReference string
 	securityFn       string
 	signatureID      int
+	profile          RDHProfile
 }
 
-func (r *rdhSignatureProvider) SetClientSystemID(clientSystemID string) {
+func (r *RDHSignatureProvider) SetClientSystemID(clientSystemID string) {
 	r.clientSystemID = clientSystemID
 }
 
-func (r *rdhSignatureProvider) SetSecurityFunction(securityFn string) {
+func (r *RDHSignatureProvider) SetSecurityFunction(securityFn string) {
 	r.securityFn = securityFn
 }
 
-func (r *rdhSignatureProvider) Sign(message []byte) ([]byte, error) {
-	hashSum := HashSum(string(message))
-	return r.signingKey.Sign(hashSum)
+// SignatureID returns the signature ID used for the next signature
+func (r *RDHSignatureProvider) SignatureID() int {
+	return r.signatureID
 }
 
-func (r *rdhSignatureProvider) WriteSignatureHeader(header segment.SignatureHeader) {
+// SetSignatureID sets the signature ID used for the next signature
+func (r *RDHSignatureProvider) SetSignatureID(signatureID int) {
+	r.signatureID = signatureID
+}
+
+func (r *RDHSignatureProvider) Sign(message []byte) ([]byte, error) {
+	return r.profile.sign(r.signingKey, message)
+}
+
+func (r *RDHSignatureProvider) WriteSignatureHeader(header segment.SignatureHeader) {
 	header.SetSecurityFunction(r.securityFn)
+	header.SetSecurityMethod(r.profile.SecurityMethod, r.profile.Version)
+	header.SetHashAlgorithm(element.NewHashAlgorithm(r.profile.HashAlgorithm))
+	header.SetSignatureAlgorithm(element.NewSignatureAlgorithm(r.profile.SignatureMode))
 	header.SetClientSystemID(r.clientSystemID)
 	header.SetSigningKeyName(r.signingKey.KeyName())
 	header.SetSignatureID(r.signatureID)
 	header.SetControlReference(r.controlReference)
+	r.signatureID++
 }
 
-func (r *rdhSignatureProvider) WriteSignature(end segment.SignatureEnd, signature []byte) {
+func (r *RDHSignatureProvider) WriteSignature(end segment.SignatureEnd, signature []byte) {
 	end.SetSignature(signature)
 	end.SetControlReference(r.controlReference)
 }
+
+// A SignatureVerifier represents a verifier for the signatures of bank
+// messages
+type SignatureVerifier interface {
+	// Verify verifies that signature was created over message with the key
+	// named keyName
+	Verify(keyName domain.KeyName, message []byte, signature []byte) error
+}
+
+// NewRDHSignatureVerifier creates a SignatureVerifier for the public
+// signing key of the bank using the algorithms of profile
+func NewRDHSignatureVerifier(bankSigningKey *domain.RSAKey, profile RDHProfile) SignatureVerifier {
+	return &rdhSignatureVerifier{
+		bankSigningKey: bankSigningKey,
+		profile:        profile,
+	}
+}
+
+type rdhSignatureVerifier struct {
+	bankSigningKey *domain.RSAKey
+	profile        RDHProfile
+}
+
+func (r *rdhSignatureVerifier) Verify(keyName domain.KeyName, message []byte, signature []byte) error {
+	if keyName != r.bankSigningKey.KeyName() {
+		return fmt.Errorf("Signed with unknown key %s of %s at %d:%s (number %d, version %d)", keyName.KeyType, keyName.UserID, keyName.BankID.CountryCode, keyName.BankID.ID, keyName.KeyNumber, keyName.KeyVersion)
+	}
+	return r.profile.verify(r.bankSigningKey, message, signature)
+}
+
+// SignatureStatus describes the result of the signature verification of a
+// bank message
+type SignatureStatus int
+
+const (
+	// SignatureUnverified means the signature was not verified, e.g. for
+	// PIN/TAN messages or messages not yet checked
+	SignatureUnverified SignatureStatus = iota
+	// SignatureValid means the message was signed with the known key of the
+	// bank
+	SignatureValid
+	// SignatureInvalid means the signature does not match the message or
+	// was created with an unknown key
+	SignatureInvalid
+	// SignatureMissing means the message was not signed
+	SignatureMissing
+)
+
+func (s SignatureStatus) String() string {
+	switch s {
+	case SignatureValid:
+		return "valid"
+	case SignatureInvalid:
+		return "invalid"
+	case SignatureMissing:
+		return "missing"
+	default:
+		return "unverified"
+	}
+}
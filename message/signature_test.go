@@ -0,0 +1,281 @@
+package message
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	hbcicrypto "github.com/mitch000001/go-hbci/crypto"
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/segment"
+)
+
+func TestHashSum(t *testing.T) {
+	// test vectors from the RIPEMD-160 specification
+	tests := []struct {
+		message string
+		hash    string
+	}{
+		{"", "9c1185a5c5e9fc54612808977ee8f548b2258d31"},
+		{"a", "0bdc9d2d256b3ee9daae347be6f4dc835a467ffe"},
+		{"abc", "8eb208f7e05d987a9b044a8e98c6b087f15a0bfc"},
+		{"message digest", "5d0689ef49d2fae572b881b123a85ffa21595f36"},
+		{"abcdefghijklmnopqrstuvwxyz", "f71c27109c692c1b56bbdceb5b9d2865b3708dbc"},
+	}
+
+	for _, test := range tests {
+		hash := hex.EncodeToString(HashSum(test.message))
+
+		if test.hash != hash {
+			t.Logf("Expected hash of %q to equal %q, got %q\n", test.message, test.hash, hash)
+			t.Fail()
+		}
+	}
+}
+
+func TestRDHSignatureProviderSign(t *testing.T) {
+	key, err := domain.GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	keyName := domain.NewInitialKeyName(280, "10000000", "userID", "S")
+	provider := NewRDHSignatureProvider(domain.NewRSAKey(key, keyName), 1, RDH2)
+
+	message := "HNHBK:1:3+000000000123+220+0+1'HKIDN:3:2+280:10000000+userID+0+0'"
+
+	signature, err := provider.Sign([]byte(message))
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if len(signature) != domain.RDHKeySize/8 {
+		t.Logf("Expected signature to have length %d, got %d\n", domain.RDHKeySize/8, len(signature))
+		t.Fail()
+	}
+
+	recovered, err := hbcicrypto.RecoverISO9796d1(signature, &key.SigningKey().PublicKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	expected := HashSum(message)
+	if !reflect.DeepEqual(expected, recovered) {
+		t.Logf("Expected signature to contain hash\n%x\ngot\n%x\n", expected, recovered)
+		t.Fail()
+	}
+}
+
+func TestRDHSignatureProviderSignKnownAnswer(t *testing.T) {
+	// A fixed 768 bit key as used with RDH-2. The expected signature was
+	// computed with a separate implementation of ISO 9796-1 reproducing the
+	// examples of the standard.
+	fromHex := func(s string) *big.Int {
+		i, ok := new(big.Int).SetString(s, 16)
+		if !ok {
+			t.Fatalf("Malformed hex number %q\n", s)
+		}
+		return i
+	}
+	rsaKey := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{
+			N: fromHex("b66bfbe2eabc38492166ec2e223402d26e786d7daa55f1b0bf76e41f82e46e8248389966b7ca2b8748b816ab88832a93d64ff20f1561e40d58e31698015c330d61159b3741cc6d145efbb0d161d44b222f28d5183ef68cd0a07d712359e145a9"),
+			E: 65537,
+		},
+		D: fromHex("a3f35736314c2ab6274c0e56c42cc4a085e2bc5d25fb0a36954df06715c7e23e3b548b22536a6346ba27a1505767b39a16ded4b6fd395f5695adc11c0e3962e3771d9b1152d6b1c73fe0953ba856b3b4558274a999c52027b43130bff2536081"),
+		Primes: []*big.Int{
+			fromHex("c483a6ff17acf4c8fcdf90512cf823147efb2257dd3d4828fec0d25a91975ccdde24eb04a396d6415a316bc9f83290f9"),
+			fromHex("eda446460dfc81b4cf8064d9ad02fdff244f56e706788cb21a27da832667dd014453385932973840d716d94b71573631"),
+		},
+	}
+	keyName := domain.NewInitialKeyName(280, "10000000", "userID", "S")
+	provider := NewRDHSignatureProvider(domain.NewRSAKey(domain.NewPrivateKey("S", rsaKey), keyName), 1, RDH2)
+	message := "HNSHK:2:3+1+5381015+1+1+2::abc+1+1:20150811:120000+1:999:1+6:10:16+280:10000000:userID:S:1:1'HKIDN:3:2+280:10000000+userID+abc+1'HKVVB:4:2+0+0+0'"
+	expected := "55ae6e80ecbc11ff1b927c2bba8fce38d60f89bb16d46413aeafea6e0f9edffc608aaf63aeb2d37aa6b76eb547629f004677baad0fe516da8f11c1220704602c71092441ddafc90c5043a26ac98ac5d887e2fd7b9feb96a4e0ffc8306814cd41"
+
+	signature, err := provider.Sign([]byte(message))
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if actual := hex.EncodeToString(signature); actual != expected {
+		t.Logf("Expected signature to equal\n%s\ngot\n%s\n", expected, actual)
+		t.Fail()
+	}
+}
+
+func TestRDHSignatureProviderSignatureID(t *testing.T) {
+	key := domain.NewRSAPublicKey("S", []byte{0xFF}, []byte{0x01, 0x00, 0x01})
+	keyName := domain.NewInitialKeyName(280, "10000000", "userID", "S")
+	provider := NewRDHSignatureProvider(domain.NewRSAKey(key, keyName), 5, RDH10)
+
+	for _, expected := range []int{5, 6} {
+		header := segment.FINTS300.SignatureHeader()
+		provider.WriteSignatureHeader(header)
+
+		marshaled, err := header.MarshalHBCI()
+		if err != nil {
+			t.Fatalf("Expected no error, got %T:%v\n", err, err)
+		}
+		if !strings.Contains(string(marshaled), fmt.Sprintf("+%d+", expected)) {
+			t.Logf("Expected header to contain signature ID %d, got %q\n", expected, marshaled)
+			t.Fail()
+		}
+	}
+
+	if provider.SignatureID() != 7 {
+		t.Logf("Expected next signature ID to equal 7, got %d\n", provider.SignatureID())
+		t.Fail()
+	}
+
+	provider.SetSignatureID(42)
+
+	if provider.SignatureID() != 42 {
+		t.Logf("Expected next signature ID to equal 42, got %d\n", provider.SignatureID())
+		t.Fail()
+	}
+}
+
+func TestRDHSignatureProviderSignWithProfiles(t *testing.T) {
+	key, err := domain.GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	keyName := domain.NewInitialKeyName(280, "10000000", "userID", "S")
+	message := "HNHBK:1:3+000000000123+300+0+1'HKIDN:3:2+280:10000000+userID+0+0'"
+	hash := sha256.Sum256([]byte(message))
+	publicKey := &key.SigningKey().PublicKey
+
+	tests := []struct {
+		profile      RDHProfile
+		headerPrefix string
+		algorithms   string
+		verify       func(signature []byte) error
+	}{
+		{
+			RDH10,
+			"HNSHK:2:4+RDH:10+1+",
+			"+1:3:1+6:10:18+",
+			func(signature []byte) error {
+				return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature)
+			},
+		},
+		{
+			RAH10,
+			"HNSHK:2:4+RAH:10+1+",
+			"+1:3:1+6:10:19+",
+			func(signature []byte) error {
+				return rsa.VerifyPSS(publicKey, crypto.SHA256, hash[:], signature, nil)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.profile.String(), func(t *testing.T) {
+			provider := NewRDHSignatureProvider(domain.NewRSAKey(key, keyName), 1, test.profile)
+
+			signature, err := provider.Sign([]byte(message))
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			if err := test.verify(signature); err != nil {
+				t.Logf("Expected signature to verify, got %T:%v\n", err, err)
+				t.Fail()
+			}
+
+			header := segment.FINTS300.SignatureHeader()
+			provider.WriteSignatureHeader(header)
+			marshaled, err := header.MarshalHBCI()
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+			marshaledHeader := string(marshaled)
+
+			if !strings.HasPrefix(marshaledHeader, test.headerPrefix) {
+				t.Logf("Expected signature header to start with %q, got %q\n", test.headerPrefix, marshaledHeader)
+				t.Fail()
+			}
+			if !strings.Contains(marshaledHeader, test.algorithms) {
+				t.Logf("Expected signature header to contain algorithms %q, got %q\n", test.algorithms, marshaledHeader)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestSelectRDHProfile(t *testing.T) {
+	tests := []struct {
+		methods  []domain.SecurityMethod
+		expected RDHProfile
+		err      bool
+	}{
+		{
+			[]domain.SecurityMethod{{Code: "RDH", Versions: []int{2}}},
+			RDH2,
+			false,
+		},
+		{
+			[]domain.SecurityMethod{{Code: "RDH", Versions: []int{2, 10}}, {Code: "PIN", Versions: []int{1}}},
+			RDH10,
+			false,
+		},
+		{
+			[]domain.SecurityMethod{{Code: "RDH", Versions: []int{10}}, {Code: "RAH", Versions: []int{10}}},
+			RAH10,
+			false,
+		},
+		{
+			[]domain.SecurityMethod{{Code: "PIN", Versions: []int{1, 2}}, {Code: "RDH", Versions: []int{5}}},
+			RDHProfile{},
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		profile, err := SelectRDHProfile(test.methods)
+
+		if test.err && err == nil {
+			t.Logf("Expected error for %v, got nil\n", test.methods)
+			t.Fail()
+		}
+		if !test.err && err != nil {
+			t.Logf("Expected no error for %v, got %T:%v\n", test.methods, err, err)
+			t.Fail()
+		}
+		if profile != test.expected {
+			t.Logf("Expected profile %v for %v, got %v\n", test.expected, test.methods, profile)
+			t.Fail()
+		}
+	}
+}
+
+func TestRDHProfileKeyHash(t *testing.T) {
+	key := domain.NewRSAPublicKey("S", []byte{0xC1, 0x02, 0x03}, []byte{0x01, 0x00, 0x01})
+	paddedKey := make([]byte, 512)
+	copy(paddedKey[253:256], key.Exponent)
+	copy(paddedKey[509:], key.Modulus)
+	sha256Sum := sha256.Sum256(paddedKey)
+
+	tests := []struct {
+		profile  RDHProfile
+		expected []byte
+	}{
+		{RDH2, HashSum(string(paddedKey))},
+		{RDH10, sha256Sum[:]},
+		{RAH10, sha256Sum[:]},
+	}
+	for _, test := range tests {
+		actual := test.profile.KeyHash(key)
+
+		if !reflect.DeepEqual(test.expected, actual) {
+			t.Logf("%s: Expected key hash to equal\n% X\n\tgot\n% X\n", test.profile, test.expected, actual)
+			t.Fail()
+		}
+	}
+}
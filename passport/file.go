@@ -0,0 +1,189 @@
+package passport
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// formatVersion is the version of the file format
+	formatVersion = 1
+	kdfScrypt     = "scrypt"
+	cipherAESGCM  = "AES-256-GCM"
+	keySize       = 32
+	saltSize      = 16
+)
+
+// The scrypt parameters of passport files. Files contain their parameters,
+// but files with other parameters are rejected, so a modified file can
+// neither weaken the key derivation nor make it exhaust the memory.
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// envelope is the stored form of an encrypted passport
+type envelope struct {
+	Version int       `json:"version"`
+	KDF     kdfParams `json:"kdf"`
+	Cipher  string    `json:"cipher"`
+	Nonce   []byte    `json:"nonce"`
+	Data    []byte    `json:"data"`
+}
+
+type kdfParams struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// additionalData returns the data authenticated along with the encrypted
+// passport, so the parameters can't be changed unnoticed
+func (e envelope) additionalData() ([]byte, error) {
+	return json.Marshal(struct {
+		Version int       `json:"version"`
+		KDF     kdfParams `json:"kdf"`
+		Cipher  string    `json:"cipher"`
+	}{e.Version, e.KDF, e.Cipher})
+}
+
+// aead returns the cipher for the key derived from password
+func (e envelope) aead(password []byte) (cipher.AEAD, error) {
+	if e.KDF.Name != kdfScrypt {
+		return nil, fmt.Errorf("Unsupported key derivation function: %q", e.KDF.Name)
+	}
+	if e.KDF.N != scryptN || e.KDF.R != scryptR || e.KDF.P != scryptP {
+		return nil, fmt.Errorf("Unsupported key derivation parameters: N=%d, r=%d, p=%d", e.KDF.N, e.KDF.R, e.KDF.P)
+	}
+	if e.Cipher != cipherAESGCM {
+		return nil, fmt.Errorf("Unsupported cipher: %q", e.Cipher)
+	}
+	key, err := scrypt.Key(password, e.KDF.Salt, e.KDF.N, e.KDF.R, e.KDF.P, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Save writes the passport encrypted with password to w
+func (p *Passport) Save(w io.Writer, password []byte) error {
+	if len(password) == 0 {
+		return fmt.Errorf("Empty password")
+	}
+	data, err := p.marshal()
+	if err != nil {
+		return err
+	}
+	e := envelope{
+		Version: formatVersion,
+		KDF: kdfParams{
+			Name: kdfScrypt,
+			Salt: make([]byte, saltSize),
+			N:    scryptN,
+			R:    scryptR,
+			P:    scryptP,
+		},
+		Cipher: cipherAESGCM,
+	}
+	if _, err := rand.Read(e.KDF.Salt); err != nil {
+		return err
+	}
+	aead, err := e.aead(password)
+	if err != nil {
+		return err
+	}
+	e.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(e.Nonce); err != nil {
+		return err
+	}
+	additionalData, err := e.additionalData()
+	if err != nil {
+		return err
+	}
+	e.Data = aead.Seal(nil, e.Nonce, data, additionalData)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(e)
+}
+
+// Load reads a passport encrypted with password from r. It returns an error
+// if the password is wrong or the data were modified.
+func Load(r io.Reader, password []byte) (*Passport, error) {
+	var e envelope
+	if err := json.NewDecoder(r).Decode(&e); err != nil {
+		return nil, fmt.Errorf("Malformed passport: %v", err)
+	}
+	if e.Version != formatVersion {
+		return nil, fmt.Errorf("Unsupported passport version: %d", e.Version)
+	}
+	aead, err := e.aead(password)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("Malformed passport: invalid nonce")
+	}
+	additionalData, err := e.additionalData()
+	if err != nil {
+		return nil, err
+	}
+	data, err := aead.Open(nil, e.Nonce, e.Data, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("Wrong password or corrupted passport")
+	}
+	p := &Passport{}
+	if err := p.unmarshal(data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// LoadFile reads the passport stored at path, see Load
+func LoadFile(path string, password []byte) (*Passport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f, password)
+}
+
+// SaveFile writes the passport to path, see Save. The file is only readable
+// by the owner. An existing file is replaced only after the new one was
+// written completely, so the keys are never lost halfway.
+func (p *Passport) SaveFile(path string, password []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := f.Name()
+	defer os.Remove(tempPath)
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if err := p.Save(f, password); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
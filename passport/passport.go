@@ -0,0 +1,232 @@
+// Package passport provides a password protected file format to store the
+// keys and the session state of a RDH user.
+//
+// A passport contains the private keys of the user, the public keys of the
+// bank, the signature ID, the client system ID and the bank and user
+// parameter data. It is encrypted with AES-256-GCM using a key derived from
+// the password with scrypt.
+package passport
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mitch000001/go-hbci/dialog"
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/segment"
+	"github.com/mitch000001/go-hbci/transport"
+)
+
+// Passport contains everything needed to talk to a bank with the RDH
+// security profiles
+type Passport struct {
+	BankID domain.BankID
+	UserID string
+	// URL is the address of the bank given as "host" or "host:port"
+	URL         string
+	HBCIVersion int
+	// SigningKey and EncryptionKey are the private keys of the user
+	SigningKey    *domain.RSAKey
+	EncryptionKey *domain.RSAKey
+	// BankSigningKey and BankEncryptionKey are the public keys of the bank
+	BankSigningKey    *domain.RSAKey
+	BankEncryptionKey *domain.RSAKey
+	// BankKeysVerified is true if the user confirmed that the hashes of the
+	// bank keys match the INI letter of the bank
+	BankKeysVerified bool
+	// SignatureID is the ID of the next signature of the user
+	SignatureID       int
+	ClientSystemID    string
+	BankParameterData domain.BankParameterData
+	UserParameterData domain.UserParameterData
+	Accounts          []domain.AccountInformation
+}
+
+// NewDialog returns a dialog using the keys and the state of the passport.
+// The dialog rejects responses without valid signature of the bank. It
+// returns an error if the keys of the bank are not verified yet. The
+// passport should be updated with the dialog afterwards, see Update.
+func (p *Passport) NewDialog(dialogTransport transport.Transport) (*dialog.RDHDialog, error) {
+	if p.SigningKey == nil || p.EncryptionKey == nil || p.BankSigningKey == nil || p.BankEncryptionKey == nil {
+		return nil, fmt.Errorf("Missing keys: the keys of the user and of the bank are required")
+	}
+	if !p.BankKeysVerified {
+		return nil, fmt.Errorf("The keys of the bank are not verified")
+	}
+	hbciVersion, ok := segment.SupportedHBCIVersions[p.HBCIVersion]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported HBCI version. Supported versions are %v", domain.SupportedHBCIVersions)
+	}
+	d, err := dialog.NewRDHDialog(dialog.RDHConfig{
+		BankID:            p.BankID,
+		HBCIURL:           p.URL,
+		UserID:            p.UserID,
+		HBCIVersion:       hbciVersion,
+		SigningKey:        p.SigningKey,
+		EncryptionKey:     p.EncryptionKey,
+		BankSigningKey:    p.BankSigningKey,
+		BankEncryptionKey: p.BankEncryptionKey,
+		SignatureID:       p.SignatureID,
+		Transport:         dialogTransport,
+		RequireSignatures: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.BankParameterData = p.BankParameterData
+	d.UserParameterData = p.UserParameterData
+	d.Accounts = append(d.Accounts, p.Accounts...)
+	if p.ClientSystemID != "" {
+		d.SetClientSystemID(p.ClientSystemID)
+	}
+	return d, nil
+}
+
+// Update takes over the keys and the state of d, e.g. after changing the
+// keys or sending messages. The passport has to be saved afterwards.
+func (p *Passport) Update(d *dialog.RDHDialog) {
+	p.SigningKey = d.SigningKey()
+	p.EncryptionKey = d.EncryptionKey()
+	if key := d.BankSigningKey(); key != nil {
+		p.BankSigningKey = key
+	}
+	if key := d.BankEncryptionKey(); key != nil {
+		p.BankEncryptionKey = key
+	}
+	p.SignatureID = d.SignatureID()
+	p.ClientSystemID = d.ClientSystemID
+	p.BankParameterData = d.BankParameterData
+	p.UserParameterData = d.UserParameterData
+	p.Accounts = d.Accounts
+}
+
+// passportData is the stored form of a passport
+type passportData struct {
+	BankID            domain.BankID
+	UserID            string
+	URL               string
+	HBCIVersion       int
+	SigningKey        *privateKey
+	EncryptionKey     *privateKey
+	BankSigningKey    *publicKey
+	BankEncryptionKey *publicKey
+	BankKeysVerified  bool
+	SignatureID       int
+	ClientSystemID    string
+	BankParameterData domain.BankParameterData
+	UserParameterData domain.UserParameterData
+	Accounts          []domain.AccountInformation
+}
+
+// privateKey is the stored form of a private key of the user. The key is
+// stored PKCS#1 encoded.
+type privateKey struct {
+	KeyName    domain.KeyName
+	PrivateKey []byte
+}
+
+// publicKey is the stored form of a public key of the bank
+type publicKey struct {
+	KeyName  domain.KeyName
+	Modulus  []byte
+	Exponent []byte
+}
+
+func (p *Passport) marshal() ([]byte, error) {
+	data := passportData{
+		BankID:            p.BankID,
+		UserID:            p.UserID,
+		URL:               p.URL,
+		HBCIVersion:       p.HBCIVersion,
+		BankKeysVerified:  p.BankKeysVerified,
+		SignatureID:       p.SignatureID,
+		ClientSystemID:    p.ClientSystemID,
+		BankParameterData: p.BankParameterData,
+		UserParameterData: p.UserParameterData,
+		Accounts:          p.Accounts,
+	}
+	var err error
+	if data.SigningKey, err = marshalPrivateKey(p.SigningKey); err != nil {
+		return nil, err
+	}
+	if data.EncryptionKey, err = marshalPrivateKey(p.EncryptionKey); err != nil {
+		return nil, err
+	}
+	data.BankSigningKey = marshalPublicKey(p.BankSigningKey)
+	data.BankEncryptionKey = marshalPublicKey(p.BankEncryptionKey)
+	return json.Marshal(data)
+}
+
+func (p *Passport) unmarshal(value []byte) error {
+	var data passportData
+	if err := json.Unmarshal(value, &data); err != nil {
+		return fmt.Errorf("Malformed passport: %v", err)
+	}
+	*p = Passport{
+		BankID:            data.BankID,
+		UserID:            data.UserID,
+		URL:               data.URL,
+		HBCIVersion:       data.HBCIVersion,
+		BankKeysVerified:  data.BankKeysVerified,
+		SignatureID:       data.SignatureID,
+		ClientSystemID:    data.ClientSystemID,
+		BankParameterData: data.BankParameterData,
+		UserParameterData: data.UserParameterData,
+		Accounts:          data.Accounts,
+	}
+	var err error
+	if p.SigningKey, err = data.SigningKey.rsaKey(); err != nil {
+		return err
+	}
+	if p.EncryptionKey, err = data.EncryptionKey.rsaKey(); err != nil {
+		return err
+	}
+	p.BankSigningKey = data.BankSigningKey.rsaKey()
+	p.BankEncryptionKey = data.BankEncryptionKey.rsaKey()
+	return nil
+}
+
+func marshalPrivateKey(key *domain.RSAKey) (*privateKey, error) {
+	if key == nil {
+		return nil, nil
+	}
+	if key.SigningKey() == nil {
+		return nil, fmt.Errorf("Missing private key for key %s", key.KeyName().KeyType)
+	}
+	return &privateKey{
+		KeyName:    key.KeyName(),
+		PrivateKey: x509.MarshalPKCS1PrivateKey(key.SigningKey()),
+	}, nil
+}
+
+func (p *privateKey) rsaKey() (*domain.RSAKey, error) {
+	if p == nil {
+		return nil, nil
+	}
+	key, err := x509.ParsePKCS1PrivateKey(p.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("Malformed private key %s: %v", p.KeyName.KeyType, err)
+	}
+	keyName := p.KeyName
+	return domain.NewRSAKey(domain.NewPrivateKey(keyName.KeyType, key), &keyName), nil
+}
+
+func marshalPublicKey(key *domain.RSAKey) *publicKey {
+	if key == nil {
+		return nil
+	}
+	return &publicKey{
+		KeyName:  key.KeyName(),
+		Modulus:  key.Modulus,
+		Exponent: key.Exponent,
+	}
+}
+
+func (p *publicKey) rsaKey() *domain.RSAKey {
+	if p == nil {
+		return nil
+	}
+	keyName := p.KeyName
+	return domain.NewRSAKey(domain.NewRSAPublicKey(keyName.KeyType, p.Modulus, p.Exponent), &keyName)
+}
@@ -0,0 +1,224 @@
+package passport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mitch000001/go-hbci/domain"
+)
+
+func TestPassportSaveLoad(t *testing.T) {
+	passport := testPassport(t)
+	password := []byte("secret")
+
+	var buf bytes.Buffer
+	err := passport.Save(&buf, password)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if bytes.Contains(buf.Bytes(), []byte("12345")) {
+		t.Logf("Expected saved passport to be encrypted, got\n%s\n", buf.String())
+		t.Fail()
+	}
+
+	loaded, err := Load(bytes.NewReader(buf.Bytes()), password)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if loaded.SigningKey.SigningKey() == nil || loaded.EncryptionKey.DecryptionKey() == nil {
+		t.Fatalf("Expected private keys of the user to be loaded\n")
+	}
+	if !equalPassports(passport, loaded) {
+		t.Logf("Expected loaded passport to equal\n%#v\n\tgot\n%#v\n", passport, loaded)
+		t.Fail()
+	}
+	if loaded.BankEncryptionKey.EncryptionKey() == nil {
+		t.Logf("Expected public encryption key of the bank to be loaded\n")
+		t.Fail()
+	}
+}
+
+func TestLoadWrongPassword(t *testing.T) {
+	var buf bytes.Buffer
+	err := testPassport(t).Save(&buf, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	_, err = Load(&buf, []byte("wrong"))
+
+	if err == nil {
+		t.Logf("Expected error, got nil\n")
+		t.Fail()
+	}
+}
+
+func TestLoadModifiedPassport(t *testing.T) {
+	var buf bytes.Buffer
+	err := testPassport(t).Save(&buf, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	tests := map[string]func(e *envelope){
+		"modified data": func(e *envelope) {
+			e.Data[0] ^= 0xFF
+		},
+		"modified parameters": func(e *envelope) {
+			e.KDF.N = 1 << 14
+		},
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			var e envelope
+			if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+			modify(&e)
+			modified, err := json.Marshal(e)
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			_, err = Load(bytes.NewReader(modified), []byte("secret"))
+
+			if err == nil {
+				t.Logf("Expected error, got nil\n")
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestLoadUnsupportedKDFParameters(t *testing.T) {
+	var buf bytes.Buffer
+	err := testPassport(t).Save(&buf, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	tests := map[string]func(p *kdfParams){
+		"N":           func(p *kdfParams) { p.N = 2 },
+		"r":           func(p *kdfParams) { p.R = 1 },
+		"p":           func(p *kdfParams) { p.P = 1 << 10 },
+		"all at once": func(p *kdfParams) { p.N, p.R, p.P = 1<<30, 1, 1 },
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			var e envelope
+			if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+			modify(&e.KDF)
+			modified, err := json.Marshal(e)
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			_, err = Load(bytes.NewReader(modified), []byte("secret"))
+
+			if err == nil || !strings.Contains(err.Error(), "Unsupported key derivation parameters") {
+				t.Logf("Expected unsupported key derivation parameters error, got %v\n", err)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestPassportNewDialog(t *testing.T) {
+	p := testPassport(t)
+
+	d, err := p.NewDialog(nil)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if !d.RequiresSignatures() {
+		t.Logf("Expected dialog to require signatures\n")
+		t.Fail()
+	}
+
+	p.BankSigningKey = nil
+
+	_, err = p.NewDialog(nil)
+
+	if err == nil {
+		t.Logf("Expected error for missing bank signing key, got nil\n")
+		t.Fail()
+	}
+}
+
+func testPassport(t *testing.T) *Passport {
+	bankID := domain.BankID{CountryCode: 280, ID: "10000000"}
+	keyName := func(userID, keyType string, version int) *domain.KeyName {
+		return &domain.KeyName{BankID: bankID, UserID: userID, KeyType: keyType, KeyNumber: 1, KeyVersion: version}
+	}
+	privateKey := func() *rsa.PrivateKey {
+		key, err := rsa.GenerateKey(rand.Reader, 1024)
+		if err != nil {
+			t.Fatalf("Expected no error, got %T:%v\n", err, err)
+		}
+		return key
+	}
+	bankSigningKey := privateKey()
+	bankEncryptionKey := privateKey()
+	return &Passport{
+		BankID:            bankID,
+		UserID:            "12345",
+		URL:               "hbci.example.com",
+		HBCIVersion:       300,
+		SigningKey:        domain.NewRSAKey(domain.NewPrivateKey("S", privateKey()), keyName("12345", "S", 2)),
+		EncryptionKey:     domain.NewRSAKey(domain.NewPrivateKey("V", privateKey()), keyName("12345", "V", 2)),
+		BankSigningKey:    domain.NewRSAKey(domain.NewRSAPublicKey("S", bankSigningKey.N.Bytes(), []byte{0x01, 0x00, 0x01}), keyName("10000000", "S", 1)),
+		BankEncryptionKey: domain.NewRSAKey(domain.NewRSAPublicKey("V", bankEncryptionKey.N.Bytes(), []byte{0x01, 0x00, 0x01}), keyName("10000000", "V", 1)),
+		BankKeysVerified:  true,
+		SignatureID:       42,
+		ClientSystemID:    "ABCDEF",
+		BankParameterData: domain.BankParameterData{
+			Version:  12,
+			BankID:   bankID,
+			BankName: "Testbank",
+			SecurityMethods: []domain.SecurityMethod{
+				{Code: "RDH", Versions: []int{2, 10}},
+			},
+			BusinessTransactions: []domain.BusinessTransactionParameters{
+				{ID: "HKKAZ", Version: 5, MaxJobs: 1, MinSignatures: 1, AccountTransactions: &domain.AccountTransactionParameters{StorageDuration: 90}},
+			},
+		},
+		UserParameterData: domain.UserParameterData{
+			Version: 3,
+			UserID:  "12345",
+		},
+		Accounts: []domain.AccountInformation{
+			{
+				AccountConnection: domain.AccountConnection{AccountID: "100200300", CountryCode: 280, BankID: "10000000"},
+				UserID:            "12345",
+				Currency:          "EUR",
+			},
+		},
+	}
+}
+
+// equalPassports returns true if a and b are equal. The precomputed values
+// of private keys depend on how the key was created, so the private keys are
+// compared by value.
+func equalPassports(a, b *Passport) bool {
+	samePrivateKey := func(x, y *domain.RSAKey) bool {
+		if x == nil || y == nil {
+			return x == y
+		}
+		return x.KeyName() == y.KeyName() && x.SigningKey().Equal(y.SigningKey())
+	}
+	if !samePrivateKey(a.SigningKey, b.SigningKey) || !samePrivateKey(a.EncryptionKey, b.EncryptionKey) {
+		return false
+	}
+	x, y := *a, *b
+	x.SigningKey, x.EncryptionKey = nil, nil
+	y.SigningKey, y.EncryptionKey = nil, nil
+	return reflect.DeepEqual(x, y)
+}
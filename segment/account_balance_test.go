@@ -16,10 +16,10 @@ func TestAccountBalanceResponseSegmentUnmarshalHBCI(t *testing.T) {
 		AccountConnection:  element.NewAccountConnection(domain.AccountConnection{AccountID: "100000000", CountryCode: 280, BankID: "10000000"}),
 		AccountProductName: element.NewAlphaNumeric("Sichteinlagen", 35),
 		AccountCurrency:    element.NewCurrency("EUR"),
-		BookedBalance:      element.NewBalance(domain.Amount{Amount: 1000.15, Currency: "EUR"}, date, false),
-		EarmarkedBalance:   element.NewBalance(domain.Amount{Amount: 20, Currency: "EUR"}, date, false),
-		CreditLimit:        element.NewAmount(500, "EUR"),
-		AvailableAmount:    element.NewAmount(1499.85, "EUR"),
+		BookedBalance:      element.NewBalance(domain.Amount{Amount: domain.NewDecimal(100015, 2), Currency: "EUR"}, date, false),
+		EarmarkedBalance:   element.NewBalance(domain.Amount{Amount: domain.NewDecimalFromInt(20), Currency: "EUR"}, date, false),
+		CreditLimit:        element.NewAmount(domain.NewDecimalFromInt(500), "EUR"),
+		AvailableAmount:    element.NewAmount(domain.NewDecimal(149985, 2), "EUR"),
 	}
 	expectedSegment.Segment = NewReferencingBasicSegment(4, 3, expectedSegment)
 
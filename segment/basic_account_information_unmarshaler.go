@@ -279,23 +279,40 @@ func (a *AccountInformationV6) UnmarshalHBCI(value []byte) error {
 			return err
 		}
 	}
+	// The allowed business transactions are repeated and only followed by the
+	// account extensions
+	extensionsIdx := len(elements)
+	if extensionsIdx > 11 && !isAllowedBusinessTransaction(elements[extensionsIdx-1]) {
+		extensionsIdx--
+	}
 	if len(elements) > 10 && len(elements[10]) > 0 {
 		a.AllowedBusinessTransactions = &element.AllowedBusinessTransactionsDataElement{}
-		err = a.AllowedBusinessTransactions.UnmarshalHBCI(elements[10])
+		err = a.AllowedBusinessTransactions.UnmarshalHBCI(bytes.Join(elements[10:extensionsIdx], []byte("+")))
 		if err != nil {
 			return err
 		}
 	}
-	if len(elements) > 11 && len(elements[11]) > 0 {
+	if len(elements) > extensionsIdx && len(elements[extensionsIdx]) > 0 {
 		a.AccountExtensions = &element.AlphaNumericDataElement{}
-		if len(elements)+1 > 11 {
-			err = a.AccountExtensions.UnmarshalHBCI(bytes.Join(elements[11:], []byte("+")))
-		} else {
-			err = a.AccountExtensions.UnmarshalHBCI(elements[11])
-		}
+		err = a.AccountExtensions.UnmarshalHBCI(elements[extensionsIdx])
 		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// isAllowedBusinessTransaction returns true if value starts with a segment
+// ID followed by the number of needed signatures
+func isAllowedBusinessTransaction(value []byte) bool {
+	idx := bytes.IndexByte(value, ':')
+	if idx < 5 || idx > 6 {
+		return false
+	}
+	for _, b := range value[:idx] {
+		if b < 'A' || b > 'Z' {
+			return false
+		}
+	}
+	return true
+}
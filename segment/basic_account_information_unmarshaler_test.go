@@ -48,6 +48,29 @@ func TestAccountInformationSegmentUnmarshalHBCI(t *testing.T) {
 				return &AccountInformationSegment{v5}
 			},
 		},
+		{
+			desc:       "version 6 segment with multiple business transactions and extension",
+			rawSegment: "HIUPD:1:6:4+123456::280:10000000+DE89100000000123456+12345+1+EUR+Muster+Max+Sichteinlagen++HKSAL:1+HKKAZ:1+{umsltzt?:1}'",
+			expectedSegmentBuilder: func() *AccountInformationSegment {
+				v6 := &AccountInformationV6{
+					AccountConnection: element.NewAccountConnection(domain.AccountConnection{AccountID: "123456", CountryCode: 280, BankID: "10000000"}),
+					IBAN:              element.NewAlphaNumeric("DE89100000000123456", 34),
+					UserID:            element.NewIdentification("12345"),
+					AccountType:       element.NewNumber(1, 2),
+					AccountCurrency:   element.NewCurrency("EUR"),
+					Name1:             element.NewAlphaNumeric("Muster", 27),
+					Name2:             element.NewAlphaNumeric("Max", 27),
+					AccountProductID:  element.NewAlphaNumeric("Sichteinlagen", 30),
+					AllowedBusinessTransactions: element.NewAllowedBusinessTransactions(
+						domain.BusinessTransaction{ID: "HKSAL", NeededSignatures: 1},
+						domain.BusinessTransaction{ID: "HKKAZ", NeededSignatures: 1},
+					),
+					AccountExtensions: element.NewAlphaNumeric("{umsltzt:1}", 2048),
+				}
+				v6.Segment = NewReferencingBasicSegment(1, 4, v6)
+				return &AccountInformationSegment{v6}
+			},
+		},
 	}
 
 	for _, tt := range testCases {
@@ -3,6 +3,7 @@ package segment
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/mitch000001/go-hbci/charset"
 	"github.com/mitch000001/go-hbci/domain"
@@ -16,6 +17,9 @@ type BusinessTransactionParamsSegment struct {
 	MaxJobs       *element.NumberDataElement
 	MinSignatures *element.NumberDataElement
 	Params        element.DataElementGroup
+	// params contains the parameters of the job as unmarshaled according
+	// to the layout of the segment version
+	params domain.BusinessTransactionParameters
 }
 
 func (b *BusinessTransactionParamsSegment) Version() int         { return b.version }
@@ -41,7 +45,9 @@ func (b *BusinessTransactionParamsSegment) UnmarshalHBCI(value []byte) error {
 		return err
 	}
 	b.Segment = seg
-	if len(elements) < 4 {
+	b.id = seg.Header().ID.Val()
+	b.version = seg.Header().Version.Val()
+	if len(elements) < 3 {
 		return fmt.Errorf("%T: Malformed marshaled value", b)
 	}
 	maxJobs, err := strconv.Atoi(charset.ToUTF8(elements[1]))
@@ -54,9 +60,176 @@ func (b *BusinessTransactionParamsSegment) UnmarshalHBCI(value []byte) error {
 		return fmt.Errorf("%T: Malformed min signatures: %v", b, err)
 	}
 	b.MinSignatures = element.NewNumber(minSignatures, 2)
+	b.params = domain.BusinessTransactionParameters{
+		ID:            jobID(b.id),
+		Version:       b.version,
+		MaxJobs:       maxJobs,
+		MinSignatures: minSignatures,
+	}
+	layout, ok := jobParameterLayouts[VersionedSegment{b.id, b.version}]
+	if !ok {
+		return nil
+	}
+	rawParams := elements[3:]
+	if layout.securityClass && len(rawParams) > 0 {
+		securityClass := &element.NumberDataElement{}
+		err = securityClass.UnmarshalHBCI(rawParams[0])
+		if err != nil {
+			return fmt.Errorf("%T: Malformed security class: %v", b, err)
+		}
+		b.params.SecurityClass = securityClass.Val()
+		rawParams = rawParams[1:]
+	}
+	if layout.unmarshal == nil {
+		return nil
+	}
+	if len(rawParams) == 0 {
+		return fmt.Errorf("%T: Missing job specific parameters", b)
+	}
+	groupElements, err := element.ExtractElements(rawParams[0])
+	if err != nil {
+		return fmt.Errorf("%T: Malformed job specific parameters: %v", b, err)
+	}
+	err = layout.unmarshal(&b.params, groupElements)
+	if err != nil {
+		return fmt.Errorf("%T: Malformed job specific parameters: %v", b, err)
+	}
+	return nil
+}
+
+// BusinessTransactionParameters returns the parameters of the job the segment
+// describes. The security class and the job specific parameters are only
+// provided for segment versions with known layout.
+func (b *BusinessTransactionParamsSegment) BusinessTransactionParameters() domain.BusinessTransactionParameters {
+	return b.params
+}
+
+// A jobParameterLayout describes the data elements following the minimum
+// number of signatures within a parameter segment version
+type jobParameterLayout struct {
+	// securityClass is true if the job specific parameters are preceded by
+	// a security class, as introduced with FinTS 3.0
+	securityClass bool
+	// unmarshal sets the job specific parameters from the group data
+	// elements of the parameter data element. It is nil for jobs without
+	// specific parameters.
+	unmarshal func(params *domain.BusinessTransactionParameters, groupElements [][]byte) error
+}
+
+// jobParameterLayouts contains the layouts of the known parameter segment
+// versions. Parameter segments not listed only provide the maximum number of
+// jobs and the minimum number of signatures.
+var jobParameterLayouts = map[VersionedSegment]jobParameterLayout{
+	{"HIKAZS", 4}: {false, accountTransactionParameters(false, false)},
+	{"HIKAZS", 5}: {false, accountTransactionParameters(false, false)},
+	{"HIKAZS", 6}: {true, accountTransactionParameters(true, false)},
+	{"HIKAZS", 7}: {true, accountTransactionParameters(true, false)},
+	{"HICAZS", 1}: {true, accountTransactionParameters(true, true)},
+	{"HISALS", 5}: {false, nil},
+	{"HISALS", 6}: {true, nil},
+	{"HISALS", 7}: {true, nil},
+	{"HISPAS", 1}: {true, unmarshalSepaAccountInformationParameters},
+}
+
+// accountTransactionParameters returns a function unmarshaling the
+// parameters of account transaction jobs. Newer versions additionally
+// provide whether all accounts may be requested at once and the supported
+// camt formats.
+func accountTransactionParameters(allAccounts, formats bool) func(*domain.BusinessTransactionParameters, [][]byte) error {
+	return func(params *domain.BusinessTransactionParameters, groupElements [][]byte) error {
+		expected := 2
+		if allAccounts {
+			expected++
+		}
+		if len(groupElements) < expected || (!formats && len(groupElements) > expected) {
+			return fmt.Errorf("Expected %d account transaction parameters, got %d", expected, len(groupElements))
+		}
+		storageDuration := &element.NumberDataElement{}
+		err := storageDuration.UnmarshalHBCI(groupElements[0])
+		if err != nil {
+			return err
+		}
+		entryCountAllowed := &element.BooleanDataElement{}
+		err = entryCountAllowed.UnmarshalHBCI(groupElements[1])
+		if err != nil {
+			return err
+		}
+		accountTransactions := &domain.AccountTransactionParameters{
+			StorageDuration:   storageDuration.Val(),
+			EntryCountAllowed: entryCountAllowed.Val(),
+		}
+		if allAccounts {
+			allAccountsAllowed := &element.BooleanDataElement{}
+			err = allAccountsAllowed.UnmarshalHBCI(groupElements[2])
+			if err != nil {
+				return err
+			}
+			accountTransactions.AllAccountsAllowed = allAccountsAllowed.Val()
+		}
+		if formats {
+			supportedFormats, err := unmarshalFormats(groupElements[expected:])
+			if err != nil {
+				return err
+			}
+			accountTransactions.SupportedFormats = supportedFormats
+		}
+		params.AccountTransactions = accountTransactions
+		return nil
+	}
+}
+
+// unmarshalSepaAccountInformationParameters unmarshals the parameters of
+// HKSPA
+func unmarshalSepaAccountInformationParameters(params *domain.BusinessTransactionParameters, groupElements [][]byte) error {
+	if len(groupElements) < 3 {
+		return fmt.Errorf("Expected at least 3 SEPA account information parameters, got %d", len(groupElements))
+	}
+	var flags [3]bool
+	for i := range flags {
+		flag := &element.BooleanDataElement{}
+		err := flag.UnmarshalHBCI(groupElements[i])
+		if err != nil {
+			return err
+		}
+		flags[i] = flag.Val()
+	}
+	supportedFormats, err := unmarshalFormats(groupElements[3:])
+	if err != nil {
+		return err
+	}
+	params.SepaAccountInformation = &domain.SepaAccountInformationParameters{
+		SingleAccountQueryAllowed: flags[0],
+		NationalAccountAllowed:    flags[1],
+		StructuredPurposeAllowed:  flags[2],
+		SupportedFormats:          supportedFormats,
+	}
 	return nil
 }
 
+// unmarshalFormats unmarshals the given group data elements as SEPA or camt
+// format identifiers
+func unmarshalFormats(groupElements [][]byte) ([]string, error) {
+	var formats []string
+	for _, groupElement := range groupElements {
+		format := &element.AlphaNumericDataElement{}
+		err := format.UnmarshalHBCI(groupElement)
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, format.Val())
+	}
+	return formats, nil
+}
+
+// jobID returns the ID of the job described by the parameter segment with the
+// given ID, e.g. "HKKAZ" for "HIKAZS"
+func jobID(paramSegmentID string) string {
+	if len(paramSegmentID) < 3 {
+		return paramSegmentID
+	}
+	return paramSegmentID[:1] + "K" + strings.TrimSuffix(paramSegmentID[2:], "S")
+}
+
 type PinTanBusinessTransactionParams interface {
 	BankSegment
 	PinTanBusinessTransactions() []domain.PinTanBusinessTransaction
@@ -80,6 +253,9 @@ func (p *PinTanBusinessTransactionParamsSegment) UnmarshalHBCI(value []byte) err
 	if err != nil {
 		return err
 	}
+	if len(elements) < 4 {
+		return fmt.Errorf("%T: Malformed marshaled value", p)
+	}
 	pinTanParams := &element.PinTanBusinessTransactionParameters{}
 	err = pinTanParams.UnmarshalHBCI(elements[3])
 	if err != nil {
@@ -111,3 +111,107 @@ func TestPinTanBusinessTransactionParamsSegmentPinTanBusinessTransactions(t *tes
 		t.Fail()
 	}
 }
+
+func TestBusinessTransactionParamsSegmentBusinessTransactionParameters(t *testing.T) {
+	tests := []struct {
+		marshaled string
+		expected  domain.BusinessTransactionParameters
+	}{
+		{
+			"HIKAZS:8:5:4+1+1+360:N'",
+			domain.BusinessTransactionParameters{
+				ID: "HKKAZ", Version: 5, MaxJobs: 1, MinSignatures: 1,
+				AccountTransactions: &domain.AccountTransactionParameters{StorageDuration: 360},
+			},
+		},
+		{
+			"HIKAZS:9:6:4+1+1+0+360:N:J'",
+			domain.BusinessTransactionParameters{
+				ID: "HKKAZ", Version: 6, MaxJobs: 1, MinSignatures: 1, SecurityClass: 0,
+				AccountTransactions: &domain.AccountTransactionParameters{StorageDuration: 360, AllAccountsAllowed: true},
+			},
+		},
+		{
+			"HICAZS:10:1:4+1+1+2+90:J:N:urn?:iso?:std?:iso?:20022?:tech?:xsd?:camt.052.001.02'",
+			domain.BusinessTransactionParameters{
+				ID: "HKCAZ", Version: 1, MaxJobs: 1, MinSignatures: 1, SecurityClass: 2,
+				AccountTransactions: &domain.AccountTransactionParameters{
+					StorageDuration:   90,
+					EntryCountAllowed: true,
+					SupportedFormats:  []string{"urn:iso:std:iso:20022:tech:xsd:camt.052.001.02"},
+				},
+			},
+		},
+		{
+			"HISPAS:11:1:4+1+1+1+J:N:N:urn?:iso?:std?:iso?:20022?:tech?:xsd?:pain.001.001.03'",
+			domain.BusinessTransactionParameters{
+				ID: "HKSPA", Version: 1, MaxJobs: 1, MinSignatures: 1, SecurityClass: 1,
+				SepaAccountInformation: &domain.SepaAccountInformationParameters{
+					SingleAccountQueryAllowed: true,
+					SupportedFormats:          []string{"urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"},
+				},
+			},
+		},
+		{
+			"HISALS:12:5:4+1+1'",
+			domain.BusinessTransactionParameters{
+				ID: "HKSAL", Version: 5, MaxJobs: 1, MinSignatures: 1,
+			},
+		},
+		{
+			"HISALS:13:7:4+1+1+3'",
+			domain.BusinessTransactionParameters{
+				ID: "HKSAL", Version: 7, MaxJobs: 1, MinSignatures: 1, SecurityClass: 3,
+			},
+		},
+		{
+			"HIXYZS:14:1:4+1+1+1+J:N'",
+			domain.BusinessTransactionParameters{
+				ID: "HKXYZ", Version: 1, MaxJobs: 1, MinSignatures: 1,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		segment := &BusinessTransactionParamsSegment{}
+		err := segment.UnmarshalHBCI([]byte(test.marshaled))
+		if err != nil {
+			t.Logf("Expected no error, got %T:%v\n", err, err)
+			t.Fail()
+			continue
+		}
+
+		actual := segment.BusinessTransactionParameters()
+
+		if !reflect.DeepEqual(test.expected, actual) {
+			t.Logf("Expected parameters of %q to equal\n%#v\n\tgot\n%#v\n", test.marshaled, test.expected, actual)
+			t.Fail()
+		}
+	}
+}
+
+func TestBusinessTransactionParamsSegmentUnmarshalHBCIMalformedParameters(t *testing.T) {
+	tests := []struct {
+		description string
+		marshaled   string
+	}{
+		{"security class within version without security class", "HIKAZS:8:5:4+1+1+0+360:N'"},
+		{"missing security class", "HIKAZS:9:6:4+1+1+360:N:N'"},
+		{"malformed storage duration", "HIKAZS:9:6:4+1+1+0+X:N:N'"},
+		{"malformed flag", "HISPAS:10:1:4+1+1+1+J:X:N'"},
+		{"missing parameters", "HISPAS:10:1:4+1+1+1'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			segment := &BusinessTransactionParamsSegment{}
+
+			err := segment.UnmarshalHBCI([]byte(test.marshaled))
+
+			if err == nil {
+				t.Logf("Expected error, got nil\n")
+				t.Fail()
+			}
+		})
+	}
+}
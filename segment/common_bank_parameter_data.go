@@ -59,6 +59,7 @@ func (c *CommonBankParameterV2) BankParameterData() domain.BankParameterData {
 	if c.MaxMessageSize != nil {
 		bpd.MaxMessageSize = c.MaxMessageSize.Val()
 	}
+	bpd.SupportedLanguages, bpd.SupportedHBCIVersions = supportedLanguagesAndVersions(c.SupportedLanguages, c.SupportedHBCIVersions)
 	return bpd
 }
 
@@ -104,6 +105,7 @@ func (c *CommonBankParameterV3) BankParameterData() domain.BankParameterData {
 	if c.MaxMessageSize != nil {
 		bpd.MaxMessageSize = c.MaxMessageSize.Val()
 	}
+	bpd.SupportedLanguages, bpd.SupportedHBCIVersions = supportedLanguagesAndVersions(c.SupportedLanguages, c.SupportedHBCIVersions)
 	if c.MinTimeoutValue != nil {
 		bpd.MinTimeout = c.MinTimeoutValue.Val()
 	}
@@ -112,3 +114,17 @@ func (c *CommonBankParameterV3) BankParameterData() domain.BankParameterData {
 	}
 	return bpd
 }
+
+func supportedLanguagesAndVersions(languages *element.SupportedLanguagesDataElement, versions *element.SupportedHBCIVersionsDataElement) ([]domain.Language, []int) {
+	var supportedLanguages []domain.Language
+	if languages != nil {
+		for _, language := range languages.Languages() {
+			supportedLanguages = append(supportedLanguages, domain.Language(language.Val()))
+		}
+	}
+	var supportedVersions []int
+	if versions != nil {
+		supportedVersions = versions.Versions()
+	}
+	return supportedLanguages, supportedVersions
+}
@@ -42,11 +42,11 @@ func (c *CommunicationAccessRequestSegment) elements() []element.DataElement {
 
 const HKKOMSegmentNumber = -1
 
-func NewCommunicationAccessResponseSegment(bankId domain.BankID, language int, params domain.CommunicationParameter) *CommunicationAccessResponseSegment {
+func NewCommunicationAccessResponseSegment(bankId domain.BankID, language int, params ...domain.CommunicationParameter) *CommunicationAccessResponseSegment {
 	c := &CommunicationAccessResponseSegment{
 		BankID:              element.NewBankIdentification(bankId),
 		StandardLanguage:    element.NewNumber(language, 3),
-		CommunicationParams: element.NewCommunicationParameter(params),
+		CommunicationParams: element.NewCommunicationParameters(params...),
 	}
 	header := element.NewReferencingSegmentHeader("HIKOM", 4, 3, HKKOMSegmentNumber)
 	c.Segment = NewBasicSegmentWithHeader(header, c)
@@ -59,7 +59,7 @@ type CommunicationAccessResponseSegment struct {
 	Segment
 	BankID              *element.BankIdentificationDataElement
 	StandardLanguage    *element.NumberDataElement
-	CommunicationParams *element.CommunicationParameterDataElement
+	CommunicationParams *element.CommunicationParametersDataElement
 }
 
 func (c *CommunicationAccessResponseSegment) Version() int         { return 3 }
@@ -74,3 +74,19 @@ func (c *CommunicationAccessResponseSegment) elements() []element.DataElement {
 		c.CommunicationParams,
 	}
 }
+
+// Language returns the default language of the institute
+func (c *CommunicationAccessResponseSegment) Language() domain.Language {
+	if c.StandardLanguage == nil {
+		return 0
+	}
+	return domain.Language(c.StandardLanguage.Val())
+}
+
+// CommunicationParameters returns the access points of the institute
+func (c *CommunicationAccessResponseSegment) CommunicationParameters() []domain.CommunicationParameter {
+	if c.CommunicationParams == nil {
+		return nil
+	}
+	return c.CommunicationParams.Val()
+}
@@ -35,7 +35,7 @@ func (c *CommunicationAccessResponseSegment) UnmarshalHBCI(value []byte) error {
 		}
 	}
 	if len(elements) > 3 && len(elements[3]) > 0 {
-		c.CommunicationParams = &element.CommunicationParameterDataElement{}
+		c.CommunicationParams = &element.CommunicationParametersDataElement{}
 		if len(elements)+1 > 3 {
 			err = c.CommunicationParams.UnmarshalHBCI(bytes.Join(elements[3:], []byte("+")))
 		} else {
@@ -0,0 +1,36 @@
+package segment
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mitch000001/go-hbci/domain"
+)
+
+func TestCommunicationAccessResponseSegmentUnmarshalHBCI(t *testing.T) {
+	test := "HIKOM:5:4:4+280:10000000+1+3:https?://banking.example.com?:443/fints::MIM:1+2:10.0.0.1'"
+
+	segment := &CommunicationAccessResponseSegment{}
+
+	err := segment.UnmarshalHBCI([]byte(test))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if segment.Language() != domain.German {
+		t.Logf("Expected language to equal %v, got %v\n", domain.German, segment.Language())
+		t.Fail()
+	}
+
+	expected := []domain.CommunicationParameter{
+		{Protocol: 3, Address: "https://banking.example.com:443/fints", FilterFunction: "MIM", FilterFunctionVersion: 1},
+		{Protocol: 2, Address: "10.0.0.1"},
+	}
+	actual := segment.CommunicationParameters()
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Logf("Expected communication parameters to equal\n%#v\n\tgot\n%#v\n", expected, actual)
+		t.Fail()
+	}
+}
@@ -13,8 +13,13 @@ type EncryptionHeader interface {
 	ClientSegment
 	SetClientSystemID(clientSystemID string)
 	SetSecurityProfile(securityFn string)
+	SetSecurityMethod(securityMethod string, version int)
+	SetSecurityFunction(securityFn string)
 	SetEncryptionKeyName(keyName domain.KeyName)
 	SetEncryptionAlgorithm(algorithm *element.EncryptionAlgorithmDataElement)
+	SetCompressionFunction(compressionFunction string)
+	CompressionFunctionCode() string
+	EncryptedMessageKey() []byte
 }
 
 func NewPinTanEncryptionHeaderSegment(clientSystemId string, keyName domain.KeyName) *EncryptionHeaderSegment {
@@ -115,6 +120,32 @@ func (e *EncryptionHeaderV2) SetSecurityProfile(securityFn string) {
 	// NO OP
 }
 
+func (e *EncryptionHeaderV2) SetSecurityMethod(securityMethod string, version int) {
+	// NO OP
+}
+
+func (e *EncryptionHeaderV2) SetSecurityFunction(securityFn string) {
+	e.SecurityFunction = element.NewAlphaNumeric(securityFn, 3)
+}
+
+func (e *EncryptionHeaderV2) SetCompressionFunction(compressionFunction string) {
+	e.CompressionFunction = element.NewAlphaNumeric(compressionFunction, 3)
+}
+
+func (e *EncryptionHeaderV2) CompressionFunctionCode() string {
+	if e.CompressionFunction == nil {
+		return ""
+	}
+	return e.CompressionFunction.Val()
+}
+
+func (e *EncryptionHeaderV2) EncryptedMessageKey() []byte {
+	if e.EncryptionAlgorithm == nil || e.EncryptionAlgorithm.Key == nil {
+		return nil
+	}
+	return e.EncryptionAlgorithm.Key.Val()
+}
+
 func NewPinTanEncryptionHeaderSegmentV3(clientSystemId string, keyName domain.KeyName) *EncryptionHeaderSegment {
 	e := &EncryptionHeaderSegmentV3{
 		SecurityProfile:      element.NewPinTanSecurityProfile(1),
@@ -199,3 +230,29 @@ func (e *EncryptionHeaderSegmentV3) SetSecurityProfile(securityFn string) {
 		e.SecurityProfile = element.NewPinTanSecurityProfile(2)
 	}
 }
+
+func (e *EncryptionHeaderSegmentV3) SetSecurityMethod(securityMethod string, version int) {
+	e.SecurityProfile = element.NewSecurityProfile(securityMethod, version)
+}
+
+func (e *EncryptionHeaderSegmentV3) SetSecurityFunction(securityFn string) {
+	e.SecurityFunction = element.NewCode(securityFn, 3, []string{"4", "998"})
+}
+
+func (e *EncryptionHeaderSegmentV3) SetCompressionFunction(compressionFunction string) {
+	e.CompressionFunction = element.NewCode(compressionFunction, 3, []string{"0", "1", "2", "3", "4", "5", "6", "7", "999"})
+}
+
+func (e *EncryptionHeaderSegmentV3) CompressionFunctionCode() string {
+	if e.CompressionFunction == nil {
+		return ""
+	}
+	return e.CompressionFunction.Val()
+}
+
+func (e *EncryptionHeaderSegmentV3) EncryptedMessageKey() []byte {
+	if e.EncryptionAlgorithm == nil || e.EncryptionAlgorithm.Key == nil {
+		return nil
+	}
+	return e.EncryptionAlgorithm.Key.Val()
+}
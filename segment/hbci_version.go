@@ -41,6 +41,10 @@ type Builder interface {
 	AccountTransactionRequest(account domain.AccountConnection, allAccounts bool) (*AccountTransactionRequestSegment, error)
 	SepaAccountTransactionRequest(account domain.InternationalAccountConnection, allAccounts bool) (*AccountTransactionRequestSegment, error)
 	StatusProtocolRequest(from, to time.Time, maxEntries int, continuationReference string) (StatusProtocolRequest, error)
+	PinChangeRequest(newPin string) (*PinChangeSegment, error)
+	PinLockRequest() (*PinLockSegment, error)
+	TanMediaListRequest(class domain.TanMediumClass) (TanMediaListRequest, error)
+	TanGeneratorSyncRequest(cardNumber, cardSequenceNumber string, atc int, tan string) (*TanGeneratorSyncSegment, error)
 }
 
 // NewBuilder returns a new Builder which uses the supported segments to
@@ -101,3 +105,32 @@ func (b *builder) StatusProtocolRequest(from, to time.Time, maxEntries int, cont
 	}
 	return request(from, to, maxEntries, continuationReference), nil
 }
+func (b *builder) PinChangeRequest(newPin string) (*PinChangeSegment, error) {
+	if _, ok := b.supportedSegments["HIPAES"]; !ok {
+		return nil, fmt.Errorf("Segment %s not supported", "HKPAE")
+	}
+	return NewPinChangeSegment(newPin), nil
+}
+func (b *builder) PinLockRequest() (*PinLockSegment, error) {
+	if _, ok := b.supportedSegments["HIPSAS"]; !ok {
+		return nil, fmt.Errorf("Segment %s not supported", "HKPSA")
+	}
+	return NewPinLockSegment(), nil
+}
+func (b *builder) TanMediaListRequest(class domain.TanMediumClass) (TanMediaListRequest, error) {
+	versions, ok := b.supportedSegments["HITABS"]
+	if !ok {
+		return nil, fmt.Errorf("Segment %s not supported", "HKTAB")
+	}
+	request, err := TanMediaListRequestBuilder(versions)
+	if err != nil {
+		return nil, err
+	}
+	return request(class), nil
+}
+func (b *builder) TanGeneratorSyncRequest(cardNumber, cardSequenceNumber string, atc int, tan string) (*TanGeneratorSyncSegment, error) {
+	if _, ok := b.supportedSegments["HITSYS"]; !ok {
+		return nil, fmt.Errorf("Segment %s not supported", "HKTSY")
+	}
+	return NewTanGeneratorSyncSegment(cardNumber, cardSequenceNumber, atc, tan), nil
+}
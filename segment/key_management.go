@@ -9,22 +9,26 @@ import (
 	"github.com/mitch000001/go-hbci/element"
 )
 
-func NewPublicKeyRenewalSegment(number int, keyName domain.KeyName, pubKey *domain.PublicKey) *PublicKeyRenewalSegment {
+func NewPublicKeyRenewalSegment(number int, keyName domain.KeyName, pubKey *domain.PublicKey, operationMode string) (*PublicKeyRenewalSegment, error) {
 	if keyName.KeyType == "B" {
 		panic(fmt.Errorf("KeyType may not be 'B'"))
 	}
+	publicKey, err := element.NewPublicKeyWithOperationMode(pubKey, operationMode)
+	if err != nil {
+		return nil, err
+	}
 	p := &PublicKeyRenewalSegment{
 		MessageID:  element.NewNumber(2, 1),
 		FunctionID: element.NewNumber(112, 3),
 		KeyName:    element.NewKeyName(keyName),
-		PublicKey:  element.NewPublicKey(pubKey),
+		PublicKey:  publicKey,
 	}
-	p.Segment = NewBasicSegment(number, p)
-	return p
+	p.ClientSegment = NewBasicSegment(number, p)
+	return p, nil
 }
 
 type PublicKeyRenewalSegment struct {
-	Segment
+	ClientSegment
 	// "2" für ‘Key-Management-Nachricht erwartet Antwort’
 	MessageID *element.NumberDataElement
 	// "112" für ‘Certificate Replacement’ (Ersatz des Zertifikats))
@@ -84,23 +88,29 @@ func (p *PublicKeyRequestSegment) elements() []element.DataElement {
 	}
 }
 
-func NewPublicKeyTransmissionSegment(dialogId string, number int, messageReference int, keyName domain.KeyName, pubKey *domain.PublicKey, refSegment *PublicKeyRequestSegment) *PublicKeyTransmissionSegment {
+func NewPublicKeyTransmissionSegment(dialogId string, number int, messageReference int, keyName domain.KeyName, pubKey *domain.PublicKey, refSegment *PublicKeyRequestSegment) (*PublicKeyTransmissionSegment, error) {
 	if messageReference <= 0 {
 		panic(fmt.Errorf("Message Reference number must be greater 0"))
 	}
+	publicKey, err := element.NewPublicKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
 	p := &PublicKeyTransmissionSegment{
 		MessageID:  element.NewNumber(1, 1),
 		DialogID:   element.NewIdentification(dialogId),
 		MessageRef: element.NewNumber(messageReference, 4),
 		FunctionID: element.NewNumber(224, 3),
 		KeyName:    element.NewKeyName(keyName),
-		PublicKey:  element.NewPublicKey(pubKey),
+		PublicKey:  publicKey,
 	}
 	header := element.NewReferencingSegmentHeader("HIISA", number, 2, refSegment.Header().Number.Val())
 	p.Segment = NewBasicSegmentWithHeader(header, p)
-	return p
+	return p, nil
 }
 
+//go:generate go run ../cmd/unmarshaler/unmarshaler_generator.go -segment PublicKeyTransmissionSegment
+
 type PublicKeyTransmissionSegment struct {
 	Segment
 	// "1" für ‘Key-Management-Nachricht ist Antwort’
@@ -143,8 +153,15 @@ var validRevocationReasons = []string{
 	KeyRevocationMisc,
 }
 
+// IsValidRevocationReason returns true if reason is one of KeyCompromitted,
+// KeyMaybeCompromitted or KeyRevocationMisc
+func IsValidRevocationReason(reason string) bool {
+	i := sort.SearchStrings(validRevocationReasons, reason)
+	return i < len(validRevocationReasons) && validRevocationReasons[i] == reason
+}
+
 func NewPublicKeyRevocationSegment(number int, keyName domain.KeyName, reason string) *PublicKeyRevocationSegment {
-	if sort.SearchStrings(validRevocationReasons, reason) > len(validRevocationReasons) {
+	if !IsValidRevocationReason(reason) {
 		panic(fmt.Errorf("Reason must be one of %v", validRevocationReasons))
 	}
 	p := &PublicKeyRevocationSegment{
@@ -154,12 +171,12 @@ func NewPublicKeyRevocationSegment(number int, keyName domain.KeyName, reason st
 		RevocationReason: element.NewAlphaNumeric(reason, 3),
 		Date:             element.NewSecurityDate(element.SecurityTimestamp, time.Now()),
 	}
-	p.Segment = NewBasicSegment(number, p)
+	p.ClientSegment = NewBasicSegment(number, p)
 	return p
 }
 
 type PublicKeyRevocationSegment struct {
-	Segment
+	ClientSegment
 	// "2" für ‘Key-Management-Nachricht erwartet Antwort’
 	MessageID *element.NumberDataElement
 	// "130" für ‘Certificate Revocation’ (Zertifikatswiderruf)
@@ -193,7 +210,7 @@ func NewPublicKeyRevocationConfirmationSegment(dialogId string, number int, mess
 	if messageReference <= 0 {
 		panic(fmt.Errorf("Message Reference number must be greater 0"))
 	}
-	if sort.SearchStrings(validRevocationReasons, reason) > len(validRevocationReasons) {
+	if !IsValidRevocationReason(reason) {
 		panic(fmt.Errorf("Reason must be one of %v", validRevocationReasons))
 	}
 	p := &PublicKeyRevocationConfirmationSegment{
@@ -0,0 +1,25 @@
+package segment
+
+import "testing"
+
+func TestIsValidRevocationReason(t *testing.T) {
+	tests := []struct {
+		reason string
+		valid  bool
+	}{
+		{KeyCompromitted, true},
+		{KeyMaybeCompromitted, true},
+		{KeyRevocationMisc, true},
+		{"", false},
+		{"5", false},
+		{"1000", false},
+	}
+	for _, test := range tests {
+		actual := IsValidRevocationReason(test.reason)
+
+		if test.valid != actual {
+			t.Logf("Reason %q: Expected valid to be %t, got %t\n", test.reason, test.valid, actual)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package segment
+
+import "github.com/mitch000001/go-hbci/element"
+
+// NewPinChangeSegment returns a new segment to change the PIN of the user to
+// newPin. The message has to be signed with the current PIN.
+func NewPinChangeSegment(newPin string) *PinChangeSegment {
+	p := &PinChangeSegment{
+		NewPin: element.NewAlphaNumeric(newPin, 99),
+	}
+	p.ClientSegment = NewBasicSegment(1, p)
+	return p
+}
+
+// PinChangeSegment represents a request to change the PIN of the user
+type PinChangeSegment struct {
+	ClientSegment
+	NewPin *element.AlphaNumericDataElement
+}
+
+func (p *PinChangeSegment) Version() int         { return 1 }
+func (p *PinChangeSegment) ID() string           { return "HKPAE" }
+func (p *PinChangeSegment) referencedId() string { return "" }
+func (p *PinChangeSegment) sender() string       { return senderUser }
+
+func (p *PinChangeSegment) elements() []element.DataElement {
+	return []element.DataElement{
+		p.NewPin,
+	}
+}
+
+// NewPinLockSegment returns a new segment to lock the access of the user.
+// The access can only be unlocked by the institute afterwards.
+func NewPinLockSegment() *PinLockSegment {
+	p := &PinLockSegment{}
+	p.ClientSegment = NewBasicSegment(1, p)
+	return p
+}
+
+// PinLockSegment represents a request to lock the access of the user
+type PinLockSegment struct {
+	ClientSegment
+}
+
+func (p *PinLockSegment) Version() int         { return 1 }
+func (p *PinLockSegment) ID() string           { return "HKPSA" }
+func (p *PinLockSegment) referencedId() string { return "" }
+func (p *PinLockSegment) sender() string       { return senderUser }
+
+func (p *PinLockSegment) elements() []element.DataElement {
+	return []element.DataElement{}
+}
@@ -74,6 +74,13 @@ func init() {
 	KnownSegments.mustAddToIndex(VersionedSegment{"HIKIM", 2}, func() Segment { return &BankAnnouncementSegment{} })
 	KnownSegments.mustAddToIndex(VersionedSegment{"HIBPA", 2}, func() Segment { return &CommonBankParameterV2{} })
 	KnownSegments.mustAddToIndex(VersionedSegment{"HIBPA", 3}, func() Segment { return &CommonBankParameterV3{} })
+	// The structure of HIKOM and HISHV did not change between the versions
+	KnownSegments.mustAddToIndex(VersionedSegment{"HIKOM", 3}, func() Segment { return &CommunicationAccessResponseSegment{} })
+	KnownSegments.mustAddToIndex(VersionedSegment{"HIKOM", 4}, func() Segment { return &CommunicationAccessResponseSegment{} })
+	KnownSegments.mustAddToIndex(VersionedSegment{"HISHV", 2}, func() Segment { return &SecurityMethodSegment{} })
+	KnownSegments.mustAddToIndex(VersionedSegment{"HIISA", 2}, func() Segment { return &PublicKeyTransmissionSegment{} })
+	KnownSegments.mustAddToIndex(VersionedSegment{"HISHV", 3}, func() Segment { return &SecurityMethodSegment{} })
+	KnownSegments.mustAddToIndex(VersionedSegment{"HIKPV", 1}, func() Segment { return &CompressionMethodSegment{} })
 	KnownSegments.mustAddToIndex(VersionedSegment{"DIPINS", 1}, func() Segment { return &PinTanBusinessTransactionParamsSegment{} })
 	KnownSegments.mustAddToIndex(VersionedSegment{"HIUPA", 2}, func() Segment { return &CommonUserParameterDataV2{} })
 	KnownSegments.mustAddToIndex(VersionedSegment{"HIUPA", 3}, func() Segment { return &CommonUserParameterDataV3{} })
@@ -88,4 +95,6 @@ func init() {
 	KnownSegments.mustAddToIndex(VersionedSegment{"HIKAZ", 7}, func() Segment { return &AccountTransactionResponseSegmentV7{} })
 	KnownSegments.mustAddToIndex(VersionedSegment{"HIPRO", 3}, func() Segment { return &StatusProtocolResponseSegmentV3{} })
 	KnownSegments.mustAddToIndex(VersionedSegment{"HIPRO", 4}, func() Segment { return &StatusProtocolResponseSegmentV4{} })
+	KnownSegments.mustAddToIndex(VersionedSegment{"HITAB", 4}, func() Segment { return &TanMediaListResponseSegment{} })
+	KnownSegments.mustAddToIndex(VersionedSegment{"HITAB", 5}, func() Segment { return &TanMediaListResponseSegment{} })
 }
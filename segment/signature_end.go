@@ -6,6 +6,8 @@ type SignatureEnd interface {
 	SetControlReference(controlReference string)
 	SetSignature(signature []byte)
 	SetPinTan(pin, tan string)
+	ControlReference() string
+	SignatureValue() []byte
 }
 
 func NewSignatureEndSegmentV1() *SignatureEndSegment {
@@ -18,9 +20,12 @@ func NewSignatureEndSegmentV1() *SignatureEndSegment {
 	return segment
 }
 
+//go:generate go run ../cmd/unmarshaler/unmarshaler_generator.go -segment SignatureEndSegment -segment_interface signatureEndSegment -segment_versions="SignatureEndV1:1:ClientSegment,SignatureEndV2:2:ClientSegment"
+
 type signatureEndSegment interface {
 	ClientSegment
 	SignatureEnd
+	Unmarshaler
 }
 
 type SignatureEndSegment struct {
@@ -58,6 +63,23 @@ func (s *SignatureEndV1) SetSignature(signature []byte) {
 func (s *SignatureEndV1) SetPinTan(pin, tan string) {
 	s.PinTan = element.NewPinTan(pin, tan)
 }
+
+// ControlReference returns the security control reference which links the
+// signature end to its signature header
+func (s *SignatureEndV1) ControlReference() string {
+	if s.SecurityControlRef == nil {
+		return ""
+	}
+	return s.SecurityControlRef.Val()
+}
+
+// SignatureValue returns the signature, or nil if there is none
+func (s *SignatureEndV1) SignatureValue() []byte {
+	if s.Signature == nil {
+		return nil
+	}
+	return s.Signature.Val()
+}
 func NewSignatureEndSegmentV2() *SignatureEndSegment {
 	s := &SignatureEndV2{}
 	s.ClientSegment = NewBasicSegment(-1, s)
@@ -99,3 +121,20 @@ func (s *SignatureEndV2) SetSignature(signature []byte) {
 func (s *SignatureEndV2) SetPinTan(pin, tan string) {
 	s.CustomSignature = element.NewCustomSignature(pin, tan)
 }
+
+// ControlReference returns the security control reference which links the
+// signature end to its signature header
+func (s *SignatureEndV2) ControlReference() string {
+	if s.SecurityControlRef == nil {
+		return ""
+	}
+	return s.SecurityControlRef.Val()
+}
+
+// SignatureValue returns the signature, or nil if there is none
+func (s *SignatureEndV2) SignatureValue() []byte {
+	if s.Signature == nil {
+		return nil
+	}
+	return s.Signature.Val()
+}
@@ -13,7 +13,12 @@ type SignatureHeader interface {
 	SetSigningKeyName(keyName domain.KeyName)
 	SetSignatureID(signatureId int)
 	SetSecurityFunction(string)
+	SetSecurityMethod(securityMethod string, version int)
+	SetHashAlgorithm(algorithm *element.HashAlgorithmDataElement)
+	SetSignatureAlgorithm(algorithm *element.SignatureAlgorithmDataElement)
 	SetControlReference(string)
+	ControlReference() string
+	SigningKeyName() domain.KeyName
 }
 
 func NewSignatureHeaderSegmentV3() *SignatureHeaderSegment {
@@ -112,6 +117,18 @@ func (s *SignatureHeaderV3) SetSecurityFunction(securityFn string) {
 	s.SecurityFunction = element.NewAlphaNumeric(securityFn, 3)
 }
 
+func (s *SignatureHeaderV3) SetSecurityMethod(securityMethod string, version int) {
+	// NO OP
+}
+
+func (s *SignatureHeaderV3) SetHashAlgorithm(algorithm *element.HashAlgorithmDataElement) {
+	s.HashAlgorithm = algorithm
+}
+
+func (s *SignatureHeaderV3) SetSignatureAlgorithm(algorithm *element.SignatureAlgorithmDataElement) {
+	s.SignatureAlgorithm = algorithm
+}
+
 func (s *SignatureHeaderV3) SetControlReference(controlReference string) {
 	s.SecurityControlRef = element.NewAlphaNumeric(controlReference, 14)
 }
@@ -128,6 +145,23 @@ func (s *SignatureHeaderV3) SetSignatureID(signatureId int) {
 	s.SecurityRefNumber = element.NewNumber(signatureId, 16)
 }
 
+// ControlReference returns the security control reference which links the
+// signature header to its signature end
+func (s *SignatureHeaderV3) ControlReference() string {
+	if s.SecurityControlRef == nil {
+		return ""
+	}
+	return s.SecurityControlRef.Val()
+}
+
+// SigningKeyName returns the name of the key the signature was created with
+func (s *SignatureHeaderV3) SigningKeyName() domain.KeyName {
+	if s.KeyName == nil {
+		return domain.KeyName{}
+	}
+	return s.KeyName.Val()
+}
+
 func (s *SignatureHeaderV3) Version() int         { return 3 }
 func (s *SignatureHeaderV3) ID() string           { return "HNSHK" }
 func (s *SignatureHeaderV3) referencedId() string { return "" }
@@ -219,6 +253,18 @@ func (s *SignatureHeaderSegmentV4) SetSecurityFunction(securityFn string) {
 	}
 }
 
+func (s *SignatureHeaderSegmentV4) SetSecurityMethod(securityMethod string, version int) {
+	s.SecurityProfile = element.NewSecurityProfile(securityMethod, version)
+}
+
+func (s *SignatureHeaderSegmentV4) SetHashAlgorithm(algorithm *element.HashAlgorithmDataElement) {
+	s.HashAlgorithm = algorithm
+}
+
+func (s *SignatureHeaderSegmentV4) SetSignatureAlgorithm(algorithm *element.SignatureAlgorithmDataElement) {
+	s.SignatureAlgorithm = algorithm
+}
+
 func (s *SignatureHeaderSegmentV4) SetControlReference(controlReference string) {
 	s.SecurityControlRef = element.NewAlphaNumeric(controlReference, 14)
 }
@@ -235,6 +281,23 @@ func (s *SignatureHeaderSegmentV4) SetSignatureID(signatureId int) {
 	s.SecurityRefNumber = element.NewNumber(signatureId, 16)
 }
 
+// ControlReference returns the security control reference which links the
+// signature header to its signature end
+func (s *SignatureHeaderSegmentV4) ControlReference() string {
+	if s.SecurityControlRef == nil {
+		return ""
+	}
+	return s.SecurityControlRef.Val()
+}
+
+// SigningKeyName returns the name of the key the signature was created with
+func (s *SignatureHeaderSegmentV4) SigningKeyName() domain.KeyName {
+	if s.KeyName == nil {
+		return domain.KeyName{}
+	}
+	return s.KeyName.Val()
+}
+
 func (s *SignatureHeaderSegmentV4) Version() int         { return 4 }
 func (s *SignatureHeaderSegmentV4) ID() string           { return "HNSHK" }
 func (s *SignatureHeaderSegmentV4) referencedId() string { return "" }
@@ -4,6 +4,8 @@ import "github.com/mitch000001/go-hbci/element"
 
 //go:generate go run ../cmd/unmarshaler/unmarshaler_generator.go -segment CompressionMethodSegment
 
+// CompressionMethodSegment contains the compression methods supported by the
+// bank institute
 type CompressionMethodSegment struct {
 	Segment
 	SupportedCompressionMethods *element.SupportedCompressionMethodsDataElement
@@ -19,3 +21,12 @@ func (c *CompressionMethodSegment) elements() []element.DataElement {
 		c.SupportedCompressionMethods,
 	}
 }
+
+// CompressionFunctions returns the codes of the compression functions
+// supported by the bank institute
+func (c *CompressionMethodSegment) CompressionFunctions() []string {
+	if c.SupportedCompressionMethods == nil {
+		return nil
+	}
+	return c.SupportedCompressionMethods.Val()
+}
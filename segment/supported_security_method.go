@@ -1,13 +1,16 @@
 package segment
 
-import "github.com/mitch000001/go-hbci/element"
+import (
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/element"
+)
 
 //go:generate go run ../cmd/unmarshaler/unmarshaler_generator.go -segment SecurityMethodSegment
 
 type SecurityMethodSegment struct {
 	Segment
 	MixAllowed       *element.BooleanDataElement
-	SupportedMethods *element.SupportedSecurityMethodDataElement
+	SupportedMethods *element.SupportedSecurityMethodsDataElement
 }
 
 func (s *SecurityMethodSegment) Version() int         { return 2 }
@@ -21,3 +24,17 @@ func (s *SecurityMethodSegment) elements() []element.DataElement {
 		s.SupportedMethods,
 	}
 }
+
+// SecurityMethods returns the security methods supported by the institute
+func (s *SecurityMethodSegment) SecurityMethods() []domain.SecurityMethod {
+	if s.SupportedMethods == nil {
+		return nil
+	}
+	return s.SupportedMethods.Val()
+}
+
+// MixingAllowed returns true if the signatures of a message may use
+// different security methods
+func (s *SecurityMethodSegment) MixingAllowed() bool {
+	return s.MixAllowed != nil && s.MixAllowed.Val()
+}
@@ -28,7 +28,7 @@ func (s *SecurityMethodSegment) UnmarshalHBCI(value []byte) error {
 		}
 	}
 	if len(elements) > 2 && len(elements[2]) > 0 {
-		s.SupportedMethods = &element.SupportedSecurityMethodDataElement{}
+		s.SupportedMethods = &element.SupportedSecurityMethodsDataElement{}
 		if len(elements)+1 > 2 {
 			err = s.SupportedMethods.UnmarshalHBCI(bytes.Join(elements[2:], []byte("+")))
 		} else {
@@ -0,0 +1,36 @@
+package segment
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mitch000001/go-hbci/domain"
+)
+
+func TestSecurityMethodSegmentUnmarshalHBCI(t *testing.T) {
+	test := "HISHV:6:3:4+J+RDH:3:10+PIN:1'"
+
+	segment := &SecurityMethodSegment{}
+
+	err := segment.UnmarshalHBCI([]byte(test))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if !segment.MixingAllowed() {
+		t.Logf("Expected mixing of security methods to be allowed\n")
+		t.Fail()
+	}
+
+	expected := []domain.SecurityMethod{
+		{Code: "RDH", Versions: []int{3, 10}},
+		{Code: "PIN", Versions: []int{1}},
+	}
+	actual := segment.SecurityMethods()
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Logf("Expected security methods to equal\n%#v\n\tgot\n%#v\n", expected, actual)
+		t.Fail()
+	}
+}
@@ -129,6 +129,9 @@ func (s *SynchronisationResponseSegmentV3) LastMessageNumber() int {
 }
 
 func (s *SynchronisationResponseSegmentV3) SignatureID() int {
+	if s.SignatureIDResponse == nil {
+		return 0
+	}
 	return s.SignatureIDResponse.Val()
 }
 
@@ -161,5 +164,8 @@ func (s *SynchronisationResponseSegmentV4) LastMessageNumber() int {
 }
 
 func (s *SynchronisationResponseSegmentV4) SignatureID() int {
+	if s.SignatureIDResponse == nil {
+		return 0
+	}
 	return s.SignatureIDResponse.Val()
 }
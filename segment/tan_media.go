@@ -0,0 +1,302 @@
+package segment
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mitch000001/go-hbci/charset"
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/element"
+)
+
+var tanMediaListRequests = map[int]func(class domain.TanMediumClass) TanMediaListRequest{
+	4: NewTanMediaListRequestV4,
+	5: NewTanMediaListRequestV5,
+}
+
+// TanMediaListRequestBuilder returns the highest matching versioned segment
+func TanMediaListRequestBuilder(versions []int) (func(class domain.TanMediumClass) TanMediaListRequest, error) {
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	for _, version := range versions {
+		builder, ok := tanMediaListRequests[version]
+		if ok {
+			return builder, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported versions %v", versions)
+}
+
+// TanMediaListRequest represents a request for the TAN media of the user
+type TanMediaListRequest interface {
+	ClientSegment
+}
+
+// Possible TAN medium types to request
+const (
+	tanMediumTypeAll       = "0"
+	tanMediumTypeActive    = "1"
+	tanMediumTypeAvailable = "2"
+)
+
+var tanMediumTypes = []string{tanMediumTypeAll, tanMediumTypeActive, tanMediumTypeAvailable}
+
+// NewTanMediaListRequestV4 returns a request for all active and available
+// TAN media of class. Secoders are not known to this version.
+func NewTanMediaListRequestV4(class domain.TanMediumClass) TanMediaListRequest {
+	t := &TanMediaListRequestSegmentV4{
+		TanMediumType:  element.NewCode(tanMediumTypeAll, 1, tanMediumTypes),
+		TanMediumClass: element.NewCode(string(class), 1, []string{"A", "L", "G", "M"}),
+	}
+	t.ClientSegment = NewBasicSegment(1, t)
+	return t
+}
+
+type TanMediaListRequestSegmentV4 struct {
+	ClientSegment
+	// Code | Bedeutung
+	// ---------------------------------------------------------
+	// 0	| Alle
+	// 1	| Aktiv
+	// 2	| Verfügbar
+	TanMediumType  *element.CodeDataElement
+	TanMediumClass *element.CodeDataElement
+}
+
+func (t *TanMediaListRequestSegmentV4) Version() int         { return 4 }
+func (t *TanMediaListRequestSegmentV4) ID() string           { return "HKTAB" }
+func (t *TanMediaListRequestSegmentV4) referencedId() string { return "" }
+func (t *TanMediaListRequestSegmentV4) sender() string       { return senderUser }
+
+func (t *TanMediaListRequestSegmentV4) elements() []element.DataElement {
+	return []element.DataElement{
+		t.TanMediumType,
+		t.TanMediumClass,
+	}
+}
+
+// NewTanMediaListRequestV5 returns a request for all active and available
+// TAN media of class
+func NewTanMediaListRequestV5(class domain.TanMediumClass) TanMediaListRequest {
+	t := &TanMediaListRequestSegmentV5{
+		TanMediumType:  element.NewCode(tanMediumTypeAll, 1, tanMediumTypes),
+		TanMediumClass: element.NewCode(string(class), 1, []string{"A", "L", "G", "M", "S"}),
+	}
+	t.ClientSegment = NewBasicSegment(1, t)
+	return t
+}
+
+type TanMediaListRequestSegmentV5 struct {
+	ClientSegment
+	// Code | Bedeutung
+	// ---------------------------------------------------------
+	// 0	| Alle
+	// 1	| Aktiv
+	// 2	| Verfügbar
+	TanMediumType  *element.CodeDataElement
+	TanMediumClass *element.CodeDataElement
+}
+
+func (t *TanMediaListRequestSegmentV5) Version() int         { return 5 }
+func (t *TanMediaListRequestSegmentV5) ID() string           { return "HKTAB" }
+func (t *TanMediaListRequestSegmentV5) referencedId() string { return "" }
+func (t *TanMediaListRequestSegmentV5) sender() string       { return senderUser }
+
+func (t *TanMediaListRequestSegmentV5) elements() []element.DataElement {
+	return []element.DataElement{
+		t.TanMediumType,
+		t.TanMediumClass,
+	}
+}
+
+// TanMediaListResponseSegment contains the TAN media of the user. Versions 4
+// and 5 only differ in the account connection of the TAN media, so both are
+// represented by this segment.
+type TanMediaListResponseSegment struct {
+	Segment
+	version int
+	// Code | Bedeutung
+	// ---------------------------------------------------------
+	// 0	| Alle Medien können genutzt werden
+	// 1	| Genau ein Medium kann genutzt werden
+	// 2	| Ein Mobiltelefon und ein TAN-Generator können genutzt werden
+	TanUsageOption *element.NumberDataElement
+	// rawMedia contains the marshaled TAN media
+	rawMedia [][]byte
+}
+
+func (t *TanMediaListResponseSegment) Version() int         { return t.version }
+func (t *TanMediaListResponseSegment) ID() string           { return "HITAB" }
+func (t *TanMediaListResponseSegment) referencedId() string { return "HKTAB" }
+func (t *TanMediaListResponseSegment) sender() string       { return senderBank }
+
+func (t *TanMediaListResponseSegment) elements() []element.DataElement {
+	return []element.DataElement{
+		t.TanUsageOption,
+	}
+}
+
+func (t *TanMediaListResponseSegment) UnmarshalHBCI(value []byte) error {
+	elements, err := ExtractElements(value)
+	if err != nil {
+		return err
+	}
+	if len(elements) < 2 {
+		return fmt.Errorf("%T: Malformed marshaled value", t)
+	}
+	seg, err := SegmentFromHeaderBytes(elements[0], t)
+	if err != nil {
+		return err
+	}
+	t.Segment = seg
+	t.version = seg.Header().Version.Val()
+	if t.version != 4 && t.version != 5 {
+		return fmt.Errorf("%T: Unsupported version %d", t, t.version)
+	}
+	usageOption, err := strconv.Atoi(charset.ToUTF8(elements[1]))
+	if err != nil {
+		return fmt.Errorf("%T: Malformed TAN usage option: %v", t, err)
+	}
+	t.TanUsageOption = element.NewNumber(usageOption, 1)
+	t.rawMedia = elements[2:]
+	return nil
+}
+
+// TanMedia returns the TAN media contained in the segment
+func (t *TanMediaListResponseSegment) TanMedia() ([]domain.TanMedium, error) {
+	var media []domain.TanMedium
+	for _, rawMedium := range t.rawMedia {
+		medium, err := t.tanMedium(rawMedium)
+		if err != nil {
+			return nil, err
+		}
+		media = append(media, medium)
+	}
+	return media, nil
+}
+
+// tanMedium unmarshals a single TAN medium. Up to version 4 the account
+// connection of the medium is a ktv, since version 5 a kti.
+func (t *TanMediaListResponseSegment) tanMedium(value []byte) (domain.TanMedium, error) {
+	fields, err := element.ExtractElements(value)
+	if err != nil {
+		return domain.TanMedium{}, err
+	}
+	field := func(i int) string {
+		if i >= len(fields) {
+			return ""
+		}
+		a := &element.AlphaNumericDataElement{}
+		a.UnmarshalHBCI(fields[i])
+		return a.Val()
+	}
+	var parseErr error
+	number := func(i int) int {
+		if field(i) == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(field(i))
+		if err != nil && parseErr == nil {
+			parseErr = fmt.Errorf("%T: Malformed number: %q", t, field(i))
+		}
+		return n
+	}
+	date := func(i int) time.Time {
+		if field(i) == "" {
+			return time.Time{}
+		}
+		d := &element.DateDataElement{}
+		if err := d.UnmarshalHBCI(fields[i]); err != nil {
+			if parseErr == nil {
+				parseErr = fmt.Errorf("%T: Malformed date: %q", t, field(i))
+			}
+			return time.Time{}
+		}
+		return d.Val()
+	}
+	if len(fields) < 2 {
+		return domain.TanMedium{}, fmt.Errorf("%T: Malformed TAN medium: %q", t, value)
+	}
+	medium := domain.TanMedium{
+		Class:              domain.TanMediumClass(field(0)),
+		Status:             domain.TanMediumStatus(number(1)),
+		CardNumber:         field(2),
+		CardSequenceNumber: field(3),
+	}
+	// The account connection starts after the card type
+	next := 5
+	if t.version == 4 {
+		medium.Account = domain.InternationalAccountConnection{
+			AccountID:                 field(next),
+			SubAccountCharacteristics: field(next + 1),
+			BankID:                    domain.BankID{CountryCode: number(next + 2), ID: field(next + 3)},
+		}
+		next += 4
+	} else {
+		medium.Account = domain.InternationalAccountConnection{
+			IBAN:                      field(next),
+			BIC:                       field(next + 1),
+			AccountID:                 field(next + 2),
+			SubAccountCharacteristics: field(next + 3),
+			BankID:                    domain.BankID{CountryCode: number(next + 4), ID: field(next + 5)},
+		}
+		next += 6
+	}
+	medium.ValidFrom = date(next)
+	medium.ValidTo = date(next + 1)
+	medium.ListNumber = field(next + 2)
+	medium.Name = field(next + 3)
+	medium.MaskedMobileNumber = field(next + 4)
+	medium.MobileNumber = field(next + 5)
+	// The SMS charge account is a kti
+	next += 6 + 6
+	medium.FreeTANs = number(next)
+	medium.LastUsed = date(next + 1)
+	medium.ActivatedOn = date(next + 2)
+	if parseErr != nil {
+		return domain.TanMedium{}, parseErr
+	}
+	return medium, nil
+}
+
+// NewTanGeneratorSyncSegment returns a new segment to synchronize the TAN
+// generator identified by cardNumber and cardSequenceNumber with the
+// institute. atc is the application transaction counter shown by the TAN
+// generator along with tan.
+func NewTanGeneratorSyncSegment(cardNumber, cardSequenceNumber string, atc int, tan string) *TanGeneratorSyncSegment {
+	t := &TanGeneratorSyncSegment{
+		CardNumber: element.NewIdentification(cardNumber),
+		ATC:        element.NewNumber(atc, 5),
+		TAN:        element.NewAlphaNumeric(tan, 99),
+	}
+	if cardSequenceNumber != "" {
+		t.CardSequenceNumber = element.NewIdentification(cardSequenceNumber)
+	}
+	t.ClientSegment = NewBasicSegment(1, t)
+	return t
+}
+
+// TanGeneratorSyncSegment represents a request to synchronize a TAN
+// generator with the institute
+type TanGeneratorSyncSegment struct {
+	ClientSegment
+	CardNumber         *element.IdentificationDataElement
+	CardSequenceNumber *element.IdentificationDataElement
+	ATC                *element.NumberDataElement
+	TAN                *element.AlphaNumericDataElement
+}
+
+func (t *TanGeneratorSyncSegment) Version() int         { return 1 }
+func (t *TanGeneratorSyncSegment) ID() string           { return "HKTSY" }
+func (t *TanGeneratorSyncSegment) referencedId() string { return "" }
+func (t *TanGeneratorSyncSegment) sender() string       { return senderUser }
+
+func (t *TanGeneratorSyncSegment) elements() []element.DataElement {
+	return []element.DataElement{
+		t.CardNumber,
+		t.CardSequenceNumber,
+		t.ATC,
+		t.TAN,
+	}
+}
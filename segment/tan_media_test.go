@@ -0,0 +1,116 @@
+package segment
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mitch000001/go-hbci/domain"
+)
+
+func TestTanMediaListResponseSegmentTanMedia(t *testing.T) {
+	date := func(month, day int) time.Time {
+		return time.Date(2015, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	}
+	generator := domain.TanMedium{
+		Class:              domain.TanGenerator,
+		Status:             domain.TanMediumActive,
+		CardNumber:         "5109972878",
+		CardSequenceNumber: "1",
+		ValidFrom:          date(1, 1),
+		ValidTo:            date(12, 31),
+		Name:               "Karte:1",
+		LastUsed:           date(8, 12),
+	}
+	phone := domain.TanMedium{
+		Class:              domain.MobilePhone,
+		Status:             domain.TanMediumAvailable,
+		Name:               "Handy",
+		MaskedMobileNumber: "0170***123",
+	}
+	tests := []struct {
+		description string
+		marshaled   string
+		expected    []domain.TanMedium
+	}{
+		{
+			"version 4 with ktv",
+			"HITAB:4:4:3+0+G:1:5109972878:1::100000000::280:10000000:20150101:20151231::Karte?:1::::::::::20150812+M:2:::::::::::Handy:0170***123'",
+			[]domain.TanMedium{
+				withAccount(generator, domain.InternationalAccountConnection{AccountID: "100000000", BankID: domain.BankID{CountryCode: 280, ID: "10000000"}}),
+				phone,
+			},
+		},
+		{
+			"version 5 with kti",
+			"HITAB:4:5:3+0+G:1:5109972878:1::DE89100000000100000000:BANKDEFFXXX:::::20150101:20151231::Karte?:1::::::::::20150812+M:2:::::::::::::Handy:0170***123'",
+			[]domain.TanMedium{
+				withAccount(generator, domain.InternationalAccountConnection{IBAN: "DE89100000000100000000", BIC: "BANKDEFFXXX"}),
+				phone,
+			},
+		},
+		{
+			"no TAN media",
+			"HITAB:4:5:3+0'",
+			nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			segment := &TanMediaListResponseSegment{}
+
+			err := segment.UnmarshalHBCI([]byte(test.marshaled))
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+			media, err := segment.TanMedia()
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			if !reflect.DeepEqual(test.expected, media) {
+				t.Logf("Expected TAN media to equal\n%+v\n\tgot\n%+v\n", test.expected, media)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestTanMediaListResponseSegmentMalformedTanMedium(t *testing.T) {
+	segment := &TanMediaListResponseSegment{}
+	err := segment.UnmarshalHBCI([]byte("HITAB:4:5:3+0+G:aktiv'"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	_, err = segment.TanMedia()
+
+	if err == nil {
+		t.Logf("Expected error, got nil\n")
+		t.Fail()
+	}
+}
+
+func TestTanMediaListRequestBuilder(t *testing.T) {
+	builder, err := TanMediaListRequestBuilder([]int{4, 5, 6})
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	expected := "HKTAB:1:5+0+G'"
+	marshaled, err := builder(domain.TanGenerator).MarshalHBCI()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	actual := string(marshaled)
+
+	if expected != actual {
+		t.Logf("Expected request to equal %q, got %q\n", expected, actual)
+		t.Fail()
+	}
+}
+
+func withAccount(medium domain.TanMedium, account domain.InternationalAccountConnection) domain.TanMedium {
+	medium.Account = account
+	return medium
+}
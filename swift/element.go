@@ -6,12 +6,14 @@ import (
 	"strconv"
 
 	"github.com/mitch000001/go-hbci/charset"
+	"github.com/mitch000001/go-hbci/domain"
 	"github.com/mitch000001/go-hbci/internal"
 )
 
 // A Tag represents a S.W.I.F.T. tag
 type Tag interface {
 	Unmarshal([]byte) error
+	Marshal() ([]byte, error)
 	Value() interface{}
 	ID() string
 }
@@ -44,6 +46,14 @@ func (a *AlphaNumericTag) Unmarshal(value []byte) error {
 	return nil
 }
 
+// Marshal marshals a into its S.W.I.F.T. representation
+func (a *AlphaNumericTag) Marshal() ([]byte, error) {
+	if a.tag == nil {
+		return nil, fmt.Errorf("%T: Tag must be set", a)
+	}
+	return charset.ToISO8859_1(a.id + a.Val()), nil
+}
+
 // Val returns the string embodied in a
 func (a *AlphaNumericTag) Val() string {
 	return a.value.(string)
@@ -72,6 +82,14 @@ func (n *NumberTag) Unmarshal(value []byte) error {
 	return nil
 }
 
+// Marshal marshals n into its S.W.I.F.T. representation
+func (n *NumberTag) Marshal() ([]byte, error) {
+	if n.tag == nil {
+		return nil, fmt.Errorf("%T: Tag must be set", n)
+	}
+	return []byte(n.id + strconv.Itoa(n.Val())), nil
+}
+
 // Val returns the int embodied in n
 func (n *NumberTag) Val() int {
 	return n.value.(int)
@@ -92,7 +110,7 @@ func (f *FloatTag) Unmarshal(value []byte) error {
 		return fmt.Errorf("%T: Malformed marshaled value", f)
 	}
 	id := string(elements[0])
-	num, err := strconv.ParseFloat(string(elements[1]), 64)
+	num, err := domain.ParseDecimal(string(elements[1]))
 	if err != nil {
 		return fmt.Errorf("%T: Error while unmarshaling: %v", f, err)
 	}
@@ -100,9 +118,17 @@ func (f *FloatTag) Unmarshal(value []byte) error {
 	return nil
 }
 
+// Marshal marshals f into its S.W.I.F.T. representation
+func (f *FloatTag) Marshal() ([]byte, error) {
+	if f.tag == nil {
+		return nil, fmt.Errorf("%T: Tag must be set", f)
+	}
+	return []byte(f.id + f.Val().Text(',')), nil
+}
+
 // Val returns the value of f
-func (f *FloatTag) Val() float64 {
-	return f.value.(float64)
+func (f *FloatTag) Val() domain.Decimal {
+	return f.value.(domain.Decimal)
 }
 
 // A CustomFieldTag represents holds multiple information about a transaction
@@ -166,6 +192,12 @@ func (c *CustomFieldTag) Unmarshal(value []byte) error {
 		}
 	}
 
+	if len(fields) == 0 && len(marshaledFields) != 0 {
+		// Unstructured description, e.g. with transaction ID 999
+		c.Purpose = []string{charset.ToUTF8(marshaledFields)}
+		return nil
+	}
+
 	getFieldValue := func(currentFieldKeyIndex, nextFieldKeyIndex int) string {
 		return charset.ToUTF8(
 			marshaledFields[currentFieldKeyIndex+3 : nextFieldKeyIndex],
@@ -211,6 +243,68 @@ func (c *CustomFieldTag) Unmarshal(value []byte) error {
 	return nil
 }
 
+// Marshal marshals c into its S.W.I.F.T. representation. Lines are wrapped
+// after 65 characters.
+func (c *CustomFieldTag) Marshal() ([]byte, error) {
+	if len(c.Purpose) > 10 {
+		return nil, fmt.Errorf("%T: too many purpose lines: %d (max 10)", c, len(c.Purpose))
+	}
+	if len(c.Purpose2) > 4 {
+		return nil, fmt.Errorf("%T: too many purpose2 lines: %d (max 4)", c, len(c.Purpose2))
+	}
+	var buf bytes.Buffer
+	buf.WriteString(tagID(c.Tag, ":86:"))
+	fmt.Fprintf(&buf, "%03d", c.TransactionID)
+	writeField := func(key string, value string) {
+		if value == "" {
+			return
+		}
+		buf.WriteString(key)
+		buf.WriteString(value)
+	}
+	writeField("?00", c.BookingText)
+	writeField("?10", c.PrimanotenNumber)
+	for i, purpose := range c.Purpose {
+		buf.WriteString(fmt.Sprintf("?2%d", i))
+		buf.WriteString(purpose)
+	}
+	writeField("?30", c.BankID)
+	writeField("?31", c.AccountID)
+	name, nameAddition := splitName(c.Name, 27)
+	writeField("?32", name)
+	writeField("?33", nameAddition)
+	if c.MessageKeyAddition != 0 {
+		writeField("?34", fmt.Sprintf("%03d", c.MessageKeyAddition))
+	}
+	for i, purpose := range c.Purpose2 {
+		buf.WriteString(fmt.Sprintf("?6%d", i))
+		buf.WriteString(purpose)
+	}
+	return wrapLines(charset.ToISO8859_1(buf.String()), 65), nil
+}
+
+// splitName splits name into the name fields ?32 and ?33 of at most
+// maxLength characters each. It splits at the last space before maxLength,
+// which is restored by unmarshaling, and cuts names without such a space.
+// Names exceeding both fields are truncated.
+func splitName(name string, maxLength int) (string, string) {
+	runes := []rune(name)
+	if len(runes) <= maxLength {
+		return name, ""
+	}
+	first, addition := runes[:maxLength], runes[maxLength:]
+	for i := maxLength; i > 0; i-- {
+		if runes[i] == ' ' {
+			first, addition = runes[:i], runes[i+1:]
+			break
+		}
+	}
+	if len(addition) > maxLength {
+		addition = addition[:maxLength]
+	}
+	return string(first), string(addition)
+}
+
 type fieldKeyIndex struct {
 	fieldKey []byte
 	index    int
@@ -35,3 +35,74 @@ func TestCustomFieldTagUnmarshal(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestCustomFieldTagMarshal(t *testing.T) {
+	tag := &CustomFieldTag{
+		Tag:                ":86:",
+		TransactionID:      123,
+		BookingText:        "ABC",
+		PrimanotenNumber:   "xyz",
+		Purpose:            []string{"ahh", "hjj"},
+		BankID:             "1000",
+		AccountID:          "56",
+		Name:               "Max Muster",
+		MessageKeyAddition: 99,
+		Purpose2:           []string{"uu", "z4"},
+	}
+
+	marshaled, err := tag.Marshal()
+
+	if err != nil {
+		t.Logf("Expected no error, got %T:%v\n", err, err)
+		t.Fail()
+	}
+
+	expected := ":86:123?00ABC?10xyz?20ahh?21hjj?301000?3156?32Max Muster?34099?60\r\nuu?61z4"
+
+	if string(marshaled) != expected {
+		t.Logf("Expected marshaled value to equal\n%q\n\tgot\n%q\n", expected, marshaled)
+		t.Fail()
+	}
+
+	actual := &CustomFieldTag{}
+	err = actual.Unmarshal(marshaled)
+
+	if err != nil {
+		t.Logf("Expected no error, got %T:%v\n", err, err)
+		t.Fail()
+	}
+
+	if !reflect.DeepEqual(tag, actual) {
+		t.Logf("Expected tag to equal\n%#v\n\tgot\n%#v\n", tag, actual)
+		t.Fail()
+	}
+}
+
+func TestSplitName(t *testing.T) {
+	tests := []struct {
+		description  string
+		name         string
+		expectedName string
+		addition     string
+	}{
+		{"short name", "Max Muster", "Max Muster", ""},
+		{"split at space", "Maximilian Mustermann Mueller Schmidt", "Maximilian Mustermann", "Mueller Schmidt"},
+		{"no space", "Maximilian-Mustermann-Mueller-Schmidt", "Maximilian-Mustermann-Muell", "er-Schmidt"},
+		{"addition too long", "Maximilian-Mustermann-Mueller-Schmidt-Meier-Schulze-Lehmann", "Maximilian-Mustermann-Muell", "er-Schmidt-Meier-Schulze-Le"},
+		{"umlauts", "Jürgen Müller-Lüdenscheidt-Süß", "Jürgen", "Müller-Lüdenscheidt-Süß"},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			name, addition := splitName(test.name, 27)
+
+			if name != test.expectedName {
+				t.Logf("Expected name to equal %q, got %q\n", test.expectedName, name)
+				t.Fail()
+			}
+			if addition != test.addition {
+				t.Logf("Expected name addition to equal %q, got %q\n", test.addition, addition)
+				t.Fail()
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 	"unicode"
 
+	"github.com/mitch000001/go-hbci/charset"
 	"github.com/mitch000001/go-hbci/domain"
 	"github.com/pkg/errors"
 )
@@ -29,36 +30,33 @@ type MT940 struct {
 
 // AccountTransactions returns a slice of account transactions created from m
 func (m *MT940) AccountTransactions() []domain.AccountTransaction {
-	accountConnection := domain.AccountConnection{BankID: m.Account.BankID, AccountID: m.Account.AccountID, CountryCode: 280}
+	var accountConnection domain.AccountConnection
+	if m.Account != nil {
+		accountConnection = domain.AccountConnection{BankID: m.Account.BankID, AccountID: m.Account.AccountID, CountryCode: 280}
+	}
+	// Statements unmarshaled in LenientMode may lack balances
+	var balanceBefore, balanceAfter domain.Balance
+	if m.StartingBalance != nil {
+		balanceBefore = m.StartingBalance.Balance()
+	}
+	if m.ClosingBalance != nil {
+		balanceAfter = m.ClosingBalance.Balance()
+	}
+	currency := balanceBefore.Amount.Currency
+	if currency == "" {
+		currency = balanceAfter.Amount.Currency
+	}
 	var transactions []domain.AccountTransaction
 	for _, transactionSequence := range m.Transactions {
 		tr := transactionSequence.Transaction
 		descr := transactionSequence.Description
-		var amount float64
-		if tr.DebitCreditIndicator == "D" {
-			amount = -tr.Amount
-		} else {
-			amount = tr.Amount
-		}
 		transaction := domain.AccountTransaction{
-			Account:     accountConnection,
-			Amount:      domain.Amount{Amount: amount, Currency: m.StartingBalance.Currency},
-			ValutaDate:  tr.ValutaDate.Time,
-			BookingDate: tr.BookingDate.Time,
-			AccountBalanceBefore: domain.Balance{
-				Amount: domain.Amount{
-					Amount:   m.StartingBalance.Amount,
-					Currency: m.StartingBalance.Currency,
-				},
-				TransmissionDate: m.StartingBalance.BookingDate.Time,
-			},
-			AccountBalanceAfter: domain.Balance{
-				Amount: domain.Amount{
-					Amount:   m.ClosingBalance.Amount,
-					Currency: m.ClosingBalance.Currency,
-				},
-				TransmissionDate: m.ClosingBalance.BookingDate.Time,
-			},
+			Account:              accountConnection,
+			Amount:               domain.Amount{Amount: tr.SignedAmount(), Currency: currency},
+			ValutaDate:           tr.ValutaDate.Time,
+			BookingDate:          tr.BookingDate.Time,
+			AccountBalanceBefore: balanceBefore,
+			AccountBalanceAfter:  balanceAfter,
 		}
 		if descr != nil {
 			transaction.BookingText = descr.BookingText
@@ -74,6 +72,104 @@ func (m *MT940) AccountTransactions() []domain.AccountTransaction {
 	return transactions
 }
 
+// NewMT940 creates a MT940 for account from the given transactions. The
+// balances are used as starting and closing balance of the statement.
+func NewMT940(jobReference string, account domain.AccountConnection, statementNumber int, startingBalance, closingBalance domain.Balance, transactions []domain.AccountTransaction) *MT940 {
+	m := &MT940{
+		JobReference: &AlphaNumericTag{&tag{id: ":20:", value: jobReference}},
+		Account: &AccountTag{
+			Tag:       ":25:",
+			BankID:    account.BankID,
+			AccountID: account.AccountID,
+		},
+		StatementNumber: &StatementNumberTag{Tag: ":28C:", Number: statementNumber},
+		StartingBalance: newBalanceTag(":60F:", startingBalance),
+		ClosingBalance:  newBalanceTag(":62F:", closingBalance),
+	}
+	for _, transaction := range transactions {
+		m.Transactions = append(m.Transactions, newTransactionSequence(transaction))
+	}
+	return m
+}
+
+func newBalanceTag(id string, balance domain.Balance) *BalanceTag {
+	debitCreditIndicator, amount := debitCredit(balance.Amount.Amount)
+	return &BalanceTag{
+		Tag:                  id,
+		DebitCreditIndicator: debitCreditIndicator,
+		BookingDate:          domain.NewShortDate(balance.TransmissionDate),
+		Currency:             balance.Amount.Currency,
+		Amount:               amount,
+	}
+}
+
+func newTransactionSequence(transaction domain.AccountTransaction) *TransactionSequence {
+	debitCreditIndicator, amount := debitCredit(transaction.Amount.Amount)
+	tr := &TransactionTag{
+		Tag:                  ":61:",
+		ValutaDate:           domain.NewShortDate(transaction.ValutaDate),
+		DebitCreditIndicator: debitCreditIndicator,
+		Amount:               amount,
+		BookingKey:           "MSC",
+		Reference:            "NONREF",
+	}
+	if len(transaction.Amount.Currency) == 3 {
+		tr.CurrencyKind = transaction.Amount.Currency[2:]
+	}
+	if !transaction.BookingDate.IsZero() {
+		tr.BookingDate = domain.NewShortDate(transaction.BookingDate)
+	}
+	descr := &CustomFieldTag{
+		Tag:           ":86:",
+		TransactionID: transaction.TransactionID,
+		BookingText:   transaction.BookingText,
+		BankID:        transaction.BankID,
+		AccountID:     transaction.AccountID,
+		Name:          transaction.Name,
+		Purpose:       splitField(transaction.Purpose, 27),
+		Purpose2:      splitField(transaction.Purpose2, 27),
+	}
+	return &TransactionSequence{Transaction: tr, Description: descr}
+}
+
+func debitCredit(amount domain.Decimal) (string, domain.Decimal) {
+	if amount.Sign() < 0 {
+		return "D", amount.Neg()
+	}
+	return "C", amount
+}
+
+// splitField splits value into lines of at most maxLength characters. Lines
+// are broken at spaces where possible, so that joining the lines with a space
+// restores value.
+func splitField(value string, maxLength int) []string {
+	if value == "" {
+		return nil
+	}
+	var lines []string
+	var current string
+	for _, word := range strings.Split(value, " ") {
+		for len(word) > maxLength {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, word[:maxLength])
+			word = word[maxLength:]
+		}
+		switch {
+		case current == "":
+			current = word
+		case len(current)+1+len(word) <= maxLength:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	return append(lines, current)
+}
+
 // AccountTag represents an account in S.W.I.F.T.
 type AccountTag struct {
 	Tag       string
@@ -100,6 +196,14 @@ func (a *AccountTag) Unmarshal(value []byte) error {
 	return nil
 }
 
+// Marshal marshals a into its S.W.I.F.T. representation
+func (a *AccountTag) Marshal() ([]byte, error) {
+	if a.BankID == "" || a.AccountID == "" {
+		return nil, fmt.Errorf("%T: BankID and AccountID must be set", a)
+	}
+	return []byte(tagID(a.Tag, ":25:") + a.BankID + "/" + a.AccountID), nil
+}
+
 // StatementNumberTag represents a S.W.I.F.T. statement number
 type StatementNumberTag struct {
 	Tag         string
@@ -141,20 +245,29 @@ func (s *StatementNumberTag) Unmarshal(value []byte) error {
 	return nil
 }
 
+// Marshal marshals s into its S.W.I.F.T. representation
+func (s *StatementNumberTag) Marshal() ([]byte, error) {
+	value := strconv.Itoa(s.Number)
+	if s.SheetNumber != 0 {
+		value += "/" + strconv.Itoa(s.SheetNumber)
+	}
+	return []byte(tagID(s.Tag, ":28C:") + value), nil
+}
+
 // A BalanceTag represents a balance in S.W.I.F.T.
 type BalanceTag struct {
 	Tag                  string
 	DebitCreditIndicator string
 	BookingDate          domain.ShortDate
 	Currency             string
-	Amount               float64
+	Amount               domain.Decimal
 }
 
 // Balance returns the balance embodied in b
 func (b *BalanceTag) Balance() domain.Balance {
 	amount := b.Amount
 	if b.DebitCreditIndicator == "D" {
-		amount = -amount
+		amount = amount.Neg()
 	}
 	return domain.Balance{
 		Amount:           domain.Amount{Amount: amount, Currency: b.Currency},
@@ -181,8 +294,7 @@ func (b *BalanceTag) Unmarshal(value []byte) error {
 	}
 	b.BookingDate = domain.NewShortDate(date)
 	b.Currency = string(buf.Next(3))
-	amountString := strings.Replace(buf.String(), ",", ".", 1)
-	amount, err := strconv.ParseFloat(amountString, 64)
+	amount, err := domain.ParseDecimal(buf.String())
 	if err != nil {
 		return errors.Wrap(err, "MT940 Balance tag: error unmarshaling amount")
 	}
@@ -190,6 +302,26 @@ func (b *BalanceTag) Unmarshal(value []byte) error {
 	return nil
 }
 
+// Marshal marshals b into its S.W.I.F.T. representation
+func (b *BalanceTag) Marshal() ([]byte, error) {
+	if b.Tag == "" {
+		return nil, fmt.Errorf("%T: Tag must be set", b)
+	}
+	if len(b.Currency) != 3 {
+		return nil, fmt.Errorf("%T: Malformed currency: %q", b, b.Currency)
+	}
+	var buf bytes.Buffer
+	if b.DebitCreditIndicator == "" {
+		return nil, fmt.Errorf("%T: DebitCreditIndicator must be set", b)
+	}
+	buf.WriteString(b.Tag)
+	buf.WriteString(b.DebitCreditIndicator)
+	buf.WriteString(b.BookingDate.Format("060102"))
+	buf.WriteString(b.Currency)
+	buf.WriteString(formatAmount(b.Amount))
+	return buf.Bytes(), nil
+}
+
 // A TransactionSequence represents a transaction with an additional
 // description in S.W.I.F.T.
 type TransactionSequence struct {
@@ -197,6 +329,27 @@ type TransactionSequence struct {
 	Description *CustomFieldTag
 }
 
+// Marshal marshals t into its S.W.I.F.T. representation. The tags are
+// separated by CRLF.
+func (t *TransactionSequence) Marshal() ([]byte, error) {
+	if t.Transaction == nil {
+		return nil, fmt.Errorf("%T: Transaction must be set", t)
+	}
+	marshaled, err := t.Transaction.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if t.Description != nil {
+		description, err := t.Description.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		marshaled = append(marshaled, tagSeparatorSequence...)
+		marshaled = append(marshaled, description...)
+	}
+	return marshaled, nil
+}
+
 // A TransactionTag represents a transaction in S.W.I.F.T.
 type TransactionTag struct {
 	Tag                   string
@@ -204,13 +357,22 @@ type TransactionTag struct {
 	BookingDate           domain.ShortDate
 	DebitCreditIndicator  string
 	CurrencyKind          string
-	Amount                float64
+	Amount                domain.Decimal
 	BookingKey            string
 	Reference             string
 	BankReference         string
 	AdditionalInformation string
 }
 
+// SignedAmount returns the amount of t as seen from the account, i.e. debits
+// and reversals of credits are negative.
+func (t *TransactionTag) SignedAmount() domain.Decimal {
+	if t.DebitCreditIndicator == "D" || t.DebitCreditIndicator == "RC" {
+		return t.Amount.Neg()
+	}
+	return t.Amount
+}
+
 // Unmarshal unmarshals value into t
 func (t *TransactionTag) Unmarshal(value []byte) error {
 	elements, err := extractTagElements(value)
@@ -255,26 +417,37 @@ func (t *TransactionTag) Unmarshal(value []byte) error {
 		if unicode.IsDigit(r) {
 			buf.UnreadRune()
 			runes = runes[:len(runes)-1]
-			if len(runes) == 3 {
-				t.DebitCreditIndicator = string(runes[:2])
-				t.CurrencyKind = string(runes[2:])
-			} else if len(runes) == 2 {
-				t.DebitCreditIndicator = string(runes[:1])
-				t.CurrencyKind = string(runes[1:])
-			} else if len(runes) == 1 {
-				t.DebitCreditIndicator = string(runes)
-			} else {
+			// Reversals (storno) are marked with RC and RD, the currency
+			// kind is optional
+			markLength := 1
+			if len(runes) > 0 && runes[0] == 'R' {
+				markLength = 2
+			}
+			if len(runes) < markLength || len(runes) > markLength+1 {
 				return fmt.Errorf("%T: Malformed marshaled value", t)
 			}
+			t.DebitCreditIndicator = string(runes[:markLength])
+			t.CurrencyKind = string(runes[markLength:])
 			break
 		}
 	}
-	amountBytes, err := buf.ReadBytes('N')
-	if err != nil {
-		return err
+	var amountBytes []byte
+	for {
+		b, err := buf.ReadByte()
+		if err != nil {
+			return err
+		}
+		if ('0' <= b && b <= '9') || b == ',' {
+			amountBytes = append(amountBytes, b)
+			continue
+		}
+		// The transaction type is one of 'N', 'F' and 'S'
+		if b != 'N' && b != 'F' && b != 'S' {
+			return fmt.Errorf("%T: Malformed transaction type: %q", t, b)
+		}
+		break
 	}
-	amountBytes = bytes.Replace(amountBytes[:len(amountBytes)-1], []byte(","), []byte("."), 1)
-	amount, err := strconv.ParseFloat(string(amountBytes), 64)
+	amount, err := domain.ParseDecimal(string(amountBytes))
 	if err != nil {
 		return errors.Wrap(err, "MT940 Transaction tag: error unmarshaling amount")
 	}
@@ -285,14 +458,15 @@ func (t *TransactionTag) Unmarshal(value []byte) error {
 	doubleSlashIdx := strings.Index(remaining, "//")
 
 	if doubleSlashIdx != -1 && addInfSepIdx != -1 {
-		t.Reference = remaining[:doubleSlashIdx]
 		if doubleSlashIdx < addInfSepIdx {
+			t.Reference = remaining[:doubleSlashIdx]
 			t.BankReference = remaining[doubleSlashIdx+2 : addInfSepIdx]
 			t.AdditionalInformation = remaining[addInfSepIdx+3:]
 		} else {
 			// The only valid case in the FINTS30 documentation in the other
 			// one, but the data we receive are sometimes formatted like that
 			// :(
+			t.Reference = remaining[:addInfSepIdx]
 			t.BankReference = remaining[addInfSepIdx+3 : doubleSlashIdx]
 			t.AdditionalInformation = remaining[doubleSlashIdx+2:]
 		}
@@ -310,6 +484,38 @@ func (t *TransactionTag) Unmarshal(value []byte) error {
 	return nil
 }
 
+// Marshal marshals t into its S.W.I.F.T. representation
+func (t *TransactionTag) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(tagID(t.Tag, ":61:"))
+	buf.WriteString(t.ValutaDate.Format("060102"))
+	if !t.BookingDate.IsZero() {
+		buf.WriteString(t.BookingDate.Format("0102"))
+	}
+	if t.DebitCreditIndicator == "" {
+		return nil, fmt.Errorf("%T: DebitCreditIndicator must be set", t)
+	}
+	buf.WriteString(t.DebitCreditIndicator)
+	buf.WriteString(t.CurrencyKind)
+	buf.WriteString(formatAmount(t.Amount))
+	buf.WriteString("N")
+	if len(t.BookingKey) != 3 {
+		return nil, fmt.Errorf("%T: Malformed booking key: %q", t, t.BookingKey)
+	}
+	buf.WriteString(t.BookingKey)
+	buf.WriteString(t.Reference)
+	if t.BankReference != "" {
+		buf.WriteString("//")
+		buf.WriteString(t.BankReference)
+	}
+	if t.AdditionalInformation != "" {
+		buf.WriteString(tagSeparatorSequence)
+		buf.WriteString("/")
+		buf.WriteString(t.AdditionalInformation)
+	}
+	return charset.ToISO8859_1(buf.String()), nil
+}
+
 func parseDate(value []byte, referenceYear int) (time.Time, error) {
 	var offset int
 	if len(value) == 6 {
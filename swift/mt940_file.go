@@ -0,0 +1,124 @@
+package swift
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/pkg/errors"
+)
+
+// ParseAccountTransactions parses all statements of a MT940 file (often
+// called STA file) from reader and returns the transactions of all statements.
+//
+// See ParseMT940Statements for the supported file variations.
+func ParseAccountTransactions(reader io.Reader) ([]domain.AccountTransaction, error) {
+	statements, err := ParseMT940Statements(reader)
+	if err != nil {
+		return nil, err
+	}
+	var transactions []domain.AccountTransaction
+	for _, statement := range statements {
+		transactions = append(transactions, statement.AccountTransactions()...)
+	}
+	return transactions, nil
+}
+
+// ParseMT940Statements parses all statements of a MT940 file from reader.
+//
+// Files downloaded from bank portals often deviate from the format used within
+// HBCI messages. The following variations are accepted:
+//   - LF or CR line endings instead of CRLF
+//   - '@@' as line separator
+//   - empty lines and leading data before the first tag
+//   - statements separated by a line containing only '-', or not separated at
+//     all, in which case a new statement starts with a ':20:' tag
+//   - a missing separator after the last statement
+func ParseMT940Statements(reader io.Reader) ([]*MT940, error) {
+	statements, _, err := ParseMT940StatementsWithOptions(reader, UnmarshalOptions{})
+	return statements, err
+}
+
+// ParseMT940StatementsWithOptions parses all statements of a MT940 file from
+// reader like ParseMT940Statements, but unmarshals every statement with the
+// given options. The messages of the returned diagnostics are prefixed with
+// the number of the statement they belong to.
+func ParseMT940StatementsWithOptions(reader io.Reader, options UnmarshalOptions) ([]*MT940, Diagnostics, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "read MT940 file")
+	}
+	normalized := normalizeMT940File(data)
+	if len(normalized) == 0 {
+		return nil, nil, nil
+	}
+	messages, err := NewMessageExtractor(normalized).Extract()
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "extract MT940 statements")
+	}
+	var statements []*MT940
+	var diagnostics Diagnostics
+	for i, message := range messages {
+		statement := &MT940{}
+		statementDiagnostics, err := statement.UnmarshalWithOptions(message, options)
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, fmt.Sprintf("unmarshal statement %d", i+1))
+		}
+		for _, d := range statementDiagnostics {
+			d.Message = fmt.Sprintf("statement %d: %s", i+1, d.Message)
+			diagnostics = append(diagnostics, d)
+		}
+		if options.Mode != LenientMode && (statement.Account == nil || statement.StartingBalance == nil || statement.ClosingBalance == nil) {
+			return nil, nil, fmt.Errorf("statement %d: missing account, starting or closing balance", i+1)
+		}
+		statements = append(statements, statement)
+	}
+	return statements, diagnostics, nil
+}
+
+// normalizeMT940File transforms data into the format expected by the
+// MessageExtractor, i.e. every tag is preceded by CRLF and every statement is
+// terminated by CRLF followed by '-'.
+func normalizeMT940File(data []byte) []byte {
+	data = bytes.Replace(data, []byte("@@"), []byte("\n"), -1)
+	data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+	data = bytes.Replace(data, []byte("\r"), []byte("\n"), -1)
+	var buf bytes.Buffer
+	statementOpen := false
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimRight(line, " \t\x1a")
+		switch {
+		case len(line) == 0:
+			continue
+		case bytes.HasPrefix(line, []byte("-")) && len(bytes.Trim(line, "-}")) == 0:
+			if statementOpen {
+				buf.WriteString(messageSeparatorSequence)
+				statementOpen = false
+			}
+			continue
+		case bytes.HasPrefix(line, []byte(":20:")):
+			if statementOpen {
+				buf.WriteString(messageSeparatorSequence)
+			}
+			statementOpen = true
+		case !statementOpen:
+			if !isTagLine(line) {
+				// Skip everything in front of the first tag, e.g. file headers
+				continue
+			}
+			statementOpen = true
+		}
+		buf.WriteString(tagSeparatorSequence)
+		buf.Write(line)
+	}
+	if statementOpen {
+		buf.WriteString(messageSeparatorSequence)
+	}
+	return buf.Bytes()
+}
+
+func isTagLine(line []byte) bool {
+	return len(line) > 2 && line[0] == ':' && '0' <= line[1] && line[1] <= '9'
+}
@@ -0,0 +1,98 @@
+package swift
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitch000001/go-hbci/domain"
+)
+
+func TestParseAccountTransactions(t *testing.T) {
+	statement := func(reference, purpose string) []string {
+		return []string{
+			":20:" + reference,
+			":25:12345678/1234123456",
+			":28C:1/1",
+			":60F:C181105EUR1234,56",
+			":61:1811051105DR50,NMSCNONREF",
+			":86:177?00SB-SEPA-Ueberweisung?20" + purpose + "?32Max Meier",
+			":62F:C181105EUR1184,56",
+		}
+	}
+	join := func(sep string, statements ...[]string) string {
+		var lines []string
+		for _, s := range statements {
+			lines = append(lines, strings.Join(s, sep))
+		}
+		return strings.Join(lines, sep+"-"+sep) + sep + "-" + sep
+	}
+
+	tests := []struct {
+		description string
+		file        string
+	}{
+		{
+			"CRLF with separators",
+			"\r\n" + join("\r\n", statement("STARTUMS", "first"), statement("STARTUMS", "second")),
+		},
+		{
+			"LF only",
+			join("\n", statement("STARTUMS", "first"), statement("STARTUMS", "second")),
+		},
+		{
+			"@@ line markers",
+			"@@" + join("@@", statement("STARTUMS", "first"), statement("STARTUMS", "second")),
+		},
+		{
+			"without separators and trailing dash",
+			strings.Join(append(statement("STARTUMS", "first"), statement("STARTUMS", "second")...), "\r\n"),
+		},
+		{
+			"with header and empty lines",
+			"HEADER\r\n\r\n" + join("\r\n\r\n", statement("STARTUMS", "first"), statement("STARTUMS", "second")) + "\x1a",
+		},
+	}
+
+	for _, test := range tests {
+		transactions, err := ParseAccountTransactions(strings.NewReader(test.file))
+
+		if err != nil {
+			t.Logf("%s: Expected no error, got %T:%v\n", test.description, err, err)
+			t.Fail()
+			continue
+		}
+
+		if len(transactions) != 2 {
+			t.Logf("%s: Expected 2 transactions, got %d\n", test.description, len(transactions))
+			t.Fail()
+			continue
+		}
+
+		for i, expectedPurpose := range []string{"first", "second"} {
+			tr := transactions[i]
+			if tr.Purpose != expectedPurpose {
+				t.Logf("%s: Expected purpose to equal %q, got %q\n", test.description, expectedPurpose, tr.Purpose)
+				t.Fail()
+			}
+			if !tr.Amount.Amount.Equal(domain.NewDecimalFromInt(-50)) {
+				t.Logf("%s: Expected amount to equal -50, got %v\n", test.description, tr.Amount.Amount)
+				t.Fail()
+			}
+			if !tr.AccountBalanceAfter.Amount.Amount.Equal(domain.NewDecimal(118456, 2)) {
+				t.Logf("%s: Expected closing balance to equal 1184.56, got %v\n", test.description, tr.AccountBalanceAfter.Amount.Amount)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestParseMT940StatementsMissingBalance(t *testing.T) {
+	file := ":20:STARTUMS\r\n:25:12345678/1234123456\r\n:28C:1\r\n:60F:C181105EUR1234,56\r\n-"
+
+	_, err := ParseMT940Statements(strings.NewReader(file))
+
+	if err == nil {
+		t.Logf("Expected error for statement without closing balance, got nil\n")
+		t.Fail()
+	}
+}
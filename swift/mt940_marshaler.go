@@ -0,0 +1,99 @@
+package swift
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mitch000001/go-hbci/domain"
+)
+
+// Marshal marshals m into its S.W.I.F.T. representation. The result starts
+// with CRLF and ends with the message separator CRLF followed by '-', so that
+// multiple marshaled messages can simply be concatenated.
+func (m *MT940) Marshal() ([]byte, error) {
+	if m.JobReference == nil {
+		return nil, fmt.Errorf("%T: JobReference must be set", m)
+	}
+	if m.Account == nil {
+		return nil, fmt.Errorf("%T: Account must be set", m)
+	}
+	if m.StatementNumber == nil {
+		return nil, fmt.Errorf("%T: StatementNumber must be set", m)
+	}
+	if m.StartingBalance == nil {
+		return nil, fmt.Errorf("%T: StartingBalance must be set", m)
+	}
+	if m.ClosingBalance == nil {
+		return nil, fmt.Errorf("%T: ClosingBalance must be set", m)
+	}
+	type marshaler interface {
+		Marshal() ([]byte, error)
+	}
+	var tags []marshaler
+	tags = append(tags, m.JobReference)
+	if m.Reference != nil {
+		tags = append(tags, m.Reference)
+	}
+	tags = append(tags, m.Account, m.StatementNumber, m.StartingBalance)
+	for _, transaction := range m.Transactions {
+		tags = append(tags, transaction)
+	}
+	tags = append(tags, m.ClosingBalance)
+	if m.CurrentValutaBalance != nil {
+		tags = append(tags, m.CurrentValutaBalance)
+	}
+	if m.FutureValutaBalance != nil {
+		tags = append(tags, m.FutureValutaBalance)
+	}
+	if m.CustomField != nil {
+		tags = append(tags, m.CustomField)
+	}
+	var buf bytes.Buffer
+	for _, tag := range tags {
+		marshaled, err := tag.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(tagSeparatorSequence)
+		buf.Write(marshaled)
+	}
+	buf.WriteString(messageSeparatorSequence)
+	return buf.Bytes(), nil
+}
+
+const (
+	tagSeparatorSequence     = "\r\n"
+	messageSeparatorSequence = "\r\n-"
+)
+
+// tagID returns id if set, defaultID otherwise
+func tagID(id, defaultID string) string {
+	if id == "" {
+		return defaultID
+	}
+	return id
+}
+
+// formatAmount formats amount with ',' as decimal separator. The separator
+// is always present, even for integral amounts.
+func formatAmount(amount domain.Decimal) string {
+	return amount.Text(',')
+}
+
+// wrapLines inserts CRLF after at most width bytes. A line break is never
+// inserted in front of ':' or '-', as the resulting line would be mistaken
+// for a tag or message boundary.
+func wrapLines(value []byte, width int) []byte {
+	var buf bytes.Buffer
+	for len(value) > width {
+		idx := width
+		for idx > 1 && (value[idx] == ':' || value[idx] == '-') {
+			idx--
+		}
+		buf.Write(value[:idx])
+		buf.WriteString(tagSeparatorSequence)
+		value = value[idx:]
+	}
+	buf.Write(value)
+	return buf.Bytes()
+}
@@ -0,0 +1,150 @@
+package swift
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// A Quirk repairs a known deviation of bank institutes from the MT940 format.
+// Quirks are only used in LenientMode and only for tags which failed to
+// unmarshal.
+type Quirk struct {
+	Name string
+	// TagID restricts the quirk to tags with the given prefix, e.g. ":61:".
+	// An empty TagID applies the quirk to all tags.
+	TagID string
+	// Repair returns the repaired tag and true, or false if the quirk does
+	// not apply to tag.
+	Repair func(tag []byte) ([]byte, bool)
+}
+
+func (q Quirk) appliesTo(tag []byte) bool {
+	return bytes.HasPrefix(tag, []byte(q.TagID))
+}
+
+// DefaultQuirks contains all quirks known to this package
+var DefaultQuirks = []Quirk{
+	IBANAccountQuirk,
+	StatementNumberQuirk,
+	MissingTransactionTypeQuirk,
+	UnstructuredDescriptionQuirk,
+	MessageKeyAdditionQuirk,
+}
+
+// IBANAccountQuirk repairs account tags which contain an IBAN instead of
+// BankID and AccountID. German IBANs are split into BankID and AccountID,
+// other IBANs are used as AccountID.
+var IBANAccountQuirk = Quirk{
+	Name:  "IBAN account",
+	TagID: ":25:",
+	Repair: func(tag []byte) ([]byte, bool) {
+		iban := bytes.TrimSpace(tag[len(":25:"):])
+		if !ibanPattern.Match(iban) {
+			return nil, false
+		}
+		repaired := []byte(":25:")
+		if bytes.HasPrefix(iban, []byte("DE")) && len(iban) == 22 {
+			repaired = append(repaired, iban[4:12]...)
+			repaired = append(repaired, '/')
+			return append(repaired, bytes.TrimLeft(iban[12:], "0")...), true
+		}
+		repaired = append(repaired, '/')
+		return append(repaired, iban...), true
+	},
+}
+
+var ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+
+// StatementNumberQuirk repairs statement numbers containing other characters
+// than digits and a single '/', e.g. "00001/" or "1 / 1". Statement numbers
+// without any digit are set to 0.
+var StatementNumberQuirk = Quirk{
+	Name:  "statement number",
+	TagID: ":28C:",
+	Repair: func(tag []byte) ([]byte, bool) {
+		fields := bytes.SplitN(tag[len(":28C:"):], []byte("/"), 2)
+		repaired := []byte(":28C:")
+		repaired = append(repaired, digitsOrZero(fields[0])...)
+		if len(fields) == 2 && len(digitsOnly(fields[1])) != 0 {
+			repaired = append(repaired, '/')
+			repaired = append(repaired, digitsOnly(fields[1])...)
+		}
+		return repaired, true
+	},
+}
+
+// MissingTransactionTypeQuirk repairs transaction tags where the transaction
+// type ('N', 'F' or 'S') in front of the booking key is missing.
+var MissingTransactionTypeQuirk = Quirk{
+	Name:  "missing transaction type",
+	TagID: ":61:",
+	Repair: func(tag []byte) ([]byte, bool) {
+		loc := transactionAmountPattern.FindSubmatchIndex(tag)
+		if loc == nil {
+			return nil, false
+		}
+		amountEnd := loc[3]
+		if next := tag[amountEnd]; next == 'N' || next == 'F' || next == 'S' {
+			return nil, false
+		}
+		var repaired []byte
+		repaired = append(repaired, tag[:amountEnd]...)
+		repaired = append(repaired, 'N')
+		return append(repaired, tag[amountEnd:]...), true
+	},
+}
+
+var transactionAmountPattern = regexp.MustCompile(`^:61:[0-9]{6,10}R?[CD][A-Z]?([0-9]+,[0-9]*)[A-Z]`)
+
+// UnstructuredDescriptionQuirk repairs descriptions which do not start with a
+// three digit transaction ID, as used by some savings banks for unstructured
+// descriptions. The transaction ID is set to 999, which marks unstructured
+// descriptions.
+var UnstructuredDescriptionQuirk = Quirk{
+	Name:  "unstructured description",
+	TagID: ":86:",
+	Repair: func(tag []byte) ([]byte, bool) {
+		value := tag[len(":86:"):]
+		if len(value) >= 3 && len(digitsOnly(value[:3])) == 3 {
+			return nil, false
+		}
+		repaired := []byte(":86:999")
+		return append(repaired, value...), true
+	},
+}
+
+// MessageKeyAdditionQuirk removes non numeric message key additions (?34)
+// from descriptions.
+var MessageKeyAdditionQuirk = Quirk{
+	Name:  "message key addition",
+	TagID: ":86:",
+	Repair: func(tag []byte) ([]byte, bool) {
+		loc := messageKeyAdditionPattern.FindIndex(tag)
+		if loc == nil {
+			return nil, false
+		}
+		var repaired []byte
+		repaired = append(repaired, tag[:loc[0]]...)
+		return append(repaired, tag[loc[1]:]...), true
+	},
+}
+
+var messageKeyAdditionPattern = regexp.MustCompile(`\?34[^?]*[^0-9?\r\n][^?]*`)
+
+func digitsOnly(value []byte) []byte {
+	var digits []byte
+	for _, b := range value {
+		if '0' <= b && b <= '9' {
+			digits = append(digits, b)
+		}
+	}
+	return digits
+}
+
+func digitsOrZero(value []byte) []byte {
+	digits := digitsOnly(value)
+	if len(digits) == 0 {
+		return []byte{'0'}
+	}
+	return digits
+}
@@ -36,107 +36,107 @@ func TestAccountTagUnmarshal(t *testing.T) {
 	}
 }
 
-func TestTransactionTagUnmarshal(t *testing.T) {
-	tests := []struct {
-		description    string
-		marshaledValue string
-		expectedTag    *TransactionTag
-	}{
-		{
-			"All attributes set, booking in next month",
-			":61:1511301202DR4,52N024NONREF//ABC\r\n/DEF",
-			&TransactionTag{
-				Tag:                   ":61:",
-				ValutaDate:            domain.ShortDate{Time: domain.Date(2015, time.November, 30, time.Local).Truncate(24 * time.Hour)},
-				BookingDate:           domain.ShortDate{Time: domain.Date(2015, time.December, 2, time.Local).Truncate(24 * time.Hour)},
-				DebitCreditIndicator:  "D",
-				CurrencyKind:          "R",
-				Amount:                4.52,
-				BookingKey:            "024",
-				Reference:             "NONREF",
-				BankReference:         "ABC",
-				AdditionalInformation: "DEF",
-			},
+var transactionTagTests = []struct {
+	description    string
+	marshaledValue string
+	expectedTag    *TransactionTag
+}{
+	{
+		"All attributes set, booking in next month",
+		":61:1511301202DR4,52N024NONREF//ABC\r\n/DEF",
+		&TransactionTag{
+			Tag:                   ":61:",
+			ValutaDate:            domain.ShortDate{Time: domain.Date(2015, time.November, 30, time.Local).Truncate(24 * time.Hour)},
+			BookingDate:           domain.ShortDate{Time: domain.Date(2015, time.December, 2, time.Local).Truncate(24 * time.Hour)},
+			DebitCreditIndicator:  "D",
+			CurrencyKind:          "R",
+			Amount:                domain.NewDecimal(452, 2),
+			BookingKey:            "024",
+			Reference:             "NONREF",
+			BankReference:         "ABC",
+			AdditionalInformation: "DEF",
 		},
-		{
-			"All attributes set, booking in new year",
-			":61:1512300102DR4,52N024NONREF//ABC\r\n/DEF",
-			&TransactionTag{
-				Tag:                   ":61:",
-				ValutaDate:            domain.ShortDate{Time: domain.Date(2015, time.December, 30, time.Local).Truncate(24 * time.Hour)},
-				BookingDate:           domain.ShortDate{Time: domain.Date(2016, time.January, 2, time.Local).Truncate(24 * time.Hour)},
-				DebitCreditIndicator:  "D",
-				CurrencyKind:          "R",
-				Amount:                4.52,
-				BookingKey:            "024",
-				Reference:             "NONREF",
-				BankReference:         "ABC",
-				AdditionalInformation: "DEF",
-			},
+	},
+	{
+		"All attributes set, booking in new year",
+		":61:1512300102DR4,52N024NONREF//ABC\r\n/DEF",
+		&TransactionTag{
+			Tag:                   ":61:",
+			ValutaDate:            domain.ShortDate{Time: domain.Date(2015, time.December, 30, time.Local).Truncate(24 * time.Hour)},
+			BookingDate:           domain.ShortDate{Time: domain.Date(2016, time.January, 2, time.Local).Truncate(24 * time.Hour)},
+			DebitCreditIndicator:  "D",
+			CurrencyKind:          "R",
+			Amount:                domain.NewDecimal(452, 2),
+			BookingKey:            "024",
+			Reference:             "NONREF",
+			BankReference:         "ABC",
+			AdditionalInformation: "DEF",
 		},
-		{
-			"All attributes set",
-			":61:1508010803DR4,52N024NONREF//ABC\r\n/DEF",
-			&TransactionTag{
-				Tag:                   ":61:",
-				ValutaDate:            domain.ShortDate{Time: domain.Date(2015, 8, 1, time.Local).Truncate(24 * time.Hour)},
-				BookingDate:           domain.ShortDate{Time: domain.Date(2015, 8, 3, time.Local).Truncate(24 * time.Hour)},
-				DebitCreditIndicator:  "D",
-				CurrencyKind:          "R",
-				Amount:                4.52,
-				BookingKey:            "024",
-				Reference:             "NONREF",
-				BankReference:         "ABC",
-				AdditionalInformation: "DEF",
-			},
+	},
+	{
+		"All attributes set",
+		":61:1508010803DR4,52N024NONREF//ABC\r\n/DEF",
+		&TransactionTag{
+			Tag:                   ":61:",
+			ValutaDate:            domain.ShortDate{Time: domain.Date(2015, 8, 1, time.Local).Truncate(24 * time.Hour)},
+			BookingDate:           domain.ShortDate{Time: domain.Date(2015, 8, 3, time.Local).Truncate(24 * time.Hour)},
+			DebitCreditIndicator:  "D",
+			CurrencyKind:          "R",
+			Amount:                domain.NewDecimal(452, 2),
+			BookingKey:            "024",
+			Reference:             "NONREF",
+			BankReference:         "ABC",
+			AdditionalInformation: "DEF",
 		},
-		{
-			"All attributes set except 'AdditionalInformation'",
-			":61:1508010803DR4,52N024NONREF//ABC",
-			&TransactionTag{
-				Tag:                  ":61:",
-				ValutaDate:           domain.ShortDate{Time: domain.Date(2015, 8, 1, time.Local).Truncate(24 * time.Hour)},
-				BookingDate:          domain.ShortDate{Time: domain.Date(2015, 8, 3, time.Local).Truncate(24 * time.Hour)},
-				DebitCreditIndicator: "D",
-				CurrencyKind:         "R",
-				Amount:               4.52,
-				BookingKey:           "024",
-				Reference:            "NONREF",
-				BankReference:        "ABC",
-			},
+	},
+	{
+		"All attributes set except 'AdditionalInformation'",
+		":61:1508010803DR4,52N024NONREF//ABC",
+		&TransactionTag{
+			Tag:                  ":61:",
+			ValutaDate:           domain.ShortDate{Time: domain.Date(2015, 8, 1, time.Local).Truncate(24 * time.Hour)},
+			BookingDate:          domain.ShortDate{Time: domain.Date(2015, 8, 3, time.Local).Truncate(24 * time.Hour)},
+			DebitCreditIndicator: "D",
+			CurrencyKind:         "R",
+			Amount:               domain.NewDecimal(452, 2),
+			BookingKey:           "024",
+			Reference:            "NONREF",
+			BankReference:        "ABC",
 		},
-		{
-			"All attributes set except 'BankReference'",
-			":61:1508010803DR4,52N024NONREF\r\n/DEF",
-			&TransactionTag{
-				Tag:                   ":61:",
-				ValutaDate:            domain.ShortDate{Time: domain.Date(2015, 8, 1, time.Local).Truncate(24 * time.Hour)},
-				BookingDate:           domain.ShortDate{Time: domain.Date(2015, 8, 3, time.Local).Truncate(24 * time.Hour)},
-				DebitCreditIndicator:  "D",
-				CurrencyKind:          "R",
-				Amount:                4.52,
-				BookingKey:            "024",
-				Reference:             "NONREF",
-				AdditionalInformation: "DEF",
-			},
+	},
+	{
+		"All attributes set except 'BankReference'",
+		":61:1508010803DR4,52N024NONREF\r\n/DEF",
+		&TransactionTag{
+			Tag:                   ":61:",
+			ValutaDate:            domain.ShortDate{Time: domain.Date(2015, 8, 1, time.Local).Truncate(24 * time.Hour)},
+			BookingDate:           domain.ShortDate{Time: domain.Date(2015, 8, 3, time.Local).Truncate(24 * time.Hour)},
+			DebitCreditIndicator:  "D",
+			CurrencyKind:          "R",
+			Amount:                domain.NewDecimal(452, 2),
+			BookingKey:            "024",
+			Reference:             "NONREF",
+			AdditionalInformation: "DEF",
 		},
-		{
-			"All attributes set except 'AdditionalInformation' and 'BankReference'",
-			":61:1508010803DR4,52N024NONREF",
-			&TransactionTag{
-				Tag:                  ":61:",
-				ValutaDate:           domain.ShortDate{Time: domain.Date(2015, 8, 1, time.Local).Truncate(24 * time.Hour)},
-				BookingDate:          domain.ShortDate{Time: domain.Date(2015, 8, 3, time.Local).Truncate(24 * time.Hour)},
-				DebitCreditIndicator: "D",
-				CurrencyKind:         "R",
-				Amount:               4.52,
-				BookingKey:           "024",
-				Reference:            "NONREF",
-			},
+	},
+	{
+		"All attributes set except 'AdditionalInformation' and 'BankReference'",
+		":61:1508010803DR4,52N024NONREF",
+		&TransactionTag{
+			Tag:                  ":61:",
+			ValutaDate:           domain.ShortDate{Time: domain.Date(2015, 8, 1, time.Local).Truncate(24 * time.Hour)},
+			BookingDate:          domain.ShortDate{Time: domain.Date(2015, 8, 3, time.Local).Truncate(24 * time.Hour)},
+			DebitCreditIndicator: "D",
+			CurrencyKind:         "R",
+			Amount:               domain.NewDecimal(452, 2),
+			BookingKey:           "024",
+			Reference:            "NONREF",
 		},
-	}
+	},
+}
 
-	for _, test := range tests {
+func TestTransactionTagUnmarshal(t *testing.T) {
+	for _, test := range transactionTagTests {
 		tag := &TransactionTag{}
 
 		err := tag.Unmarshal([]byte(test.marshaledValue))
@@ -154,6 +154,28 @@ func TestTransactionTagUnmarshal(t *testing.T) {
 	}
 }
 
+func TestTransactionTagUnmarshalAdditionalInformationFirst(t *testing.T) {
+	tag := &TransactionTag{}
+
+	err := tag.Unmarshal([]byte(":61:1508010803DR4,52N024NONREF\r\n/DEF//ABC"))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	if tag.Reference != "NONREF" {
+		t.Logf("Expected reference to equal %q, got %q\n", "NONREF", tag.Reference)
+		t.Fail()
+	}
+	if tag.BankReference != "DEF" {
+		t.Logf("Expected bank reference to equal %q, got %q\n", "DEF", tag.BankReference)
+		t.Fail()
+	}
+	if tag.AdditionalInformation != "ABC" {
+		t.Logf("Expected additional information to equal %q, got %q\n", "ABC", tag.AdditionalInformation)
+		t.Fail()
+	}
+}
+
 func TestTransactionTagOrder(t *testing.T) {
 	testdata := "\r\n:20:HBCIKTOLST"
 	for i := 0; i < 10; i++ {
@@ -216,3 +238,180 @@ func TestTransactionWithRedefineCustomDataTag(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestTransactionTagMarshal(t *testing.T) {
+	for _, test := range transactionTagTests {
+		marshaled, err := test.expectedTag.Marshal()
+
+		if err != nil {
+			t.Logf("%s: Expected no error, got %T:%v\n", test.description, err, err)
+			t.Fail()
+		}
+
+		if string(marshaled) != test.marshaledValue {
+			t.Logf("%s: Expected marshaled value to equal\n%q\n\tgot\n%q\n", test.description, test.marshaledValue, marshaled)
+			t.Fail()
+		}
+	}
+}
+
+func TestAccountTagMarshal(t *testing.T) {
+	test := ":25:12345678/100000000"
+
+	tag := &AccountTag{}
+	err := tag.Unmarshal([]byte(test))
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	marshaled, err := tag.Marshal()
+
+	if err != nil {
+		t.Logf("Expected no error, got %T:%v\n", err, err)
+		t.Fail()
+	}
+
+	if string(marshaled) != test {
+		t.Logf("Expected marshaled value to equal\n%q\n\tgot\n%q\n", test, marshaled)
+		t.Fail()
+	}
+}
+
+func TestBalanceTagMarshal(t *testing.T) {
+	tests := []string{
+		":60F:C181105EUR1234,56",
+		":62M:D190125EUR50,",
+		":64:C150801USD0,5",
+	}
+
+	for _, test := range tests {
+		tag := &BalanceTag{}
+		err := tag.Unmarshal([]byte(test))
+		if err != nil {
+			t.Fatalf("Expected no error, got %T:%v\n", err, err)
+		}
+
+		marshaled, err := tag.Marshal()
+
+		if err != nil {
+			t.Logf("Expected no error, got %T:%v\n", err, err)
+			t.Fail()
+		}
+
+		if string(marshaled) != test {
+			t.Logf("Expected marshaled value to equal\n%q\n\tgot\n%q\n", test, marshaled)
+			t.Fail()
+		}
+	}
+}
+
+func TestMT940MarshalRoundTrip(t *testing.T) {
+	tests := []struct {
+		description string
+		transaction string
+	}{
+		{
+			"bank reference before additional information",
+			"\r\n:61:1811051105DR50,NMSCNONREF//BANKREF" +
+				"\r\n/OCMT/EUR50,/CHGS/   0,/",
+		},
+		{
+			"additional information before bank reference",
+			"\r\n:61:1811051105DR50,NMSCNONREF" +
+				"\r\n/OCMT/EUR50,//CHGS/   0,/",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testdata := "\r\n:20:HBCIKTOLST"
+			for i := 0; i < 3; i++ {
+				testdata += "\r\n:25:12345678/1234123456" +
+					"\r\n:28C:0" +
+					"\r\n:60F:C181105EUR1234,56" +
+					test.transaction +
+					"\r\n:86:177?00SB-SEPA-Ueberweisung?20" + strconv.Itoa(i+10) + "                                                                                                                                                 ?30?31?32Max Meier                  ?33                           ?34000" +
+					"\r\n:62F:C190125EUR1234,56"
+			}
+			testdata += "\r\n-"
+
+			expected := &MT940{}
+			err := expected.Unmarshal([]byte(testdata))
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			marshaled, err := expected.Marshal()
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			actual := &MT940{}
+			err = actual.Unmarshal(marshaled)
+			if err != nil {
+				t.Logf("Expected no error, got %T:%v\n", err, err)
+				t.Logf("Marshaled value:\n%q\n", marshaled)
+				t.Fail()
+			}
+
+			if !reflect.DeepEqual(expected, actual) {
+				pretty.Ldiff(t, expected, actual)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestNewMT940(t *testing.T) {
+	account := domain.AccountConnection{BankID: "12345678", AccountID: "1234123456", CountryCode: 280}
+	startingBalance := domain.Balance{
+		Amount:           domain.Amount{Amount: domain.NewDecimal(-1005, 1), Currency: "EUR"},
+		TransmissionDate: domain.Date(2018, time.November, 5, time.UTC).Time,
+	}
+	closingBalance := domain.Balance{
+		Amount:           domain.Amount{Amount: domain.NewDecimal(13995, 1), Currency: "EUR"},
+		TransmissionDate: domain.Date(2018, time.November, 6, time.UTC).Time,
+	}
+	transactions := []domain.AccountTransaction{
+		{
+			Account:              account,
+			Amount:               domain.Amount{Amount: domain.NewDecimalFromInt(1500), Currency: "EUR"},
+			ValutaDate:           domain.Date(2018, time.November, 5, time.UTC).Time,
+			BookingDate:          domain.Date(2018, time.November, 6, time.UTC).Time,
+			BookingText:          "SEPA-Gutschrift",
+			BankID:               "87654321",
+			AccountID:            "DE89370400440532013000",
+			Name:                 "Max Mustermann und Erika Mustermann",
+			Purpose:              "Rechnung 2018-11-42 vom 01.11.2018 vielen Dank fuer Ihren Einkauf",
+			TransactionID:        166,
+			AccountBalanceBefore: startingBalance,
+			AccountBalanceAfter:  closingBalance,
+		},
+	}
+
+	mt := NewMT940("STARTUMS", account, 42, startingBalance, closingBalance, transactions)
+
+	marshaled, err := mt.Marshal()
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	actual := &MT940{}
+	err = actual.Unmarshal(marshaled)
+	if err != nil {
+		t.Logf("Marshaled value:\n%q\n", marshaled)
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	if actual.StatementNumber.Number != 42 {
+		t.Logf("Expected statement number to equal 42, got %d\n", actual.StatementNumber.Number)
+		t.Fail()
+	}
+
+	actualTransactions := actual.AccountTransactions()
+
+	if !reflect.DeepEqual(transactions, actualTransactions) {
+		pretty.Ldiff(t, transactions, actualTransactions)
+		t.Fail()
+	}
+}
@@ -3,107 +3,336 @@ package swift
 import (
 	"bytes"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
+// UnmarshalMode defines how malformed data are handled when unmarshaling a
+// MT940
+type UnmarshalMode int
+
+const (
+	// DefaultMode fails on the first malformed tag
+	DefaultMode UnmarshalMode = iota
+	// LenientMode skips malformed tags, records a Diagnostic for each and
+	// returns everything it could parse. Before a tag is skipped, all
+	// configured quirks are tried to repair it.
+	LenientMode
+	// StrictMode fails like DefaultMode on malformed tags and additionally
+	// validates field lengths, currencies and balances.
+	StrictMode
+)
+
+// UnmarshalOptions configures MT940.UnmarshalWithOptions
+type UnmarshalOptions struct {
+	Mode UnmarshalMode
+	// Quirks are used in LenientMode to repair malformed tags. If nil,
+	// DefaultQuirks are used.
+	Quirks []Quirk
+}
+
+// A Diagnostic describes a problem found while unmarshaling a S.W.I.F.T.
+// message. Offset is the byte offset of the tag within the message.
+type Diagnostic struct {
+	Tag     string
+	Offset  int
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s at offset %d: %s", d.Tag, d.Offset, d.Message)
+}
+
+// Diagnostics represents a list of Diagnostic. It implements the error
+// interface to be returned from StrictMode validations.
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Error() string {
+	messages := make([]string, len(d))
+	for i, diagnostic := range d {
+		messages[i] = diagnostic.String()
+	}
+	return strings.Join(messages, "\n")
+}
+
 // Unmarshal unmarshals value into m
 func (m *MT940) Unmarshal(value []byte) error {
+	_, err := m.UnmarshalWithOptions(value, UnmarshalOptions{})
+	return err
+}
+
+// UnmarshalWithOptions unmarshals value into m according to the provided
+// options. In LenientMode the returned Diagnostics contain all problems m
+// recovered from. In StrictMode all validation problems are returned as
+// Diagnostics error.
+func (m *MT940) UnmarshalWithOptions(value []byte, options UnmarshalOptions) (Diagnostics, error) {
 	tagExtractor := newTagExtractor(value)
 	tags, err := tagExtractor.Extract()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(tags) == 0 {
-		return fmt.Errorf("Malformed marshaled value")
+		return nil, fmt.Errorf("Malformed marshaled value")
+	}
+	if options.Quirks == nil {
+		options.Quirks = DefaultQuirks
 	}
+	u := &mt940Unmarshaler{options: options, offsets: make(map[string]int)}
 	balanceTagOpen := false
+	descriptionAllowed := false
+	offset := 0
 	for _, tag := range tags {
+		if idx := bytes.Index(value[offset:], tag); idx != -1 {
+			offset += idx
+		}
+		tagOffset := offset
+		offset += len(tag)
+		if tagID, err := extractTagID(tag); err == nil {
+			u.offsets[string(tagID)] = tagOffset
+		}
 
 		switch {
 		case bytes.HasPrefix(tag, []byte(":20:")):
 			m.JobReference = &AlphaNumericTag{}
-			err = m.JobReference.Unmarshal(tag)
-			if err != nil {
-				return err
+			if !u.unmarshal(m.JobReference, tag, tagOffset) {
+				m.JobReference = nil
 			}
 		case bytes.HasPrefix(tag, []byte(":21:")):
 			m.Reference = &AlphaNumericTag{}
-			err = m.Reference.Unmarshal(tag)
-			if err != nil {
-				return err
+			if !u.unmarshal(m.Reference, tag, tagOffset) {
+				m.Reference = nil
 			}
 		case bytes.HasPrefix(tag, []byte(":25:")):
 			m.Account = &AccountTag{}
-			err = m.Account.Unmarshal(tag)
-			if err != nil {
-				return err
+			if !u.unmarshal(m.Account, tag, tagOffset) {
+				m.Account = nil
 			}
 		case bytes.HasPrefix(tag, []byte(":28C:")):
 			m.StatementNumber = &StatementNumberTag{}
-			err = m.StatementNumber.Unmarshal(tag)
-			if err != nil {
-				return err
+			if !u.unmarshal(m.StatementNumber, tag, tagOffset) {
+				m.StatementNumber = nil
 			}
 		case bytes.HasPrefix(tag, []byte(":60")):
 			m.StartingBalance = &BalanceTag{}
-			err = m.StartingBalance.Unmarshal(tag)
-			if err != nil {
-				return errors.WithMessage(err, "unmarshal starting balance tag")
+			if !u.unmarshal(m.StartingBalance, tag, tagOffset) {
+				m.StartingBalance = nil
 			}
+			u.wrapErr("unmarshal starting balance tag")
 			balanceTagOpen = true
 		case bytes.HasPrefix(tag, []byte(":62")):
-
 			m.ClosingBalance = &BalanceTag{}
-			err = m.ClosingBalance.Unmarshal(tag)
-			if err != nil {
-				return errors.WithMessage(err, "unmarshal closing balance tag")
+			if !u.unmarshal(m.ClosingBalance, tag, tagOffset) {
+				m.ClosingBalance = nil
 			}
-
+			u.wrapErr("unmarshal closing balance tag")
 			balanceTagOpen = false
 		case bytes.HasPrefix(tag, []byte(":64:")):
 			m.CurrentValutaBalance = &BalanceTag{}
-			err = m.CurrentValutaBalance.Unmarshal(tag)
-			if err != nil {
-				return errors.WithMessage(err, "unmarshal current valuta balance tag")
+			if !u.unmarshal(m.CurrentValutaBalance, tag, tagOffset) {
+				m.CurrentValutaBalance = nil
 			}
+			u.wrapErr("unmarshal current valuta balance tag")
 		case bytes.HasPrefix(tag, []byte(":65:")):
 			m.FutureValutaBalance = &BalanceTag{}
-			err = m.FutureValutaBalance.Unmarshal(tag)
-			if err != nil {
-				return errors.WithMessage(err, "unmarshal future valuta balance tag")
+			if !u.unmarshal(m.FutureValutaBalance, tag, tagOffset) {
+				m.FutureValutaBalance = nil
 			}
+			u.wrapErr("unmarshal future valuta balance tag")
 		case bytes.HasPrefix(tag, []byte(":61:")):
-
 			transaction := &TransactionTag{}
-
-			err = transaction.Unmarshal(tag)
-			if err != nil {
-				return err
+			descriptionAllowed = u.unmarshal(transaction, tag, tagOffset)
+			if descriptionAllowed {
+				m.Transactions = append(m.Transactions, &TransactionSequence{Transaction: transaction})
+				u.validateTransaction(transaction, tagOffset)
 			}
-			m.Transactions = append(m.Transactions, &TransactionSequence{Transaction: transaction})
 		case bytes.HasPrefix(tag, []byte(":86:")):
 			customField := &CustomFieldTag{}
-			err = customField.Unmarshal(tag)
-			if err != nil {
-				return err
+			if !u.unmarshal(customField, tag, tagOffset) {
+				break
 			}
+			u.validateCustomField(customField, tagOffset)
 			if balanceTagOpen {
 				indexLastSliceitem := len(m.Transactions) - 1
 				if indexLastSliceitem < 0 {
-					return errors.New("Unexpected CustomTag before first TransactionTag")
+					u.fail(tag, tagOffset, errors.New("Unexpected CustomTag before first TransactionTag"))
+					break
+				}
+				if !descriptionAllowed {
+					u.fail(tag, tagOffset, errors.New("Unexpected CustomTag: TransactionTag was skipped"))
+					break
 				}
 				if m.Transactions[indexLastSliceitem].Description != nil {
-					return errors.Errorf("Unexpected CustomTag: CustomTag would replace Description of %v", m.Transactions[indexLastSliceitem])
+					u.fail(tag, tagOffset, errors.Errorf("Unexpected CustomTag: CustomTag would replace Description of %v", m.Transactions[indexLastSliceitem]))
+					break
 				}
 				m.Transactions[indexLastSliceitem].Description = customField
 			} else {
 				m.CustomField = customField
 			}
 		default:
-			return fmt.Errorf("Malformed marshaled value")
+			u.fail(tag, tagOffset, fmt.Errorf("Malformed marshaled value"))
+		}
+		if u.err != nil {
+			return nil, u.err
+		}
+	}
+	if options.Mode == StrictMode {
+		u.validate(m)
+		if len(u.diagnostics) != 0 {
+			return nil, u.diagnostics
 		}
 	}
+	return u.diagnostics, nil
+}
 
-	return nil
+type tagUnmarshaler interface {
+	Unmarshal([]byte) error
+}
+
+type mt940Unmarshaler struct {
+	options     UnmarshalOptions
+	diagnostics Diagnostics
+	err         error
+	// offsets maps tag ids to the offset of their last occurrence
+	offsets map[string]int
+}
+
+// unmarshal unmarshals tag into t. It returns false if the tag could not be
+// unmarshaled, even with the help of quirks.
+func (u *mt940Unmarshaler) unmarshal(t tagUnmarshaler, tag []byte, offset int) bool {
+	err := t.Unmarshal(tag)
+	if err == nil {
+		return true
+	}
+	if u.options.Mode == LenientMode {
+		for _, quirk := range u.options.Quirks {
+			if !quirk.appliesTo(tag) {
+				continue
+			}
+			repaired, ok := quirk.Repair(tag)
+			if !ok {
+				continue
+			}
+			// Reset t, as the failed attempt may have filled it partially
+			v := reflect.ValueOf(t).Elem()
+			v.Set(reflect.Zero(v.Type()))
+			if t.Unmarshal(repaired) == nil {
+				u.addDiagnostic(tag, offset, fmt.Sprintf("repaired by quirk %q: %v", quirk.Name, err))
+				return true
+			}
+		}
+	}
+	u.fail(tag, offset, err)
+	return false
+}
+
+// fail records err as diagnostic in LenientMode and as error otherwise
+func (u *mt940Unmarshaler) fail(tag []byte, offset int, err error) {
+	if u.options.Mode == LenientMode {
+		u.addDiagnostic(tag, offset, err.Error())
+		return
+	}
+	if u.err == nil {
+		u.err = err
+	}
+}
+
+func (u *mt940Unmarshaler) wrapErr(message string) {
+	if u.err != nil {
+		u.err = errors.WithMessage(u.err, message)
+	}
+}
+
+func (u *mt940Unmarshaler) addDiagnostic(tag []byte, offset int, message string) {
+	tagID, err := extractTagID(tag)
+	if err != nil {
+		tagID = tag
+	}
+	u.diagnostics = append(u.diagnostics, Diagnostic{
+		Tag:     string(tagID),
+		Offset:  offset,
+		Message: message,
+	})
+}
+
+// validate performs the StrictMode validations of m which span more than one
+// tag.
+func (u *mt940Unmarshaler) validate(m *MT940) {
+	if m.JobReference == nil {
+		u.addDiagnostic([]byte(":20:"), 0, "missing job reference")
+	} else {
+		u.validateLength(":20:", u.offsets[":20:"], "job reference", m.JobReference.Val(), 16)
+	}
+	if m.Reference != nil {
+		u.validateLength(":21:", u.offsets[":21:"], "reference", m.Reference.Val(), 16)
+	}
+	if m.Account == nil {
+		u.addDiagnostic([]byte(":25:"), 0, "missing account")
+	} else {
+		u.validateLength(":25:", u.offsets[":25:"], "account", m.Account.BankID+"/"+m.Account.AccountID, 35)
+	}
+	if m.StatementNumber == nil {
+		u.addDiagnostic([]byte(":28C:"), 0, "missing statement number")
+	} else if m.StatementNumber.Number > 99999 || m.StatementNumber.SheetNumber > 99999 {
+		u.addDiagnostic([]byte(":28C:"), u.offsets[":28C:"], "statement or sheet number exceeds 5 digits")
+	}
+	if m.StartingBalance == nil {
+		u.addDiagnostic([]byte(":60F:"), 0, "missing starting balance")
+	}
+	if m.ClosingBalance == nil {
+		u.addDiagnostic([]byte(":62F:"), 0, "missing closing balance")
+	}
+	if m.StartingBalance == nil || m.ClosingBalance == nil {
+		return
+	}
+	for _, balance := range []*BalanceTag{m.ClosingBalance, m.CurrentValutaBalance, m.FutureValutaBalance} {
+		if balance != nil && balance.Currency != m.StartingBalance.Currency {
+			u.addDiagnostic([]byte(balance.Tag), u.offsets[balance.Tag], fmt.Sprintf("currency %s differs from starting balance currency %s", balance.Currency, m.StartingBalance.Currency))
+		}
+	}
+	sum, err := m.calculatedClosingBalance()
+	closing := m.ClosingBalance.Balance().Amount.Amount
+	if err != nil {
+		u.addDiagnostic(
+			[]byte(m.ClosingBalance.Tag), u.offsets[m.ClosingBalance.Tag],
+			fmt.Sprintf("closing balance %s can not be calculated: %v", formatAmount(closing), err),
+		)
+	} else if !sum.Equal(closing) {
+		u.addDiagnostic(
+			[]byte(m.ClosingBalance.Tag), u.offsets[m.ClosingBalance.Tag],
+			fmt.Sprintf("closing balance %s does not match starting balance plus transactions %s", formatAmount(closing), formatAmount(sum)),
+		)
+	}
+}
+
+func (u *mt940Unmarshaler) validateTransaction(t *TransactionTag, offset int) {
+	if u.options.Mode != StrictMode {
+		return
+	}
+	u.validateLength(t.Tag, offset, "reference", t.Reference, 16)
+	u.validateLength(t.Tag, offset, "bank reference", t.BankReference, 16)
+	u.validateLength(t.Tag, offset, "additional information", t.AdditionalInformation, 34)
+}
+
+func (u *mt940Unmarshaler) validateCustomField(c *CustomFieldTag, offset int) {
+	if u.options.Mode != StrictMode {
+		return
+	}
+	u.validateLength(c.Tag, offset, "booking text", c.BookingText, 27)
+	u.validateLength(c.Tag, offset, "primanoten number", c.PrimanotenNumber, 10)
+	for _, purpose := range append(c.Purpose, c.Purpose2...) {
+		u.validateLength(c.Tag, offset, "purpose", purpose, 27)
+	}
+	u.validateLength(c.Tag, offset, "bank id", c.BankID, 12)
+	u.validateLength(c.Tag, offset, "account id", c.AccountID, 34)
+	// Name consists of two fields with 27 characters each, joined by a space
+	u.validateLength(c.Tag, offset, "name", c.Name, 55)
+}
+
+func (u *mt940Unmarshaler) validateLength(tag string, offset int, field, value string, maxLength int) {
+	if length := len([]rune(value)); length > maxLength {
+		u.addDiagnostic([]byte(tag), offset, fmt.Sprintf("%s exceeds %d characters: %d", field, maxLength, length))
+	}
 }
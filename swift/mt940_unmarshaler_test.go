@@ -0,0 +1,161 @@
+package swift
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMT940UnmarshalWithOptionsLenientMode(t *testing.T) {
+	testdata := "\r\n:20:STARTUMS" +
+		"\r\n:25:DE89370400440532013000" +
+		"\r\n:28C:00001/" +
+		"\r\n:60F:C181105EUR1000,00" +
+		"\r\n:61:1811051105RC10,NMSCNONREF" +
+		"\r\n:86:166?00Storno?20first" +
+		"\r\n:61:1811061106C20,NMSCNONREF" +
+		"\r\n:86:Sparkasse Freitext ohne Struktur" +
+		"\r\n:61:1813991106D30,NMSCNONREF" +
+		"\r\n:86:177?00Lastschrift?20skipped" +
+		"\r\n:61:1811071107D40,MSCNONREF" +
+		"\r\n:86:177?00Lastschrift?20third?34ABC" +
+		"\r\n:34F:EURD0," +
+		"\r\n:62F:C181107EUR970,00" +
+		"\r\n-"
+
+	mt := &MT940{}
+	diagnostics, err := mt.UnmarshalWithOptions([]byte(testdata), UnmarshalOptions{Mode: LenientMode})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	expectedDiagnostics := []string{":25:", ":28C:", ":86:", ":61:", ":86:", ":61:", ":86:", ":34F:"}
+	var diagnosticTags []string
+	for _, d := range diagnostics {
+		diagnosticTags = append(diagnosticTags, d.Tag)
+		if !strings.HasPrefix(testdata[d.Offset:], d.Tag) {
+			t.Logf("Expected offset %d to point to tag %s, got %q\n", d.Offset, d.Tag, testdata[d.Offset:d.Offset+5])
+			t.Fail()
+		}
+	}
+	if !reflect.DeepEqual(expectedDiagnostics, diagnosticTags) {
+		t.Logf("Expected diagnostics for tags\n%v\n\tgot\n%v\n", expectedDiagnostics, diagnostics)
+		t.Fail()
+	}
+
+	if mt.Account == nil || mt.Account.BankID != "37040044" || mt.Account.AccountID != "532013000" {
+		t.Logf("Expected account to be repaired from IBAN, got %#v\n", mt.Account)
+		t.Fail()
+	}
+
+	transactions := mt.AccountTransactions()
+	expectedAmounts := []string{"-10", "20", "-40"}
+	var amounts []string
+	for _, tr := range transactions {
+		amounts = append(amounts, tr.Amount.Amount.String())
+	}
+	if !reflect.DeepEqual(expectedAmounts, amounts) {
+		t.Logf("Expected amounts to equal %v, got %v\n", expectedAmounts, amounts)
+		t.Fail()
+	}
+	if len(transactions) == 3 {
+		if transactions[1].Purpose != "Sparkasse Freitext ohne Struktur" {
+			t.Logf("Expected unstructured purpose, got %q\n", transactions[1].Purpose)
+			t.Fail()
+		}
+		if transactions[2].Purpose != "third" {
+			t.Logf("Expected purpose to equal %q, got %q\n", "third", transactions[2].Purpose)
+			t.Fail()
+		}
+	}
+}
+
+func TestMT940UnmarshalWithOptionsDefaultMode(t *testing.T) {
+	testdata := "\r\n:20:STARTUMS" +
+		"\r\n:25:12345678/1234123456" +
+		"\r\n:28C:1" +
+		"\r\n:60F:C181105EUR1000,00" +
+		"\r\n:61:1813991106D30,NMSCNONREF" +
+		"\r\n:62F:C181107EUR970,00" +
+		"\r\n-"
+
+	mt := &MT940{}
+	_, err := mt.UnmarshalWithOptions([]byte(testdata), UnmarshalOptions{Mode: DefaultMode})
+
+	if err == nil {
+		t.Logf("Expected error for malformed transaction tag, got nil\n")
+		t.Fail()
+	}
+}
+
+func TestMT940UnmarshalWithOptionsStrictMode(t *testing.T) {
+	tests := []struct {
+		description         string
+		marshaledValue      string
+		expectedDiagnostics []string
+	}{
+		{
+			"valid statement",
+			"\r\n:20:STARTUMS" +
+				"\r\n:25:12345678/1234123456" +
+				"\r\n:28C:1" +
+				"\r\n:60F:C181105EUR10,00" +
+				"\r\n:61:1811051105D30,10NMSCNONREF" +
+				"\r\n:86:177?00Lastschrift?20purpose" +
+				"\r\n:61:1811051105C0,20NMSCNONREF" +
+				"\r\n:62F:D181105EUR19,90" +
+				"\r\n-",
+			nil,
+		},
+		{
+			"balance mismatch and long fields",
+			"\r\n:20:STARTUMS" +
+				"\r\n:25:12345678/1234123456" +
+				"\r\n:28C:1" +
+				"\r\n:60F:C181105EUR10,00" +
+				"\r\n:61:1811051105D30,NMSCNONREF" +
+				"\r\n:86:177?00Lastschrift?20this purpose is way too long for a subfield" +
+				"\r\n:62F:C181105EUR20,00" +
+				"\r\n:64:C181105USD20,00" +
+				"\r\n-",
+			[]string{":86:", ":64:", ":62F:"},
+		},
+		{
+			"missing mandatory tags",
+			"\r\n:20:STARTUMS" +
+				"\r\n:25:12345678/1234123456" +
+				"\r\n:62F:C181105EUR20,00" +
+				"\r\n-",
+			[]string{":28C:", ":60F:"},
+		},
+	}
+
+	for _, test := range tests {
+		mt := &MT940{}
+		_, err := mt.UnmarshalWithOptions([]byte(test.marshaledValue), UnmarshalOptions{Mode: StrictMode})
+
+		if test.expectedDiagnostics == nil {
+			if err != nil {
+				t.Logf("%s: Expected no error, got %T:%v\n", test.description, err, err)
+				t.Fail()
+			}
+			continue
+		}
+
+		diagnostics, ok := err.(Diagnostics)
+		if !ok {
+			t.Logf("%s: Expected error to be Diagnostics, got %T:%v\n", test.description, err, err)
+			t.Fail()
+			continue
+		}
+		var diagnosticTags []string
+		for _, d := range diagnostics {
+			diagnosticTags = append(diagnosticTags, d.Tag)
+		}
+		if !reflect.DeepEqual(test.expectedDiagnostics, diagnosticTags) {
+			t.Logf("%s: Expected diagnostics for tags\n%v\n\tgot\n%v\n", test.description, test.expectedDiagnostics, diagnostics)
+			t.Fail()
+		}
+	}
+}
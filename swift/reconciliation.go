@@ -0,0 +1,208 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitch000001/go-hbci/domain"
+)
+
+// IssueType defines the kind of a ReconciliationIssue
+type IssueType int
+
+const (
+	// BalanceMismatch marks statements whose closing balance does not equal
+	// the starting balance plus all transactions, or whose starting balance
+	// does not equal the closing balance of the previous statement.
+	BalanceMismatch IssueType = iota
+	// StatementNumberGap marks statements whose statement number does not
+	// continue the number of the previous statement.
+	StatementNumberGap
+	// SheetNumberGap marks statements whose sheet number does not continue
+	// the sheet number of the previous statement.
+	SheetNumberGap
+	// DuplicateBooking marks bookings already contained in a previous
+	// statement.
+	DuplicateBooking
+	// CurrencyMismatch marks statements with balances in different
+	// currencies.
+	CurrencyMismatch
+)
+
+var issueTypeNames = map[IssueType]string{
+	BalanceMismatch:    "balance mismatch",
+	StatementNumberGap: "statement number gap",
+	SheetNumberGap:     "sheet number gap",
+	DuplicateBooking:   "duplicate booking",
+	CurrencyMismatch:   "currency mismatch",
+}
+
+func (i IssueType) String() string {
+	return issueTypeNames[i]
+}
+
+// A ReconciliationIssue describes an integrity problem found by Reconcile.
+// Statement is the index of the affected statement.
+type ReconciliationIssue struct {
+	Type      IssueType
+	Statement int
+	Message   string
+}
+
+func (r ReconciliationIssue) String() string {
+	return fmt.Sprintf("statement %d: %s: %s", r.Statement+1, r.Type, r.Message)
+}
+
+// Reconcile checks the integrity of statements and returns all issues found.
+// The statements are expected in the order they were issued by the bank
+// institute and may belong to different accounts.
+//
+// Every statement is checked for adding up balances and consistent
+// currencies. Consecutive statements of the same account are checked for
+// continuous statement and sheet numbers and matching closing and starting
+// balances. Statement numbers of 0 are treated as not numbered and a
+// statement number of 1 is accepted as restart of the numbering.
+//
+// Bookings are reported as duplicate if an equal booking is contained in
+// another statement. Equal bookings within the same statement are not
+// reported, as they are covered by the balance check.
+func Reconcile(statements []*MT940) []ReconciliationIssue {
+	var issues []ReconciliationIssue
+	addIssue := func(typ IssueType, statement int, format string, args ...interface{}) {
+		issues = append(issues, ReconciliationIssue{
+			Type:      typ,
+			Statement: statement,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+	previousStatements := make(map[string]int)
+	bookings := make(map[string]int)
+	for i, m := range statements {
+		currency := ""
+		for _, balance := range m.balances() {
+			if currency == "" {
+				currency = balance.Currency
+			} else if balance.Currency != currency {
+				addIssue(CurrencyMismatch, i, "balance %s in %s, expected %s", balance.Tag, balance.Currency, currency)
+			}
+		}
+		if m.StartingBalance != nil && m.ClosingBalance != nil {
+			calculated, err := m.calculatedClosingBalance()
+			closing := m.ClosingBalance.Balance().Amount.Amount
+			if err != nil {
+				addIssue(BalanceMismatch, i, "closing balance %s can not be calculated: %v", formatAmount(closing), err)
+			} else if !calculated.Equal(closing) {
+				addIssue(BalanceMismatch, i, "closing balance %s does not match starting balance plus transactions %s", formatAmount(closing), formatAmount(calculated))
+			}
+		}
+		account := m.accountKey()
+		if previous, ok := previousStatements[account]; ok {
+			issues = append(issues, reconcileConsecutive(statements[previous], m, i)...)
+		}
+		previousStatements[account] = i
+		for _, transaction := range m.Transactions {
+			key := account + "|" + transaction.bookingKey()
+			first, ok := bookings[key]
+			if !ok {
+				bookings[key] = i
+				continue
+			}
+			if first != i {
+				addIssue(DuplicateBooking, i, "booking %s already contained in statement %d", transaction.Transaction.describe(), first+1)
+			}
+		}
+	}
+	return issues
+}
+
+// reconcileConsecutive checks that m with index i continues previous
+func reconcileConsecutive(previous, m *MT940, i int) []ReconciliationIssue {
+	var issues []ReconciliationIssue
+	addIssue := func(typ IssueType, format string, args ...interface{}) {
+		issues = append(issues, ReconciliationIssue{
+			Type:      typ,
+			Statement: i,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+	if previous.StatementNumber != nil && m.StatementNumber != nil && previous.StatementNumber.Number != 0 && m.StatementNumber.Number != 0 {
+		prev, current := previous.StatementNumber, m.StatementNumber
+		switch {
+		case current.Number == prev.Number:
+			if current.SheetNumber != prev.SheetNumber+1 {
+				addIssue(SheetNumberGap, "sheet %d/%d follows sheet %d/%d", current.Number, current.SheetNumber, prev.Number, prev.SheetNumber)
+			}
+		case current.Number == prev.Number+1 || current.Number == 1:
+			if current.SheetNumber > 1 {
+				addIssue(SheetNumberGap, "statement %d starts with sheet %d", current.Number, current.SheetNumber)
+			}
+		default:
+			addIssue(StatementNumberGap, "statement %d follows statement %d", current.Number, prev.Number)
+		}
+	}
+	if previous.ClosingBalance != nil && m.StartingBalance != nil {
+		closing := previous.ClosingBalance.Balance().Amount
+		starting := m.StartingBalance.Balance().Amount
+		if closing.Currency != starting.Currency {
+			addIssue(CurrencyMismatch, "starting balance in %s, previous closing balance in %s", starting.Currency, closing.Currency)
+		} else if !closing.Amount.Equal(starting.Amount) {
+			addIssue(BalanceMismatch, "starting balance %s does not match previous closing balance %s", formatAmount(starting.Amount), formatAmount(closing.Amount))
+		}
+	}
+	return issues
+}
+
+// calculatedClosingBalance returns the starting balance of m plus all
+// transactions
+func (m *MT940) calculatedClosingBalance() (domain.Decimal, error) {
+	sum := m.StartingBalance.Balance().Amount.Amount
+	for _, transaction := range m.Transactions {
+		var err error
+		sum, err = sum.Add(transaction.Transaction.SignedAmount())
+		if err != nil {
+			return domain.Decimal{}, err
+		}
+	}
+	return sum, nil
+}
+
+func (m *MT940) balances() []*BalanceTag {
+	var balances []*BalanceTag
+	for _, balance := range []*BalanceTag{m.StartingBalance, m.ClosingBalance, m.CurrentValutaBalance, m.FutureValutaBalance} {
+		if balance != nil {
+			balances = append(balances, balance)
+		}
+	}
+	return balances
+}
+
+func (m *MT940) accountKey() string {
+	if m.Account == nil {
+		return ""
+	}
+	return m.Account.BankID + "/" + m.Account.AccountID
+}
+
+func (t *TransactionSequence) bookingKey() string {
+	tr := t.Transaction
+	key := []string{
+		tr.ValutaDate.Format("20060102"),
+		tr.BookingDate.Format("20060102"),
+		tr.DebitCreditIndicator,
+		formatAmount(tr.Amount),
+		tr.BookingKey,
+		tr.Reference,
+		tr.BankReference,
+		tr.AdditionalInformation,
+	}
+	if d := t.Description; d != nil {
+		key = append(key, d.BookingText, d.BankID, d.AccountID, d.Name)
+		key = append(key, d.Purpose...)
+		key = append(key, d.Purpose2...)
+	}
+	return strings.Join(key, "|")
+}
+
+func (t *TransactionTag) describe() string {
+	return fmt.Sprintf("%s %s%s %s", t.BookingDate.Format("2006-01-02"), t.DebitCreditIndicator, formatAmount(t.Amount), t.Reference)
+}
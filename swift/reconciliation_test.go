@@ -0,0 +1,107 @@
+package swift
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	statement := func(number, opening string, bookings []string, closing string) string {
+		s := "\r\n:20:STARTUMS" +
+			"\r\n:25:12345678/1234123456" +
+			"\r\n:28C:" + number +
+			"\r\n:60" + opening
+		for _, booking := range bookings {
+			s += "\r\n:61:" + booking
+		}
+		return s + "\r\n:62" + closing + "\r\n-"
+	}
+
+	tests := []struct {
+		description    string
+		statements     []string
+		expectedIssues []IssueType
+	}{
+		{
+			"continuous statements",
+			[]string{
+				statement("1/1", "F:C181105EUR100,00", []string{"1811051105D30,NMSCNONREF"}, "M:C181105EUR70,00"),
+				statement("1/2", "M:C181105EUR70,00", []string{"1811051105C5,NMSCNONREF"}, "F:C181105EUR75,00"),
+				statement("2/1", "F:C181106EUR75,00", []string{"1811061106D80,NMSCNONREF"}, "F:D181106EUR5,00"),
+			},
+			nil,
+		},
+		{
+			"not numbered statements",
+			[]string{
+				statement("0", "F:C181105EUR100,00", nil, "F:C181105EUR100,00"),
+				statement("0", "F:C181106EUR100,00", nil, "F:C181106EUR100,00"),
+			},
+			nil,
+		},
+		{
+			"balance mismatch within statement",
+			[]string{
+				statement("1", "F:C181105EUR100,00", []string{"1811051105D30,NMSCNONREF"}, "F:C181105EUR80,00"),
+			},
+			[]IssueType{BalanceMismatch},
+		},
+		{
+			"missing sheet",
+			[]string{
+				statement("1/1", "F:C181105EUR100,00", nil, "M:C181105EUR100,00"),
+				statement("1/3", "M:C181105EUR90,00", nil, "F:C181105EUR90,00"),
+			},
+			[]IssueType{SheetNumberGap, BalanceMismatch},
+		},
+		{
+			"missing statement",
+			[]string{
+				statement("1", "F:C181105EUR100,00", nil, "F:C181105EUR100,00"),
+				statement("3", "F:C181107EUR100,00", nil, "F:C181107EUR100,00"),
+			},
+			[]IssueType{StatementNumberGap},
+		},
+		{
+			"duplicate booking in overlapping statements",
+			[]string{
+				statement("1", "F:C181105EUR100,00", []string{"1811051105D30,NMSCNONREF"}, "F:C181105EUR70,00"),
+				statement("2", "F:C181105EUR70,00", []string{"1811051105D30,NMSCNONREF"}, "F:C181105EUR40,00"),
+			},
+			[]IssueType{DuplicateBooking},
+		},
+		{
+			"currency mismatch",
+			[]string{
+				statement("1", "F:C181105EUR100,00", nil, "F:C181105USD100,00"),
+			},
+			[]IssueType{CurrencyMismatch},
+		},
+	}
+
+	for _, test := range tests {
+		var statements []*MT940
+		for _, s := range test.statements {
+			mt := &MT940{}
+			err := mt.Unmarshal([]byte(s))
+			if err != nil {
+				t.Fatalf("%s: Expected no error, got %T:%v\n", test.description, err, err)
+			}
+			statements = append(statements, mt)
+		}
+
+		issues := Reconcile(statements)
+
+		var issueTypes []IssueType
+		var messages []string
+		for _, issue := range issues {
+			issueTypes = append(issueTypes, issue.Type)
+			messages = append(messages, issue.String())
+		}
+		if !reflect.DeepEqual(test.expectedIssues, issueTypes) {
+			t.Logf("%s: Expected issues\n%v\n\tgot\n%v\n", test.description, test.expectedIssues, strings.Join(messages, "\n"))
+			t.Fail()
+		}
+	}
+}
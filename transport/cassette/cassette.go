@@ -0,0 +1,258 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/mitch000001/go-hbci/charset"
+	"github.com/mitch000001/go-hbci/transport"
+	middleware "github.com/mitch000001/go-hbci/transport/middleware"
+)
+
+// An Interaction represents a recorded request and the response received for
+// it. Both are stored as plain HBCI messages, converted from ISO-8859-1 to
+// UTF-8.
+type Interaction struct {
+	Request  string `json:"request"`
+	Response string `json:"response"`
+}
+
+// A Cassette contains recorded interactions with a HBCI server
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads the cassette stored at path
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cassette Cassette
+	err = json.Unmarshal(data, &cassette)
+	if err != nil {
+		return nil, fmt.Errorf("%T: Error while unmarshaling %s: %v", &cassette, path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes c to path
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// NewRecorder returns a Recorder performing requests with t. Credentials and
+// the personal fields configured in redactor are redacted from requests
+// before recording. If redactor is nil, only credentials are redacted.
+//
+// Responses are recorded as received, as redacted amounts, IBANs and dates
+// could not be parsed on replay. Cassettes thus contain the personal data of
+// the responses and have to be kept private.
+func NewRecorder(t transport.Transport, redactor *middleware.Redactor) *Recorder {
+	if redactor == nil {
+		redactor = middleware.NewRedactor()
+	}
+	return &Recorder{
+		transport: t,
+		redactor:  redactor,
+		cassette:  &Cassette{},
+	}
+}
+
+// A Recorder implements transport.Transport and records all requests and
+// responses exchanged over the wrapped transport into a Cassette.
+//
+// The Recorder can be used at any position of the transport chain. Base64
+// encoded messages, as sent by the PinTanDialog, are recorded decoded.
+type Recorder struct {
+	transport transport.Transport
+	redactor  *middleware.Redactor
+	mu        sync.Mutex
+	cassette  *Cassette
+}
+
+// Do performs request with the wrapped transport and records the request and
+// its response. Requests returning an error are not recorded.
+func (r *Recorder) Do(request *transport.Request) (*transport.Response, error) {
+	var requestBuf bytes.Buffer
+	marshaledRequest, err := ioutil.ReadAll(io.TeeReader(request.Body, &requestBuf))
+	if err != nil {
+		return nil, err
+	}
+	request.Body = ioutil.NopCloser(&requestBuf)
+	response, err := r.transport.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	var responseBuf bytes.Buffer
+	marshaledResponse, err := ioutil.ReadAll(io.TeeReader(response.Body, &responseBuf))
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(&responseBuf)
+	decodedResponse, _ := decodeMessage(marshaledResponse)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:  charset.ToUTF8(r.redact(marshaledRequest)),
+		Response: charset.ToUTF8(decodedResponse),
+	})
+	return response, nil
+}
+
+// redact decodes and redacts marshaledMessage. Messages which are no HBCI
+// messages, like error pages of the server, are returned as is.
+func (r *Recorder) redact(marshaledMessage []byte) []byte {
+	decoded, _ := decodeMessage(marshaledMessage)
+	redacted, err := r.redactor.Redact(decoded)
+	if err != nil {
+		return decoded
+	}
+	return redacted
+}
+
+// Cassette returns the cassette with all interactions recorded so far
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cassette := &Cassette{
+		Interactions: make([]Interaction, len(r.cassette.Interactions)),
+	}
+	copy(cassette.Interactions, r.cassette.Interactions)
+	return cassette
+}
+
+// Save writes all interactions recorded so far to path
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+// NewReplayer returns a Replayer replaying the interactions of cassette
+func NewReplayer(cassette *Cassette) *Replayer {
+	return &Replayer{
+		cassette: cassette,
+		played:   make([]bool, len(cassette.Interactions)),
+	}
+}
+
+// A Replayer implements transport.Transport and answers requests with the
+// responses recorded in a Cassette, without contacting any server.
+//
+// A request is answered with the response of the first interaction not
+// replayed yet whose request consists of the same segments. Volatile data
+// elements, i.e. message sizes, dialog IDs, message numbers, timestamps,
+// control references and client system IDs, are ignored as well as
+// credentials and personal data, which might have been redacted from the
+// requests while recording.
+type Replayer struct {
+	cassette *Cassette
+	mu       sync.Mutex
+	played   []bool
+}
+
+// Do returns the recorded response for request. It returns an error if no
+// recorded interaction matches request.
+func (r *Replayer) Do(request *transport.Request) (*transport.Response, error) {
+	marshaledRequest, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	requestMessage, encoded := decodeMessage(marshaledRequest)
+	normalizedRequest, err := normalize(requestMessage)
+	if err != nil {
+		return nil, fmt.Errorf("%T: Error while parsing request: %v", r, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.played[i] {
+			continue
+		}
+		recordedRequest, err := normalize(charset.ToISO8859_1(interaction.Request))
+		if err != nil {
+			return nil, fmt.Errorf("%T: Error while parsing recorded request %d: %v", r, i, err)
+		}
+		if !bytes.Equal(normalizedRequest, recordedRequest) {
+			continue
+		}
+		r.played[i] = true
+		body := charset.ToISO8859_1(interaction.Response)
+		if encoded {
+			body = []byte(base64.StdEncoding.EncodeToString(body))
+		}
+		return &transport.Response{
+			Body:    ioutil.NopCloser(bytes.NewReader(body)),
+			Request: request,
+		}, nil
+	}
+	return nil, fmt.Errorf("%T: No recorded interaction matches request:\n%s", r, normalizedRequest)
+}
+
+// Done returns true if all recorded interactions were replayed
+func (r *Replayer) Done() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, played := range r.played {
+		if !played {
+			return false
+		}
+	}
+	return true
+}
+
+// volatileRules mark data elements which differ between dialogs with the
+// same content. They use the Credentials field, which is always redacted.
+var volatileRules = []middleware.RedactionRule{
+	// Message size, dialog ID, message number and reference message
+	{SegmentID: "HNHBK", Element: 1},
+	{SegmentID: "HNHBK", Element: 3},
+	{SegmentID: "HNHBK", Element: 4},
+	{SegmentID: "HNHBK", Element: 5},
+	{SegmentID: "HNHBS", Element: 1},
+	// Control reference, security identification and timestamp
+	{SegmentID: "HNSHK", Versions: []int{3}, Element: 2},
+	{SegmentID: "HNSHK", Versions: []int{3}, Element: 5},
+	{SegmentID: "HNSHK", Versions: []int{3}, Element: 7},
+	{SegmentID: "HNSHK", Versions: []int{4}, Element: 3},
+	{SegmentID: "HNSHK", Versions: []int{4}, Element: 6},
+	{SegmentID: "HNSHK", Versions: []int{4}, Element: 8},
+	{SegmentID: "HNSHA", Element: 1},
+	{SegmentID: "HNVSK", Versions: []int{2}, Element: 3},
+	{SegmentID: "HNVSK", Versions: []int{2}, Element: 4},
+	{SegmentID: "HNVSK", Versions: []int{3}, Element: 4},
+	{SegmentID: "HNVSK", Versions: []int{3}, Element: 5},
+	// Client system ID
+	{SegmentID: "HKIDN", Element: 3},
+}
+
+// normalize masks all volatile data elements, credentials and personal data
+// within marshaledMessage
+func normalize(marshaledMessage []byte) ([]byte, error) {
+	redactor := middleware.NewRedactor(middleware.AllPersonalFields...)
+	for _, rule := range volatileRules {
+		redactor.AddRule(rule)
+	}
+	return redactor.Redact(marshaledMessage)
+}
+
+// decodeMessage decodes marshaledMessage from Base64 if it is no plain HBCI
+// message. It returns whether marshaledMessage was encoded.
+func decodeMessage(marshaledMessage []byte) ([]byte, bool) {
+	if bytes.HasPrefix(marshaledMessage, []byte("HNHBK")) {
+		return marshaledMessage, false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(string(marshaledMessage))
+	if err != nil {
+		return marshaledMessage, false
+	}
+	return decoded, true
+}
@@ -0,0 +1,161 @@
+package transport
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitch000001/go-hbci/transport"
+	middleware "github.com/mitch000001/go-hbci/transport/middleware"
+)
+
+func testMessage(dialogID string, number int, controlRef, clientSystemID, date, pin string) string {
+	inner := fmt.Sprintf(
+		"HNSHK:2:4+PIN:1+999+%s+1+1+1::%s+1+1:%s:101500+1:999:1+6:10:16+280:10000000:12345:S:0:0'"+
+			"HKIDN:3:2+280:10000000+12345+%s+1'"+
+			"HKSAL:4:5+1234567::280:10000000+N'"+
+			"HNSHA:5:2+%s++%s'",
+		controlRef, clientSystemID, date, clientSystemID, controlRef, pin,
+	)
+	return fmt.Sprintf(
+		"HNHBK:1:3+000000000123+300+%s+%d'"+
+			"HNVSK:998:3+PIN:1+998+1+1::%s+1:%s:101500+2:2:13:@8@00000000:5:1+280:10000000:12345:V:0:0+0'"+
+			"HNVSD:999:1+@%d@%s'"+
+			"HNHBS:6:1+%d'",
+		dialogID, number, clientSystemID, date, len(inner), inner, number,
+	)
+}
+
+func TestRecorderAndReplayer(t *testing.T) {
+	recordedRequest := testMessage("0", 1, "1234567", "0", "20181105", "secretPIN")
+	recordedResponse := "HNHBK:1:3+000000000100+300+DIALOG1+1+0:1'HIRMG:2:2+0010::Nachricht entgegengenommen.'HNHBS:3:1+1'"
+	called := 0
+	innerTransport := transport.Func(func(req *transport.Request) (*transport.Response, error) {
+		called++
+		return &transport.Response{
+			Body:    ioutil.NopCloser(strings.NewReader(base64.StdEncoding.EncodeToString([]byte(recordedResponse)))),
+			Request: req,
+		}, nil
+	})
+
+	recorder := NewRecorder(innerTransport, middleware.NewRedactor(middleware.AllPersonalFields...))
+
+	response, err := recorder.Do(&transport.Request{
+		Body: ioutil.NopCloser(strings.NewReader(base64.StdEncoding.EncodeToString([]byte(recordedRequest)))),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	body, _ := ioutil.ReadAll(response.Body)
+	if string(body) != base64.StdEncoding.EncodeToString([]byte(recordedResponse)) {
+		t.Logf("Expected response to be passed through, got %q\n", body)
+		t.Fail()
+	}
+
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+	err = recorder.Save(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	content, _ := ioutil.ReadFile(path)
+	for _, secret := range []string{"secretPIN", "1234567::280"} {
+		if strings.Contains(string(content), secret) {
+			t.Logf("Expected cassette not to contain %q, got\n%s\n", secret, content)
+			t.Fail()
+		}
+	}
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	replayer := NewReplayer(cassette)
+
+	tests := []struct {
+		description string
+		request     string
+		err         bool
+	}{
+		{
+			"different segments",
+			strings.Replace(testMessage("DIALOG1", 2, "7654321", "SYSID", "20181106", "otherPIN"), "HKSAL:4:5", "HKSAL:4:6", 1),
+			true,
+		},
+		{
+			"volatile fields only",
+			testMessage("DIALOG1", 2, "7654321", "SYSID", "20181106", "otherPIN"),
+			false,
+		},
+		{
+			"already replayed",
+			testMessage("DIALOG1", 2, "7654321", "SYSID", "20181106", "otherPIN"),
+			true,
+		},
+	}
+	for _, test := range tests {
+		response, err := replayer.Do(&transport.Request{
+			Body: ioutil.NopCloser(strings.NewReader(test.request)),
+		})
+		if test.err {
+			if err == nil {
+				t.Logf("%s: Expected error, got nil\n", test.description)
+				t.Fail()
+			}
+			continue
+		}
+		if err != nil {
+			t.Logf("%s: Expected no error, got %T:%v\n", test.description, err, err)
+			t.Fail()
+			continue
+		}
+		body, _ := ioutil.ReadAll(response.Body)
+		if string(body) != recordedResponse {
+			t.Logf("%s: Expected response to equal\n%q\n\tgot\n%q\n", test.description, recordedResponse, body)
+			t.Fail()
+		}
+	}
+
+	if !replayer.Done() {
+		t.Logf("Expected all interactions to be replayed\n")
+		t.Fail()
+	}
+	if called != 1 {
+		t.Logf("Expected inner transport to be called once, was called %d times\n", called)
+		t.Fail()
+	}
+}
+
+func TestRecorderRecordsResponsesUnredacted(t *testing.T) {
+	request := testMessage("0", 1, "1234567", "0", "20181105", "secretPIN")
+	response := "HNHBK:1:3+000000000208+300+DIALOG1+1+0:1'" +
+		"HISAL:2:5:4+1234567::280:10000000+Girokonto+EUR+C:1000,15:EUR:20150812+C:20,:EUR:20150812+500,:EUR+1499,85:EUR'" +
+		"HNHBS:3:1+1'"
+	innerTransport := transport.Func(func(req *transport.Request) (*transport.Response, error) {
+		return &transport.Response{Body: ioutil.NopCloser(strings.NewReader(response)), Request: req}, nil
+	})
+	recorder := NewRecorder(innerTransport, middleware.NewRedactor(middleware.AllPersonalFields...))
+
+	_, err := recorder.Do(&transport.Request{Body: ioutil.NopCloser(strings.NewReader(request))})
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	interaction := recorder.Cassette().Interactions[0]
+	if strings.Contains(interaction.Request, "secretPIN") || strings.Contains(interaction.Request, "1234567::280") {
+		t.Logf("Expected request to be redacted, got %q\n", interaction.Request)
+		t.Fail()
+	}
+	if interaction.Response != response {
+		t.Logf("Expected response to equal\n%q\n\tgot\n%q\n", response, interaction.Response)
+		t.Fail()
+	}
+}
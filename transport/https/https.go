@@ -10,14 +10,16 @@ import (
 	"github.com/mitch000001/go-hbci/transport"
 )
 
-// NewBase64 returns a HTTPSBase64Transport. It sets http.DefaultClient as
-// http.Client to perform requests to the HBCI server.
+// NewBase64 returns a HTTPSBase64Transport configured with options like New.
 //
 // Each request will be encoded and each response will be decoded with Base64
 // encoding.
-func NewBase64() transport.Transport {
+func NewBase64(options ...Option) transport.Transport {
+	t := newTransport(options)
 	return &HTTPSBase64Transport{
-		httpClient: http.DefaultClient,
+		httpClient: t.HTTPClient,
+		userAgent:  t.UserAgent,
+		err:        t.err,
 	}
 }
 
@@ -25,6 +27,8 @@ func NewBase64() transport.Transport {
 // HTTPS with also encoding requests and responses with Base64 encoding.
 type HTTPSBase64Transport struct {
 	httpClient *http.Client
+	userAgent  string
+	err        error
 }
 
 // Do performs the request to the HBCI server. If successful, it returns a
@@ -36,6 +40,9 @@ type HTTPSBase64Transport struct {
 // with Base64 encoding. A non 200 status code will be returned as is, without
 // decoding it from Base64.
 func (h *HTTPSBase64Transport) Do(request *transport.Request) (*transport.Response, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
 	var buf bytes.Buffer
 	encodingWriter := base64.NewEncoder(base64.StdEncoding, &buf)
 	_, err := io.Copy(encodingWriter, request.Body)
@@ -46,7 +53,7 @@ func (h *HTTPSBase64Transport) Do(request *transport.Request) (*transport.Respon
 	if err != nil {
 		return nil, err
 	}
-	httpResponse, err := h.httpClient.Post(request.URL, "application/vnd.hbci", &buf)
+	httpResponse, err := post(h.httpClient, h.userAgent, request.URL, &buf)
 	if err != nil {
 		return nil, err
 	}
@@ -59,34 +66,57 @@ func (h *HTTPSBase64Transport) Do(request *transport.Request) (*transport.Respon
 	return &transport.Response{Body: ioutil.NopCloser(reader), Request: request}, nil
 }
 
-// New returns a HTTPSTransport. It sets http.DefaultClient as http.Client to
-// perform requests to the HBCI server
-func New() *HTTPSTransport {
-	return &HTTPSTransport{
-		HTTPClient: http.DefaultClient,
-	}
+// New returns a HTTPSTransport configured with options. Without options
+// it performs requests with a http.Client using the http.DefaultTransport and
+// the DefaultTimeout.
+func New(options ...Option) *HTTPSTransport {
+	return newTransport(options)
 }
 
 // NewNonDefault returns a HTTPSTransport which uses the given http.Client to
 // perform requests to the HBCO server
 func NewNonDefault(h *http.Client) transport.Transport {
-	return &HTTPSTransport{
-		HTTPClient: h,
-	}
+	return New(WithHTTPClient(h))
 }
 
 // A HTTPSTransport implements transport.Transport and performs request over HTTPS
 type HTTPSTransport struct {
 	HTTPClient *http.Client
+	// UserAgent is sent with every request. If empty, DefaultUserAgent is
+	// used.
+	UserAgent string
+	// err is a configuration error returned on every request
+	err error
 }
 
 // Do performs the request to the HBCI server. If successful, it returns a
 // populated transport.Response with the HTTP Response Body as Body and the
-// request as Request
+// request as Request. If the server responds with a status code other than
+// 200, a *transport.StatusError is returned.
 func (h *HTTPSTransport) Do(request *transport.Request) (*transport.Response, error) {
-	httpResponse, err := h.HTTPClient.Post(request.URL, "application/vnd.hbci", request.Body)
+	if h.err != nil {
+		return nil, h.err
+	}
+	httpResponse, err := post(h.HTTPClient, h.UserAgent, request.URL, request.Body)
 	if err != nil {
 		return nil, err
 	}
+	if httpResponse.StatusCode != http.StatusOK {
+		httpResponse.Body.Close()
+		return nil, &transport.StatusError{StatusCode: httpResponse.StatusCode, Status: httpResponse.Status}
+	}
 	return &transport.Response{Body: httpResponse.Body, Request: request}, nil
 }
+
+func post(client *http.Client, userAgent, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("Content-Type", "application/vnd.hbci")
+	req.Header.Set("User-Agent", userAgent)
+	return client.Do(req)
+}
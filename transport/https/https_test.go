@@ -21,8 +21,8 @@ func TestHttpsBase64Transport(t *testing.T) {
 	})
 	httpClient := &http.Client{Transport: roundtripper}
 
-	httpsTransport := middleware.Base64Encoding(base64.StdEncoding)(&HTTPSTransport{httpClient})
-	httpsBase64Transport := &HTTPSBase64Transport{httpClient}
+	httpsTransport := middleware.Base64Encoding(base64.StdEncoding)(&HTTPSTransport{HTTPClient: httpClient})
+	httpsBase64Transport := &HTTPSBase64Transport{httpClient: httpClient}
 
 	httpReq := &transport.Request{
 		URL:  "foo",
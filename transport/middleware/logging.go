@@ -13,18 +13,39 @@ import (
 // Logging creates a middleware that logs every request and response sent over
 // the transport
 func Logging(logger *log.Logger) transport.Middleware {
+	return logging(logger, func(marshaledMessage []byte) []byte {
+		return marshaledMessage
+	})
+}
+
+// RedactedLogging creates a middleware that logs every request and response
+// sent over the transport with credentials and personal data redacted by
+// redactor. If redactor is nil, a Redactor redacting AllPersonalFields is
+// used. Messages which can not be parsed are not logged at all.
+func RedactedLogging(logger *log.Logger, redactor *Redactor) transport.Middleware {
+	if redactor == nil {
+		redactor = NewRedactor(AllPersonalFields...)
+	}
+	return logging(logger, func(marshaledMessage []byte) []byte {
+		redacted, err := redactor.Redact(marshaledMessage)
+		if err != nil {
+			return []byte("<unparseable message omitted>")
+		}
+		return redacted
+	})
+}
+
+func logging(logger *log.Logger, format func([]byte) []byte) transport.Middleware {
 	if logger == nil {
 		logger = internal.Debug
 	}
-	var count int
 	return func(t transport.Transport) transport.Transport {
 		return transport.Func(func(req *transport.Request) (*transport.Response, error) {
-			count++
 			var buf bytes.Buffer
 			marshaledRequest, err := ioutil.ReadAll(io.TeeReader(req.Body, &buf))
 			req.Body = ioutil.NopCloser(&buf)
 			logger.Println("Request:")
-			logger.Printf("%s\n", marshaledRequest)
+			logger.Printf("%s\n", format(marshaledRequest))
 
 			res, err := t.Do(req)
 			if err != nil {
@@ -35,7 +56,7 @@ func Logging(logger *log.Logger) transport.Middleware {
 			marshaledResponse, err := ioutil.ReadAll(io.TeeReader(res.Body, &responseBuf))
 			res.Body = ioutil.NopCloser(&responseBuf)
 			logger.Println("Response:")
-			logger.Printf("%s\n", marshaledResponse)
+			logger.Printf("%s\n", format(marshaledResponse))
 			return res, nil
 		})
 	}
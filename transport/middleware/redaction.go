@@ -0,0 +1,285 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/mitch000001/go-hbci/message"
+	"github.com/mitch000001/go-hbci/token"
+)
+
+// redactionMask replaces the value of redacted data elements
+const redactionMask = "***"
+
+// PersonalField defines a category of personal data which can be redacted
+// from HBCI messages
+type PersonalField int
+
+const (
+	// Credentials marks PINs and TANs. Credentials are always redacted.
+	Credentials PersonalField = iota
+	// Names marks names of account holders and users
+	Names
+	// IBANs marks IBANs and account connections
+	IBANs
+	// Purposes marks purposes of transactions
+	Purposes
+	// Amounts marks balances, limits and amounts of transactions
+	Amounts
+	// UserIDs marks the IDs of users, i.e. within the identification and
+	// the key names of signatures and encryption
+	UserIDs
+)
+
+// AllPersonalFields contains all PersonalFields which can be redacted
+var AllPersonalFields = []PersonalField{Names, IBANs, Purposes, Amounts, UserIDs}
+
+// A RedactionRule marks a data element of a segment for redaction
+type RedactionRule struct {
+	// Field defines the category of the data element. The rule is only applied
+	// if the Redactor redacts the Field or one of the OtherFields.
+	Field PersonalField
+	// OtherFields defines further categories of data elements mixing
+	// different kinds of personal data, like MT940 statements
+	OtherFields []PersonalField
+	// SegmentID is the ID of the segment, e.g. "HNSHA"
+	SegmentID string
+	// Versions restricts the rule to the given segment versions. If empty,
+	// the rule applies to all versions.
+	Versions []int
+	// Element is the position of the data element, starting with 1 for the
+	// first data element after the segment header
+	Element int
+	// GroupElement is the position within a data element group, starting with
+	// 1. If GroupElement is 0, the whole data element is redacted.
+	GroupElement int
+}
+
+func (r RedactionRule) appliesTo(segmentID string, version int) bool {
+	if r.SegmentID != segmentID {
+		return false
+	}
+	if len(r.Versions) == 0 {
+		return true
+	}
+	for _, v := range r.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (r RedactionRule) redactedBy(fields map[PersonalField]bool) bool {
+	if fields[r.Field] {
+		return true
+	}
+	for _, field := range r.OtherFields {
+		if fields[field] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r RedactionRule) matches(element, groupElement int) bool {
+	return r.Element == element && (r.GroupElement == 0 || r.GroupElement == groupElement)
+}
+
+// DefaultRedactionRules contains the rules for all segments known to carry
+// credentials or personal data
+var DefaultRedactionRules = []RedactionRule{
+	// PIN and TAN
+	{Field: Credentials, SegmentID: "HNSHA", Element: 3},
+	{Field: Credentials, SegmentID: "HKPAE", Element: 1},
+	{Field: Credentials, SegmentID: "HKTSY", Element: 4},
+	// User IDs within the identification and the key names
+	{Field: UserIDs, SegmentID: "HKIDN", Element: 2},
+	{Field: UserIDs, SegmentID: "HNSHK", Versions: []int{3}, Element: 10, GroupElement: 3},
+	{Field: UserIDs, SegmentID: "HNSHK", Versions: []int{4}, Element: 11, GroupElement: 3},
+	{Field: UserIDs, SegmentID: "HNVSK", Versions: []int{2}, Element: 6, GroupElement: 3},
+	{Field: UserIDs, SegmentID: "HNVSK", Versions: []int{3}, Element: 7, GroupElement: 3},
+	// User parameter data
+	{Field: Names, SegmentID: "HIUPA", Versions: []int{3, 4}, Element: 4},
+	{Field: IBANs, SegmentID: "HIUPD", Element: 1},
+	{Field: Names, SegmentID: "HIUPD", Versions: []int{4}, Element: 4},
+	{Field: Names, SegmentID: "HIUPD", Versions: []int{4}, Element: 5},
+	{Field: Amounts, SegmentID: "HIUPD", Versions: []int{4}, Element: 7},
+	{Field: Names, SegmentID: "HIUPD", Versions: []int{5}, Element: 5},
+	{Field: Names, SegmentID: "HIUPD", Versions: []int{5}, Element: 6},
+	{Field: Amounts, SegmentID: "HIUPD", Versions: []int{5}, Element: 8},
+	{Field: IBANs, SegmentID: "HIUPD", Versions: []int{6}, Element: 2},
+	{Field: Names, SegmentID: "HIUPD", Versions: []int{6}, Element: 6},
+	{Field: Names, SegmentID: "HIUPD", Versions: []int{6}, Element: 7},
+	{Field: Amounts, SegmentID: "HIUPD", Versions: []int{6}, Element: 9},
+	// Account information
+	{Field: IBANs, SegmentID: "HKKIF", Element: 1},
+	{Field: IBANs, SegmentID: "HIKIF", Element: 1},
+	{Field: Names, SegmentID: "HIKIF", Element: 3},
+	{Field: Names, SegmentID: "HIKIF", Element: 4},
+	{Field: Amounts, SegmentID: "HIKIF", Element: 11},
+	{Field: IBANs, SegmentID: "HIKIF", Element: 12},
+	{Field: Names, SegmentID: "HIKIF", Element: 16},
+	// Balances
+	{Field: IBANs, SegmentID: "HKSAL", Element: 1},
+	{Field: IBANs, SegmentID: "HISAL", Element: 1},
+	{Field: Amounts, SegmentID: "HISAL", Element: 4},
+	{Field: Amounts, SegmentID: "HISAL", Element: 5},
+	{Field: Amounts, SegmentID: "HISAL", Element: 6},
+	{Field: Amounts, SegmentID: "HISAL", Element: 7},
+	{Field: Amounts, SegmentID: "HISAL", Element: 8},
+	// Transactions. The MT940 data contains names, IBANs, purposes and
+	// amounts, so it is redacted as a whole if any of them is redacted.
+	{Field: IBANs, SegmentID: "HKKAZ", Element: 1},
+	{Field: Names, OtherFields: []PersonalField{IBANs, Purposes, Amounts}, SegmentID: "HIKAZ", Element: 1},
+	{Field: Names, OtherFields: []PersonalField{IBANs, Purposes, Amounts}, SegmentID: "HIKAZ", Element: 2},
+}
+
+// NewRedactor returns a Redactor redacting credentials and the given fields.
+// It uses the DefaultRedactionRules.
+func NewRedactor(fields ...PersonalField) *Redactor {
+	r := &Redactor{
+		fields: map[PersonalField]bool{Credentials: true},
+		rules:  append([]RedactionRule{}, DefaultRedactionRules...),
+	}
+	for _, field := range fields {
+		r.fields[field] = true
+	}
+	return r
+}
+
+// A Redactor masks credentials and personal data within marshaled HBCI
+// messages. The content of encrypted data segments (HNVSD) is redacted
+// recursively.
+type Redactor struct {
+	fields map[PersonalField]bool
+	rules  []RedactionRule
+}
+
+// AddRule adds rule to the rules of r. The Field of rule is redacted in
+// any case.
+func (r *Redactor) AddRule(rule RedactionRule) {
+	r.fields[rule.Field] = true
+	r.rules = append(r.rules, rule)
+}
+
+// Redact returns a copy of marshaledMessage with all data elements masked
+// which match a rule of r. It returns an error if marshaledMessage can not be
+// split into segments.
+func (r *Redactor) Redact(marshaledMessage []byte) ([]byte, error) {
+	extractor := message.NewSegmentExtractor(marshaledMessage)
+	segments, err := extractor.Extract()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, segment := range segments {
+		redacted, err := r.redactSegment(segment)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(redacted)
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Redactor) redactSegment(segment []byte) ([]byte, error) {
+	var header [][]byte
+	var rules []RedactionRule
+	element, groupElement := 0, 1
+	masked := false
+	var buf bytes.Buffer
+	lexer := token.NewLexer("Redactor", segment)
+	for lexer.HasNext() {
+		t := lexer.Next()
+		switch t.Type() {
+		case token.ERROR:
+			return nil, fmt.Errorf("%T: SyntaxError at position %d: %q", r, t.Pos(), t.Value())
+		case token.EOF:
+			continue
+		case token.DATA_ELEMENT_SEPARATOR:
+			if element == 0 {
+				rules = r.rulesFor(header)
+			}
+			element++
+			groupElement = 1
+			masked = false
+			buf.Write(t.Value())
+			continue
+		case token.GROUP_DATA_ELEMENT_SEPARATOR:
+			groupElement++
+			if !masked {
+				buf.Write(t.Value())
+			}
+			continue
+		case token.SEGMENT_END_MARKER:
+			buf.Write(t.Value())
+			continue
+		}
+		if element == 0 {
+			header = append(header, t.Value())
+			buf.Write(t.Value())
+			continue
+		}
+		if masked {
+			continue
+		}
+		value := t.Value()
+		if match, whole := r.match(rules, element, groupElement); match {
+			masked = whole
+			value = []byte(redactionMask)
+			if t.Type() == token.BINARY_DATA {
+				value = []byte(fmt.Sprintf("@%d@%s", len(redactionMask), redactionMask))
+			}
+		} else if t.Type() == token.BINARY_DATA && string(header[0]) == "HNVSD" {
+			redacted, err := r.redactBinary(value)
+			if err != nil {
+				return nil, err
+			}
+			value = redacted
+		}
+		buf.Write(value)
+	}
+	return buf.Bytes(), nil
+}
+
+// redactBinary redacts the segments embedded in the binary data element
+// value
+func (r *Redactor) redactBinary(value []byte) ([]byte, error) {
+	data := value[bytes.IndexByte(value[1:], '@')+2:]
+	redacted, err := r.Redact(data)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("@%d@%s", len(redacted), redacted)), nil
+}
+
+func (r *Redactor) rulesFor(header [][]byte) []RedactionRule {
+	if len(header) < 3 {
+		return nil
+	}
+	id := string(header[0])
+	version, err := strconv.Atoi(string(header[2]))
+	if err != nil {
+		return nil
+	}
+	var rules []RedactionRule
+	for _, rule := range r.rules {
+		if rule.redactedBy(r.fields) && rule.appliesTo(id, version) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// match returns whether a rule matches the given position and whether the
+// whole data element is to be redacted
+func (r *Redactor) match(rules []RedactionRule, element, groupElement int) (bool, bool) {
+	for _, rule := range rules {
+		if rule.matches(element, groupElement) {
+			return true, rule.GroupElement == 0
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,202 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/mitch000001/go-hbci/transport"
+)
+
+func TestRedactorRedact(t *testing.T) {
+	innerMessage := "HNSHK:2:3+900+12345'" +
+		"HKSAL:3:5+1234567::280:10000000+N'" +
+		"HNSHA:4:1+12345++secretPIN:123456'"
+	marshaledMessage := "HNHBK:1:3+000000000123+220+0+1'" +
+		fmt.Sprintf("HNVSD:999:1+@%d@%s'", len(innerMessage), innerMessage) +
+		"HIUPD:5:6:4+1234567::280:10000000+DE12100000000001234567+12345+1+EUR+Max Muster+Erika Muster+Girokonto+E:1000,:EUR+HKSAL:1'" +
+		"HISAL:6:5:3+1234567::280:10000000+Girokonto+EUR+C:1000,15:EUR:20150812+C:20,:EUR:20150812+500,:EUR+1499,85:EUR'" +
+		"HNHBS:7:1+1'"
+
+	tests := []struct {
+		description string
+		fields      []PersonalField
+		expected    string
+	}{
+		{
+			"credentials only",
+			nil,
+			"HNHBK:1:3+000000000123+220+0+1'" +
+				"HNVSD:999:1+@75@HNSHK:2:3+900+12345'HKSAL:3:5+1234567::280:10000000+N'HNSHA:4:1+12345++***''" +
+				"HIUPD:5:6:4+1234567::280:10000000+DE12100000000001234567+12345+1+EUR+Max Muster+Erika Muster+Girokonto+E:1000,:EUR+HKSAL:1'" +
+				"HISAL:6:5:3+1234567::280:10000000+Girokonto+EUR+C:1000,15:EUR:20150812+C:20,:EUR:20150812+500,:EUR+1499,85:EUR'" +
+				"HNHBS:7:1+1'",
+		},
+		{
+			"all personal fields",
+			AllPersonalFields,
+			"HNHBK:1:3+000000000123+220+0+1'" +
+				"HNVSD:999:1+@57@HNSHK:2:3+900+12345'HKSAL:3:5+***+N'HNSHA:4:1+12345++***''" +
+				"HIUPD:5:6:4+***+***+12345+1+EUR+***+***+Girokonto+***+HKSAL:1'" +
+				"HISAL:6:5:3+***+Girokonto+EUR+***+***+***+***'" +
+				"HNHBS:7:1+1'",
+		},
+		{
+			"amounts only",
+			[]PersonalField{Amounts},
+			"HNHBK:1:3+000000000123+220+0+1'" +
+				"HNVSD:999:1+@75@HNSHK:2:3+900+12345'HKSAL:3:5+1234567::280:10000000+N'HNSHA:4:1+12345++***''" +
+				"HIUPD:5:6:4+1234567::280:10000000+DE12100000000001234567+12345+1+EUR+Max Muster+Erika Muster+Girokonto+***+HKSAL:1'" +
+				"HISAL:6:5:3+1234567::280:10000000+Girokonto+EUR+***+***+***+***'" +
+				"HNHBS:7:1+1'",
+		},
+	}
+
+	for _, test := range tests {
+		redactor := NewRedactor(test.fields...)
+
+		redacted, err := redactor.Redact([]byte(marshaledMessage))
+
+		if err != nil {
+			t.Logf("%s: Expected no error, got %T:%v\n", test.description, err, err)
+			t.Fail()
+		}
+
+		if string(redacted) != test.expected {
+			t.Logf("%s: Expected redacted message to equal\n%q\n\tgot\n%q\n", test.description, test.expected, redacted)
+			t.Fail()
+		}
+	}
+}
+
+func TestRedactorRedactCredentialManagement(t *testing.T) {
+	redactor := NewRedactor()
+
+	redacted, err := redactor.Redact([]byte("HKPAE:3:1+newPIN'HKTSY:4:1+5109972878+1+42+654321'"))
+
+	if err != nil {
+		t.Logf("Expected no error, got %T:%v\n", err, err)
+		t.Fail()
+	}
+
+	expected := "HKPAE:3:1+***'HKTSY:4:1+5109972878+1+42+***'"
+	if string(redacted) != expected {
+		t.Logf("Expected redacted message to equal\n%q\n\tgot\n%q\n", expected, redacted)
+		t.Fail()
+	}
+}
+
+func TestRedactorRedactUserIDs(t *testing.T) {
+	marshaledMessage := "HNVSK:998:3+PIN:1+998+1+1::0+1:20150812:120000+2:2:13:@8@00000000:5:1+280:10000000:12345:V:0:0+0'" +
+		"HKIDN:2:2+280:10000000+12345+0+0'" +
+		"HNSHK:3:4+PIN:1+999+1234567+1+1+1::0+1+1:20150812:120000+1:999:1+6:10:16+280:10000000:12345:S:0:0'"
+
+	tests := []struct {
+		description string
+		fields      []PersonalField
+		expected    string
+	}{
+		{
+			"user IDs",
+			[]PersonalField{UserIDs},
+			"HNVSK:998:3+PIN:1+998+1+1::0+1:20150812:120000+2:2:13:@8@00000000:5:1+280:10000000:***:V:0:0+0'" +
+				"HKIDN:2:2+280:10000000+***+0+0'" +
+				"HNSHK:3:4+PIN:1+999+1234567+1+1+1::0+1+1:20150812:120000+1:999:1+6:10:16+280:10000000:***:S:0:0'",
+		},
+		{
+			"names only",
+			[]PersonalField{Names},
+			marshaledMessage,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			redactor := NewRedactor(test.fields...)
+
+			redacted, err := redactor.Redact([]byte(marshaledMessage))
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %T:%v\n", err, err)
+			}
+
+			if string(redacted) != test.expected {
+				t.Logf("Expected redacted message to equal\n%q\n\tgot\n%q\n", test.expected, redacted)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestRedactorRedactStatementsForAnyField(t *testing.T) {
+	marshaledMessage := "HIKAZ:4:7:3+@6@:20:ID+@6@:20:ID'"
+
+	for _, field := range []PersonalField{Names, IBANs, Purposes, Amounts} {
+		redactor := NewRedactor(field)
+
+		redacted, err := redactor.Redact([]byte(marshaledMessage))
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %T:%v\n", err, err)
+		}
+
+		expected := "HIKAZ:4:7:3+@3@***+@3@***'"
+		if string(redacted) != expected {
+			t.Logf("Field %d: Expected redacted message to equal\n%q\n\tgot\n%q\n", field, expected, redacted)
+			t.Fail()
+		}
+	}
+}
+
+func TestRedactorAddRule(t *testing.T) {
+	redactor := NewRedactor()
+	redactor.AddRule(RedactionRule{Field: Names, SegmentID: "HKIDN", Element: 2})
+
+	redacted, err := redactor.Redact([]byte("HKIDN:2:2+280:10000000+Max Muster+0+0'"))
+
+	if err != nil {
+		t.Logf("Expected no error, got %T:%v\n", err, err)
+		t.Fail()
+	}
+
+	expected := "HKIDN:2:2+280:10000000+***+0+0'"
+	if string(redacted) != expected {
+		t.Logf("Expected redacted message to equal\n%q\n\tgot\n%q\n", expected, redacted)
+		t.Fail()
+	}
+}
+
+func TestRedactedLogging(t *testing.T) {
+	innerTransport := transport.Func(func(req *transport.Request) (*transport.Response, error) {
+		body := "HNHBK:1:3+000000000123+220+0+1'HIUPA:2:4:3+12345+3+0+Max Muster'HNHBS:3:1+1'"
+		return &transport.Response{Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+	})
+	var logs bytes.Buffer
+	logger := log.New(&logs, "", 0)
+
+	wrappedTransport := RedactedLogging(logger, nil)(innerTransport)
+
+	request := "HNHBK:1:3+000000000123+220+0+1'HNSHA:2:2+12345++secretPIN'HNHBS:3:1+1'"
+	response, err := wrappedTransport.Do(&transport.Request{
+		Body: ioutil.NopCloser(strings.NewReader(request)),
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+
+	body, _ := ioutil.ReadAll(response.Body)
+	if !strings.Contains(string(body), "Max Muster") {
+		t.Logf("Expected response body not to be redacted, got %q\n", body)
+		t.Fail()
+	}
+
+	for _, secret := range []string{"secretPIN", "Max Muster"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Logf("Expected logs not to contain %q, got\n%s\n", secret, logs.String())
+			t.Fail()
+		}
+	}
+}
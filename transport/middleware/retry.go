@@ -0,0 +1,219 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/message"
+	"github.com/mitch000001/go-hbci/transport"
+)
+
+// IdempotentJobs contains the IDs of the segments which can be sent to the
+// institute more than once without side effects, i.e. dialog management and
+// jobs which only read data. Jobs not listed here, like payments, are never
+// resent.
+var IdempotentJobs = map[string]bool{
+	// Dialog management
+	"HKIDN": true,
+	"HKVVB": true,
+	"HKSYN": true,
+	"HKEND": true,
+	// Read jobs
+	"HKSAL": true,
+	"HKKAZ": true,
+	"HKCAZ": true,
+	"HKKIF": true,
+	"HKSPA": true,
+	"HKPRO": true,
+	"HKKOM": true,
+}
+
+// envelopeSegments frame, sign and encrypt the jobs of a message
+var envelopeSegments = map[string]bool{
+	"HNHBK": true,
+	"HNHBS": true,
+	"HNVSK": true,
+	"HNVSD": true,
+	"HNSHK": true,
+	"HNSHA": true,
+}
+
+// DefaultRetryPolicy is the RetryPolicy used if none is configured
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     3,
+	InitialBackoff: 1 * time.Second,
+	MaxBackoff:     30 * time.Second,
+	MaxElapsed:     2 * time.Minute,
+}
+
+// A RetryPolicy defines how often and how fast failed requests are retried.
+// The delay between two attempts starts with InitialBackoff and is doubled
+// for every retry, but does not exceed MaxBackoff.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries after the first attempt
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum delay between two attempts. If zero, the
+	// delay is not limited.
+	MaxBackoff time.Duration
+	// MaxElapsed is the maximum time spent on all attempts. No retry is
+	// started if it would begin after MaxElapsed. If zero, the time is not
+	// limited.
+	MaxElapsed time.Duration
+}
+
+// Limit returns a copy of p bounded by the timeouts of the bank parameter
+// data. The delay between two attempts does not exceed the MinTimeout and
+// all attempts have to be started within the MaxTimeout of the institute.
+// Timeouts not provided by the institute are ignored.
+func (p RetryPolicy) Limit(bpd domain.BankParameterData) RetryPolicy {
+	if minTimeout := time.Duration(bpd.MinTimeout) * time.Second; minTimeout > 0 {
+		if p.MaxBackoff == 0 || p.MaxBackoff > minTimeout {
+			p.MaxBackoff = minTimeout
+		}
+		if p.InitialBackoff > minTimeout {
+			p.InitialBackoff = minTimeout
+		}
+	}
+	if maxTimeout := time.Duration(bpd.MaxTimeout) * time.Second; maxTimeout > 0 {
+		if p.MaxElapsed == 0 || p.MaxElapsed > maxTimeout {
+			p.MaxElapsed = maxTimeout
+		}
+	}
+	return p
+}
+
+// Backoff returns the delay before the given retry, starting with 1
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < retry; i++ {
+		if p.MaxBackoff != 0 && backoff >= p.MaxBackoff {
+			break
+		}
+		backoff *= 2
+	}
+	if p.MaxBackoff != 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
+
+// Do calls fn until it succeeds, returns an error for which retryable
+// returns false or the limits of p are exceeded. It returns the error of the
+// last attempt.
+func (p RetryPolicy) Do(fn func() error, retryable func(error) bool) error {
+	start := time.Now()
+	for retry := 1; ; retry++ {
+		err := fn()
+		if err == nil || !retryable(err) || retry > p.MaxRetries {
+			return err
+		}
+		backoff := p.Backoff(retry)
+		if p.MaxElapsed != 0 && time.Since(start)+backoff > p.MaxElapsed {
+			return err
+		}
+		time.Sleep(backoff)
+	}
+}
+
+// IsTemporary returns true if err is a transport error which may not occur
+// again, i.e. a timeout, a temporary network error or a server error
+func IsTemporary(err error) bool {
+	if e, ok := err.(interface{ Timeout() bool }); ok && e.Timeout() {
+		return true
+	}
+	if e, ok := err.(interface{ Temporary() bool }); ok && e.Temporary() {
+		return true
+	}
+	return false
+}
+
+// IsIdempotent returns true if the marshaled message only contains
+// IdempotentJobs. Encrypted messages are inspected as well. Messages which
+// can not be parsed are not idempotent.
+func IsIdempotent(marshaledMessage []byte) bool {
+	ids, err := jobIDs(marshaledMessage)
+	if err != nil {
+		return false
+	}
+	for _, id := range ids {
+		if !IdempotentJobs[id] {
+			return false
+		}
+	}
+	return true
+}
+
+func jobIDs(marshaledMessage []byte) ([]string, error) {
+	segments, err := message.NewSegmentExtractor(marshaledMessage).Extract()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, segment := range segments {
+		idx := bytes.IndexByte(segment, ':')
+		if idx == -1 {
+			return nil, fmt.Errorf("Malformed segment: %q", segment)
+		}
+		id := string(segment[:idx])
+		if id == "HNVSD" {
+			start := bytes.IndexByte(segment, '@')
+			if start == -1 {
+				return nil, fmt.Errorf("Malformed encrypted data: %q", segment)
+			}
+			end := bytes.IndexByte(segment[start+1:], '@')
+			if end == -1 {
+				return nil, fmt.Errorf("Malformed encrypted data: %q", segment)
+			}
+			encryptedIDs, err := jobIDs(bytes.TrimSuffix(segment[start+end+2:], []byte("'")))
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, encryptedIDs...)
+			continue
+		}
+		if !envelopeSegments[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// Retry creates a middleware which retries requests failing with temporary
+// transport errors, like timeouts or HTTP status codes 5xx, as defined by
+// policy.
+//
+// Only requests consisting solely of IdempotentJobs are retried, as the
+// institute may have processed a request even if the response got lost.
+// Retry has to be applied to unencoded messages, i.e. above any encoding
+// middleware.
+//
+// Errors reported by the institute within a response, like a dialog abort,
+// can not be handled by resending a single message, as they end the dialog.
+// They have to be handled on the dialog level.
+func Retry(policy RetryPolicy) transport.Middleware {
+	return func(t transport.Transport) transport.Transport {
+		return transport.Func(func(req *transport.Request) (*transport.Response, error) {
+			marshaledRequest, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			retryable := func(err error) bool { return false }
+			if IsIdempotent(marshaledRequest) {
+				retryable = IsTemporary
+			}
+			var res *transport.Response
+			err = policy.Do(func() error {
+				req.Body = ioutil.NopCloser(bytes.NewReader(marshaledRequest))
+				var err error
+				res, err = t.Do(req)
+				return err
+			}, retryable)
+			return res, err
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitch000001/go-hbci/domain"
+	"github.com/mitch000001/go-hbci/transport"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	tests := []struct {
+		retry   int
+		backoff time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{100, 5 * time.Second},
+	}
+
+	for _, test := range tests {
+		backoff := policy.Backoff(test.retry)
+		if backoff != test.backoff {
+			t.Logf("Expected backoff for retry %d to equal %s, got %s\n", test.retry, test.backoff, backoff)
+			t.Fail()
+		}
+	}
+}
+
+func TestRetryPolicyLimit(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, InitialBackoff: 20 * time.Second, MaxBackoff: time.Minute, MaxElapsed: 10 * time.Minute}
+
+	limited := policy.Limit(domain.BankParameterData{MinTimeout: 10, MaxTimeout: 120})
+
+	expected := RetryPolicy{MaxRetries: 3, InitialBackoff: 10 * time.Second, MaxBackoff: 10 * time.Second, MaxElapsed: 2 * time.Minute}
+	if limited != expected {
+		t.Logf("Expected limited policy to equal\n%+v\n\tgot\n%+v\n", expected, limited)
+		t.Fail()
+	}
+
+	unlimited := policy.Limit(domain.BankParameterData{})
+	if unlimited != policy {
+		t.Logf("Expected policy to be unchanged without timeouts, got %+v\n", unlimited)
+		t.Fail()
+	}
+}
+
+func TestIsIdempotent(t *testing.T) {
+	tests := []struct {
+		message    string
+		idempotent bool
+	}{
+		{"HNHBK:1:3+000000000100+300+0+1'HKIDN:2:2+280:10000000+12345+0+1'HKVVB:3:3+0+0+0+go-hbci+0.1'HNHBS:4:1+1'", true},
+		{"HNHBK:1:3+000000000100+300+abc+2'HNVSK:998:3+PIN:1'HNVSD:999:1+@48@HKSAL:3:5+100000000::280:10000000+N'HNSHA:4:2+1''HNHBS:5:1+2'", true},
+		{"HNHBK:1:3+000000000100+300+abc+2'HNVSK:998:3+PIN:1'HNVSD:999:1+@34@HKCCS:3:1+DE89::12345'HNSHA:4:2+1''HNHBS:5:1+2'", false},
+		{"garbage", false},
+	}
+
+	for _, test := range tests {
+		idempotent := IsIdempotent([]byte(test.message))
+		if idempotent != test.idempotent {
+			t.Logf("Expected IsIdempotent to return %t for %q\n", test.idempotent, test.message)
+			t.Fail()
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond}
+
+	tests := []struct {
+		description string
+		message     string
+		errors      []error
+		calls       int
+		success     bool
+	}{
+		{
+			"idempotent message with temporary errors",
+			"HNHBK:1:3+000000000100+300+abc+2'HKSAL:2:5+100000000::280:10000000+N'HNHBS:3:1+2'",
+			[]error{&transport.StatusError{StatusCode: 503}, &transport.StatusError{StatusCode: 502}},
+			3,
+			true,
+		},
+		{
+			"idempotent message exceeding max retries",
+			"HNHBK:1:3+000000000100+300+abc+2'HKSAL:2:5+100000000::280:10000000+N'HNHBS:3:1+2'",
+			[]error{&transport.StatusError{StatusCode: 503}, &transport.StatusError{StatusCode: 503}, &transport.StatusError{StatusCode: 503}},
+			3,
+			false,
+		},
+		{
+			"idempotent message with permanent error",
+			"HNHBK:1:3+000000000100+300+abc+2'HKSAL:2:5+100000000::280:10000000+N'HNHBS:3:1+2'",
+			[]error{&transport.StatusError{StatusCode: 404}},
+			1,
+			false,
+		},
+		{
+			"payment message with temporary error",
+			"HNHBK:1:3+000000000100+300+abc+2'HKCCS:2:1+DE89::12345'HNHBS:3:1+2'",
+			[]error{&transport.StatusError{StatusCode: 503}},
+			1,
+			false,
+		},
+	}
+
+	for _, test := range tests {
+		var calls int
+		var bodies []string
+		fakeTransport := transport.Func(func(req *transport.Request) (*transport.Response, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			bodies = append(bodies, string(body))
+			calls++
+			if calls <= len(test.errors) {
+				return nil, test.errors[calls-1]
+			}
+			return &transport.Response{Body: ioutil.NopCloser(bytes.NewReader([]byte("HNHBK:1:3+abc'")))}, nil
+		})
+
+		_, err := Retry(policy)(fakeTransport).Do(&transport.Request{
+			Body: ioutil.NopCloser(strings.NewReader(test.message)),
+		})
+
+		if test.success && err != nil {
+			t.Logf("%s: Expected no error, got %T:%v\n", test.description, err, err)
+			t.Fail()
+		}
+		if !test.success && err == nil {
+			t.Logf("%s: Expected error, got nil\n", test.description)
+			t.Fail()
+		}
+		if calls != test.calls {
+			t.Logf("%s: Expected %d calls, got %d\n", test.description, test.calls, calls)
+			t.Fail()
+		}
+		for i, body := range bodies {
+			if body != test.message {
+				t.Logf("%s: Expected request %d to equal\n%q\n\tgot\n%q\n", test.description, i+1, test.message, body)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestIsTemporary(t *testing.T) {
+	tests := []struct {
+		err       error
+		temporary bool
+	}{
+		{&transport.StatusError{StatusCode: 500}, true},
+		{&transport.StatusError{StatusCode: 429}, true},
+		{&transport.StatusError{StatusCode: 400}, false},
+		{timeoutError{}, true},
+		{fmt.Errorf("connection refused"), false},
+	}
+
+	for _, test := range tests {
+		if temporary := IsTemporary(test.err); temporary != test.temporary {
+			t.Logf("Expected IsTemporary to return %t for %v\n", test.temporary, test.err)
+			t.Fail()
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "i/o timeout" }
+func (timeoutError) Timeout() bool { return true }
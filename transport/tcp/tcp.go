@@ -0,0 +1,213 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/go-hbci/transport"
+)
+
+// DefaultPort is the port HBCI servers listen on for native TCP access
+const DefaultPort = "3000"
+
+// DefaultTimeout is the timeout of a request if no other timeout is
+// configured. It covers the time from dialing until the response is read.
+const DefaultTimeout = 2 * time.Minute
+
+// maxMessageSize limits the size of a response to protect against malformed
+// message headers
+const maxMessageSize = 64 << 20
+
+// New returns a TCPTransport with the DefaultTimeout
+func New() *TCPTransport {
+	return &TCPTransport{
+		Timeout: DefaultTimeout,
+	}
+}
+
+// A TCPTransport implements transport.Transport and performs requests over
+// plain TCP connections as used by HBCI with RDH security. The messages are
+// sent as is, without any further encoding, and are delimited by the message
+// size of the message header.
+//
+// The URL of a request is the address of the server as "host:port". If the
+// port is missing, the DefaultPort is used. The connection is kept open and
+// reused for subsequent requests to the same address, as the messages of a
+// dialog have to be sent over the same connection. Close closes it.
+type TCPTransport struct {
+	// Dialer is used to establish connections. If nil, a net.Dialer with
+	// default values is used.
+	Dialer *net.Dialer
+	// Timeout limits the time of a request, including dialing, sending the
+	// request and reading the response. A timeout of zero means no timeout.
+	Timeout time.Duration
+	mu      sync.Mutex
+	address string
+	conn    net.Conn
+	reader  *bufio.Reader
+}
+
+// Do sends the request to the server and reads the response. If the server
+// closed the connection kept open since the last request, a new connection
+// is established before sending the request. The request is never sent
+// twice, as the server might have processed it already if the connection
+// breaks afterwards, so retries are left to the caller.
+func (t *TCPTransport) Do(request *transport.Request) (*transport.Response, error) {
+	marshaledRequest, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	address := Address(request.URL)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn != nil && t.address == address && t.closedByServer() {
+		t.closeConn()
+	}
+	marshaledResponse, err := t.roundTrip(address, marshaledRequest)
+	if err != nil {
+		return nil, err
+	}
+	return &transport.Response{
+		Body:    ioutil.NopCloser(bytes.NewReader(marshaledResponse)),
+		Request: request,
+	}, nil
+}
+
+func (t *TCPTransport) roundTrip(address string, marshaledRequest []byte) ([]byte, error) {
+	if t.conn == nil || t.address != address {
+		if err := t.connect(address); err != nil {
+			return nil, err
+		}
+	}
+	if t.Timeout != 0 {
+		if err := t.conn.SetDeadline(time.Now().Add(t.Timeout)); err != nil {
+			t.closeConn()
+			return nil, err
+		}
+	}
+	if _, err := t.conn.Write(marshaledRequest); err != nil {
+		t.closeConn()
+		return nil, err
+	}
+	marshaledResponse, err := ReadMessage(t.reader)
+	if err != nil {
+		t.closeConn()
+		if err == io.EOF {
+			return nil, fmt.Errorf("Connection closed before the response was received")
+		}
+		return nil, err
+	}
+	return marshaledResponse, nil
+}
+
+// closedByServer returns true if the server closed the open connection or
+// sent unexpected data on it. As the check happens before the request is
+// written, the request can safely be sent over a new connection then.
+func (t *TCPTransport) closedByServer() bool {
+	if t.reader.Buffered() != 0 {
+		return true
+	}
+	if err := t.conn.SetReadDeadline(time.Now().Add(time.Millisecond)); err != nil {
+		return true
+	}
+	_, err := t.reader.Peek(1)
+	if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		return true
+	}
+	return t.conn.SetReadDeadline(time.Time{}) != nil
+}
+
+func (t *TCPTransport) connect(address string) error {
+	t.closeConn()
+	dialer := t.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	if t.Timeout != 0 && (dialer.Timeout == 0 || dialer.Timeout > t.Timeout) {
+		d := *dialer
+		d.Timeout = t.Timeout
+		dialer = &d
+	}
+	conn, err := dialer.Dial("tcp", address)
+	if err != nil {
+		return err
+	}
+	t.address = address
+	t.conn = conn
+	t.reader = bufio.NewReader(conn)
+	return nil
+}
+
+func (t *TCPTransport) closeConn() error {
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	t.reader = nil
+	t.address = ""
+	return err
+}
+
+// Close closes the open connection, if any
+func (t *TCPTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closeConn()
+}
+
+// Address returns hbciURL as address to dial. The DefaultPort is added if
+// hbciURL has no port.
+func Address(hbciURL string) string {
+	if _, _, err := net.SplitHostPort(hbciURL); err == nil {
+		return hbciURL
+	}
+	return net.JoinHostPort(hbciURL, DefaultPort)
+}
+
+// ReadMessage reads a single HBCI message from r. The end of the message is
+// determined by the message size within the message header, which includes
+// the header itself.
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	// The message header starts with "HNHBK:1:3+<size>+", the segment header
+	// and the size do not contain escaped characters
+	segmentHeader, err := r.ReadBytes('+')
+	if err != nil {
+		if err == io.EOF && len(segmentHeader) != 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if !bytes.HasPrefix(segmentHeader, []byte("HNHBK:")) {
+		return nil, fmt.Errorf("Malformed message: expected message header, got %q", segmentHeader)
+	}
+	sizeElement, err := r.ReadBytes('+')
+	if err != nil {
+		return nil, fmt.Errorf("Malformed message header: %v", err)
+	}
+	size, err := strconv.Atoi(string(bytes.TrimSuffix(sizeElement, []byte("+"))))
+	if err != nil {
+		return nil, fmt.Errorf("Malformed message size: %q", sizeElement)
+	}
+	headerSize := len(segmentHeader) + len(sizeElement)
+	if size < headerSize || size > maxMessageSize {
+		return nil, fmt.Errorf("Malformed message size: %d", size)
+	}
+	message := make([]byte, size)
+	copy(message, segmentHeader)
+	copy(message[len(segmentHeader):], sizeElement)
+	if _, err := io.ReadFull(r, message[headerSize:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("Error while reading message: %v", err)
+	}
+	return message, nil
+}
@@ -0,0 +1,290 @@
+package transport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitch000001/go-hbci/transport"
+)
+
+// newMessage returns a message with the given segments and a correct message
+// size
+func newMessage(segments string) string {
+	header := func(size int) string {
+		return fmt.Sprintf("HNHBK:1:3+%012d+300+abc+1'", size)
+	}
+	size := len(header(0)) + len(segments)
+	return header(size) + segments
+}
+
+// fakeServer accepts connections and answers every message with the
+// response returned by respond. It writes the responses in small chunks to
+// exercise the framing. If respond returns an empty response, the
+// connection is closed without answering.
+type fakeServer struct {
+	listener    net.Listener
+	respond     func(request []byte) string
+	requests    chan string
+	connections chan net.Conn
+}
+
+func newFakeServer(t *testing.T, respond func(request []byte) string) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	server := &fakeServer{
+		listener:    listener,
+		respond:     respond,
+		requests:    make(chan string, 10),
+		connections: make(chan net.Conn, 10),
+	}
+	go server.serve()
+	return server
+}
+
+func (f *fakeServer) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		f.connections <- conn
+		go func() {
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for {
+				request, err := ReadMessage(reader)
+				if err != nil {
+					return
+				}
+				f.requests <- string(request)
+				response := f.respond(request)
+				if response == "" {
+					return
+				}
+				for len(response) > 0 {
+					n := 7
+					if n > len(response) {
+						n = len(response)
+					}
+					if _, err := io.WriteString(conn, response[:n]); err != nil {
+						return
+					}
+					response = response[n:]
+				}
+			}
+		}()
+	}
+}
+
+func (f *fakeServer) address() string {
+	return f.listener.Addr().String()
+}
+
+func (f *fakeServer) close() {
+	f.listener.Close()
+}
+
+func TestTCPTransportDo(t *testing.T) {
+	response := newMessage("HIRMG:2:2+0010::Nachricht entgegengenommen?+ok'HNHBS:3:1+1'")
+	server := newFakeServer(t, func([]byte) string { return response })
+	defer server.close()
+
+	tcpTransport := New()
+	defer tcpTransport.Close()
+
+	requests := []string{
+		newMessage("HKIDN:2:2+280:10000000+12345+0+1'HNHBS:3:1+1'"),
+		newMessage("HKEND:2:1+abc'HNHBS:3:1+2'"),
+	}
+	for _, request := range requests {
+		res, err := tcpTransport.Do(&transport.Request{
+			URL:  server.address(),
+			Body: ioutil.NopCloser(strings.NewReader(request)),
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %T:%v\n", err, err)
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Expected no error, got %T:%v\n", err, err)
+		}
+		if string(body) != response {
+			t.Logf("Expected response to equal\n%q\n\tgot\n%q\n", response, body)
+			t.Fail()
+		}
+		if received := <-server.requests; received != request {
+			t.Logf("Expected server to receive\n%q\n\tgot\n%q\n", request, received)
+			t.Fail()
+		}
+	}
+
+	if connections := len(server.connections); connections != 1 {
+		t.Logf("Expected requests to share one connection, got %d connections\n", connections)
+		t.Fail()
+	}
+}
+
+func TestTCPTransportReconnect(t *testing.T) {
+	response := newMessage("HIRMG:2:2+0010::Nachricht entgegengenommen'HNHBS:3:1+1'")
+	server := newFakeServer(t, func([]byte) string { return response })
+	defer server.close()
+
+	tcpTransport := New()
+	defer tcpTransport.Close()
+
+	request := newMessage("HKIDN:2:2+280:10000000+12345+0+1'HNHBS:3:1+1'")
+	do := func() error {
+		_, err := tcpTransport.Do(&transport.Request{
+			URL:  server.address(),
+			Body: ioutil.NopCloser(strings.NewReader(request)),
+		})
+		return err
+	}
+
+	if err := do(); err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	// The server closes the connection after the dialog
+	(<-server.connections).Close()
+
+	if err := do(); err != nil {
+		t.Logf("Expected no error after server closed connection, got %T:%v\n", err, err)
+		t.Fail()
+	}
+}
+
+func TestTCPTransportNoResendAfterWrite(t *testing.T) {
+	response := newMessage("HIRMG:2:2+0010::Nachricht entgegengenommen'HNHBS:3:1+1'")
+	calls := 0
+	server := newFakeServer(t, func([]byte) string {
+		calls++
+		// The server closes the connection after receiving the second
+		// request, which might have been processed already
+		if calls == 2 {
+			return ""
+		}
+		return response
+	})
+	defer server.close()
+
+	tcpTransport := New()
+	defer tcpTransport.Close()
+
+	do := func(request string) error {
+		_, err := tcpTransport.Do(&transport.Request{
+			URL:  server.address(),
+			Body: ioutil.NopCloser(strings.NewReader(request)),
+		})
+		return err
+	}
+
+	if err := do(newMessage("HKIDN:2:2+280:10000000+12345+0+1'HNHBS:3:1+1'")); err != nil {
+		t.Fatalf("Expected no error, got %T:%v\n", err, err)
+	}
+	<-server.requests
+
+	err := do(newMessage("HKCCS:2:1+DE12345678901234567890:ABCDEFGH'HNHBS:3:1+2'"))
+
+	if err == nil {
+		t.Logf("Expected error, got nil\n")
+		t.Fail()
+	}
+	<-server.requests
+	select {
+	case request := <-server.requests:
+		t.Logf("Expected request not to be sent again, got %q\n", request)
+		t.Fail()
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTCPTransportTimeout(t *testing.T) {
+	server := newFakeServer(t, func([]byte) string {
+		time.Sleep(200 * time.Millisecond)
+		return newMessage("HNHBS:2:1+1'")
+	})
+	defer server.close()
+
+	tcpTransport := &TCPTransport{Timeout: 20 * time.Millisecond}
+	defer tcpTransport.Close()
+
+	_, err := tcpTransport.Do(&transport.Request{
+		URL:  server.address(),
+		Body: ioutil.NopCloser(strings.NewReader(newMessage("HNHBS:2:1+1'"))),
+	})
+
+	if err == nil {
+		t.Logf("Expected error, got nil\n")
+		t.Fail()
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	message := newMessage("HIRMG:2:2+0010::Text mit ?+ und ?''HNHBS:3:1+1'")
+
+	tests := []struct {
+		description string
+		input       string
+		messages    []string
+		err         bool
+	}{
+		{"single message", message, []string{message}, false},
+		{"two messages", message + message, []string{message, message}, false},
+		{"truncated message", message[:len(message)-5], nil, true},
+		{"wrong size", "HNHBK:1:3+000000000010+300+abc+1'", nil, true},
+		{"no message header", "HIRMG:2:2+0010'", nil, true},
+	}
+
+	for _, test := range tests {
+		reader := bufio.NewReader(strings.NewReader(test.input))
+		var messages []string
+		var err error
+		for {
+			var msg []byte
+			msg, err = ReadMessage(reader)
+			if err != nil {
+				break
+			}
+			messages = append(messages, string(msg))
+		}
+		if !reflect.DeepEqual(test.messages, messages) {
+			t.Logf("%s: Expected messages to equal\n%q\n\tgot\n%q\n", test.description, test.messages, messages)
+			t.Fail()
+		}
+		if test.err && err == io.EOF {
+			t.Logf("%s: Expected error, got EOF\n", test.description)
+			t.Fail()
+		}
+		if !test.err && err != io.EOF {
+			t.Logf("%s: Expected EOF, got %T:%v\n", test.description, err, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		url     string
+		address string
+	}{
+		{"hbci.example.com", "hbci.example.com:3000"},
+		{"hbci.example.com:3001", "hbci.example.com:3001"},
+		{"10.0.0.1", "10.0.0.1:3000"},
+	}
+
+	for _, test := range tests {
+		if address := Address(test.url); address != test.address {
+			t.Logf("Expected address of %q to equal %q, got %q\n", test.url, test.address, address)
+			t.Fail()
+		}
+	}
+}
@@ -3,6 +3,7 @@ package transport
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 
@@ -30,6 +31,25 @@ func (fn Func) Do(req *Request) (*Response, error) {
 // Middleware defines the interface for writing middleware for transports
 type Middleware func(Transport) Transport
 
+// A StatusError is returned by transports if the server answers a request
+// with an error status, e.g. a HTTP status code other than 200
+type StatusError struct {
+	// StatusCode is the status code returned by the server
+	StatusCode int
+	// Status is the human readable status, e.g. "503 Service Unavailable"
+	Status string
+}
+
+func (s *StatusError) Error() string {
+	return fmt.Sprintf("Server returned status %s", s.Status)
+}
+
+// Temporary returns true if the server signals a temporary condition, i.e. a
+// server error or too many requests
+func (s *StatusError) Temporary() bool {
+	return s.StatusCode >= 500 || s.StatusCode == 408 || s.StatusCode == 429
+}
+
 // A Request represents a client request to a HBCI server
 type Request struct {
 	// URL specifies the URI being requested